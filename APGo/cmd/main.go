@@ -1,11 +1,23 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/AmandaChou/RedisLab/APGo/internal/config"
 	"github.com/AmandaChou/RedisLab/APGo/internal/controller"
+	"github.com/AmandaChou/RedisLab/APGo/internal/metrics"
+	"github.com/AmandaChou/RedisLab/APGo/internal/registry"
+	"github.com/AmandaChou/RedisLab/APGo/internal/reload"
+	"github.com/AmandaChou/RedisLab/APGo/internal/tracing"
 	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
 	"github.com/gin-gonic/gin"
 )
@@ -20,12 +32,67 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// 建立 trace exporter（未設定 tracing.exporter 時不記錄 span）
+	traceProvider, err := cfg.SetupTracing(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer traceProvider.Shutdown(context.Background())
+
 	// 建立 Redis 連線（根據 config.yaml 的 redis.mode 自動選擇實作）
-	redisConn, err = cfg.ConnectRedis()
+	// 以 metrics 裝飾器包裝連線，記錄每個操作的次數、延遲與錯誤；熱重載建立的連線也使用相同的包裝
+	apiMetrics := metrics.NewMetrics()
+	connect := func(cfg *config.Config) (redislib.IRedisConn, error) {
+		conn, err := cfg.ConnectRedis()
+		if err != nil {
+			return nil, err
+		}
+		if traceProvider.Enabled() {
+			conn = tracing.NewTracedConn(conn, cfg.Redis.Mode)
+		}
+		return apiMetrics.Instrument(conn, cfg.Redis.Mode), nil
+	}
+	conn, err := connect(cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to Redis: %v", err)
 	}
-	defer redisConn.Close()
+	// 設定變更時在背景替換連線，舊連線等進行中的請求完成後才關閉
+	swappable := reload.NewSwappableConn(conn, cfg.Server.ShutdownTimeout)
+	redisConn = swappable
+	reloader := reload.NewReloader(cfg, swappable, connect)
+	if cfg.Server.WatchConfig {
+		files, err := config.WatchConfig(reloader.OnConfigChange)
+		if err != nil {
+			log.Printf("Warning: config hot-reload disabled: %v", err)
+		} else {
+			log.Printf("Watching %s for Redis config changes", strings.Join(files, ", "))
+		}
+	}
+
+	// 建立具名後端的連線（redis.backends），與預設連線一起以 /backends/:name 存取
+	backends := registry.New()
+	if err := backends.Register(config.DefaultBackend, redisConn); err != nil {
+		redisConn.Close()
+		log.Fatalf("Failed to register default backend: %v", err)
+	}
+	for _, name := range cfg.BackendNames() {
+		backendCfg, err := cfg.Backend(name)
+		if err != nil {
+			backends.Close()
+			log.Fatalf("Failed to load backend %s: %v", name, err)
+		}
+		conn, err := connect(backendCfg)
+		if err != nil {
+			backends.Close()
+			log.Fatalf("Failed to connect to backend %s: %v", name, err)
+		}
+		if err := backends.Register(name, conn); err != nil {
+			conn.Close()
+			backends.Close()
+			log.Fatalf("Failed to register backend %s: %v", name, err)
+		}
+		log.Printf("Connected to backend %s with Redis mode: %s", name, backendCfg.Redis.Mode)
+	}
 
 	// 設定 Gin 模式
 	if cfg.Server.Mode == "release" {
@@ -34,40 +101,181 @@ func main() {
 
 	// 初始化 Gin 引擎
 	router := gin.Default()
+	router.Use(apiMetrics.Middleware())
+	if traceProvider.Enabled() {
+		router.Use(tracing.Middleware())
+	}
+	// LoadConfig 已檢查過 route_timeouts 格式
+	routeTimeouts, _ := cfg.Server.RouteTimeoutMap()
+	router.Use(controller.RequestTimeout(cfg.Server.RequestTimeout, routeTimeouts))
+
+	if cfg.Server.Admin.ModeSwitch && cfg.Server.Admin.Token == "" {
+		log.Printf("Warning: POST /admin/mode is enabled without server.admin.token")
+	}
 
 	// 設定基本路由
-	setupRoutes(router, redisConn)
+	setupRoutes(router, redisConn, backends, reloader, cfg.Server.Admin, apiMetrics)
+
+	// 啟動服務器，收到 SIGINT / SIGTERM 時停止接受新請求並等待進行中的請求完成
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// 啟動服務器
+	srv := &http.Server{
+		Addr:    cfg.GetServerAddr(),
+		Handler: router,
+	}
 	log.Printf("Starting server on %s with Redis mode: %s",
 		cfg.GetServerAddr(), cfg.Redis.Mode)
-	if err := router.Run(cfg.GetServerAddr()); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	serveErr := runServer(ctx, srv, cfg.Server.ShutdownTimeout)
+
+	// 所有請求結束後才關閉 Redis 連線（包含具名後端），避免進行中的請求使用已關閉的連線
+	if err := backends.Close(); err != nil {
+		log.Printf("Failed to close Redis connection: %v", err)
+	}
+	if serveErr != nil {
+		traceProvider.Shutdown(context.Background())
+		log.Fatalf("Failed to start server: %v", serveErr)
+	}
+	log.Printf("Server stopped")
+}
+
+// runServer 啟動 srv 直到 ctx 結束，再於 timeout 內等待進行中的請求完成
+// 超過 timeout 時取消所有請求的 context 並強制關閉剩下的連線（timeout < 0 表示一直等待）；
+// 返回前所有 handler 都已結束，呼叫端可以安全地關閉 Redis 連線。只有啟動失敗時回傳錯誤
+func runServer(ctx context.Context, srv *http.Server, timeout time.Duration) error {
+	// 請求的 context 衍生自 baseCtx，逾時後取消讓 Redis 操作與 FillCluster 儘快返回
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	srv.BaseContext = func(net.Listener) context.Context { return baseCtx }
+
+	// srv.Close 不會等待 handler 結束，另外記錄進行中的 handler
+	var handlers sync.WaitGroup
+	handler := srv.Handler
+	srv.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlers.Add(1)
+		defer handlers.Done()
+		handler.ServeHTTP(w, r)
+	})
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
 	}
+
+	log.Printf("Shutting down server, draining in-flight requests (timeout %s)", timeout)
+	shutdownCtx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, timeout)
+		defer cancel()
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Warning: in-flight requests did not finish in time, canceling them and closing connections: %v", err)
+		cancelRequests()
+		srv.Close()
+	}
+	<-serveErr
+	handlers.Wait()
+	return nil
 }
 
 // setupRoutes 設定所有路由
-func setupRoutes(router *gin.Engine, redisConn redislib.IRedisConn) {
+func setupRoutes(router *gin.Engine, redisConn redislib.IRedisConn, backends *registry.Registry,
+	switcher controller.ModeSwitcher, admin config.AdminConfig, apiMetrics *metrics.Metrics) {
 	// 建立 CacheController
 	cacheController := controller.NewCacheController(redisConn)
+	adminController := controller.NewAdminController(switcher, redisConn)
+	backendController := controller.NewBackendController(backends)
 
 	// 健康檢查端點
 	router.GET("/health", healthCheck)
+	router.GET("/health/live", cacheController.GetLiveness)
+	router.GET("/health/ready", cacheController.GetReadiness)
 
 	// Cache API 路由
 	router.GET("/cache", cacheController.GetCache)
 	router.POST("/cache", cacheController.UpdateCache)
+	router.DELETE("/cache", cacheController.DeleteCache)
+	router.HEAD("/cache", cacheController.HeadCache)
+	router.GET("/cache/batch", cacheController.GetCacheBatch)
+	router.POST("/cache/batch", cacheController.UpdateCacheBatch)
+	router.GET("/cache/ttl", cacheController.GetCacheTTL)
+	router.POST("/cache/ttl", cacheController.UpdateCacheTTL)
 	router.GET("/fillcluster", cacheController.FillCluster)
+
+	// 拓樸路由
+	router.GET("/topology", cacheController.GetTopology)
+
+	// Sentinel 路由
+	router.GET("/sentinel/failovers", cacheController.GetFailoverHistory)
+
+	// 具名後端路由：與上方路由相同，以 :name 選擇後端
+	router.GET("/backends", backendController.ListBackends)
+	backend := router.Group("/backends/:name")
+	backend.GET("/health/ready", backendController.Handle((*controller.CacheController).GetReadiness))
+	backend.GET("/cache", backendController.Handle((*controller.CacheController).GetCache))
+	backend.POST("/cache", backendController.Handle((*controller.CacheController).UpdateCache))
+	backend.DELETE("/cache", backendController.Handle((*controller.CacheController).DeleteCache))
+	backend.HEAD("/cache", backendController.Handle((*controller.CacheController).HeadCache))
+	backend.GET("/cache/batch", backendController.Handle((*controller.CacheController).GetCacheBatch))
+	backend.POST("/cache/batch", backendController.Handle((*controller.CacheController).UpdateCacheBatch))
+	backend.GET("/cache/ttl", backendController.Handle((*controller.CacheController).GetCacheTTL))
+	backend.POST("/cache/ttl", backendController.Handle((*controller.CacheController).UpdateCacheTTL))
+	backend.GET("/fillcluster", backendController.Handle((*controller.CacheController).FillCluster))
+	backend.GET("/topology", backendController.Handle((*controller.CacheController).GetTopology))
+	backend.GET("/sentinel/failovers", backendController.Handle((*controller.CacheController).GetFailoverHistory))
+
+	// 管理路由
+	router.GET("/admin/mode", adminController.GetMode)
+	router.POST("/admin/mode", controller.RequireModeSwitch(admin.ModeSwitch, admin.Token), adminController.SwitchMode)
+
+	// Prometheus 指標
+	router.GET("/metrics", apiMetrics.Handler())
 }
 
 // healthCheck 健康檢查處理器
+// 以 Topology 判斷狀態：所有節點健康為 healthy，部分節點不健康為 degraded，
+// 沒有健康的 Master / Leader 或無法取得拓樸時為 unhealthy（503）
 func healthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":          "healthy",
+	topology, err := redisConn.Topology(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":  "unhealthy",
+			"service": "APGo Redis API",
+			"version": "1.0.0",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	status, code := "healthy", http.StatusOK
+	masterEndpoint := ""
+	for _, primary := range topology.Primaries() {
+		if primary.Healthy && masterEndpoint == "" {
+			masterEndpoint = primary.Address
+		}
+	}
+	switch {
+	case masterEndpoint == "":
+		status, code = "unhealthy", http.StatusServiceUnavailable
+	case topology.HealthyCount() < len(topology.Nodes):
+		status = "degraded"
+	}
+
+	c.JSON(code, gin.H{
+		"status":          status,
 		"service":         "APGo Redis API",
 		"version":         "1.0.0",
-		"redis_mode":      "connected",
-		"master_endpoint": redisConn.GetMasterEndpoint(),
-		"slave_endpoint":  redisConn.GetSlaveEndpoint(),
+		"redis_mode":      topology.Mode,
+		"master_endpoint": masterEndpoint,
+		"nodes_total":     len(topology.Nodes),
+		"nodes_healthy":   topology.HealthyCount(),
+		"nodes":           topology.Nodes,
 	})
 }
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+// DefaultBackend 預設連線（redis 區段本身）在 /backends/:name 中的名稱
+const DefaultBackend = "default"
+
+// backendNamePattern 後端名稱的格式，需要可以直接放在 URL 路徑中
+var backendNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// BackendConfig 具名的 Redis 後端，格式與 redis 區段相同
+// 沒有設定的模式區段（master_slave / sentinel / cluster / raft / in_memory）與共用連線設定沿用 redis 區段，
+// 因此只設定 mode 即可使用 redis 區段中該模式的節點
+type BackendConfig struct {
+	Name        string `mapstructure:"name"`
+	RedisConfig `mapstructure:",squash"`
+}
+
+// resolveBackends 檢查每個後端的名稱與模式，補上沿用 redis 區段的設定，並讀取密碼
+// 呼叫前 redis 區段本身須已讀取密碼
+func (r *RedisConfig) resolveBackends() error {
+	seen := map[string]bool{DefaultBackend: true}
+	for i := range r.Backends {
+		b := &r.Backends[i]
+		switch {
+		case !backendNamePattern.MatchString(b.Name):
+			return fmt.Errorf("invalid backend %q: name must contain only letters, digits, '-' and '_'", b.Name)
+		case seen[b.Name]:
+			return fmt.Errorf("invalid backend %q: duplicate or reserved name", b.Name)
+		case len(b.Backends) > 0:
+			return fmt.Errorf("invalid backend %q: backends cannot be nested", b.Name)
+		}
+		seen[b.Name] = true
+
+		if _, err := redislib.ParseRedisMode(b.Mode); err != nil {
+			return fmt.Errorf("invalid backend %q: %w: %q", b.Name, err, b.Mode)
+		}
+		// 先讀取後端自己的密碼檔或環境變數，沿用的共用帳號密碼才不會與之衝突
+		if err := b.resolveCredentials(); err != nil {
+			return fmt.Errorf("invalid backend %q: %w", b.Name, err)
+		}
+		b.RedisConfig = r.inherit(b.RedisConfig)
+		if err := b.Validate(); err != nil {
+			return fmt.Errorf("invalid backend %q: %w", b.Name, err)
+		}
+	}
+	return nil
+}
+
+// inherit 以 r 補上 backend 沒有設定的區段，共用連線設定則逐欄補上
+func (r RedisConfig) inherit(backend RedisConfig) RedisConfig {
+	return RedisConfig{
+		Mode:         backend.Mode,
+		ClientConfig: backend.ClientConfig.merge(r.ClientConfig),
+		MasterSlave:  orDefault(backend.MasterSlave, r.MasterSlave),
+		Sentinel:     orDefault(backend.Sentinel, r.Sentinel),
+		Cluster:      orDefault(backend.Cluster, r.Cluster),
+		Raft:         orDefault(backend.Raft, r.Raft),
+		InMemory:     orDefault(backend.InMemory, r.InMemory),
+	}
+}
+
+// orDefault v 為零值時返回 defaults
+func orDefault[T any](v, defaults T) T {
+	if reflect.ValueOf(v).IsZero() {
+		return defaults
+	}
+	return v
+}
+
+// Backend 取得具名後端的設定，其他區段（server、tracing）與 c 相同
+func (c *Config) Backend(name string) (*Config, error) {
+	for _, b := range c.Redis.Backends {
+		if b.Name == name {
+			backend := *c
+			backend.Redis = b.RedisConfig
+			return &backend, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown backend %q", name)
+}
+
+// BackendNames 依設定順序列出具名後端的名稱（不含 DefaultBackend）
+func (c *Config) BackendNames() []string {
+	names := make([]string, 0, len(c.Redis.Backends))
+	for _, b := range c.Redis.Backends {
+		names = append(names, b.Name)
+	}
+	return names
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// unmarshalConfig 從 YAML 字串解析設定
+func unmarshalConfig(t *testing.T, yaml string) Config {
+	t.Helper()
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(yaml)); err != nil {
+		t.Fatalf("ReadConfig failed: %v", err)
+	}
+	var config Config
+	if err := v.Unmarshal(&config); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	return config
+}
+
+func TestBackendConfig_Unmarshal(t *testing.T) {
+	config := unmarshalConfig(t, `
+redis:
+  mode: RedisMasterSlaves
+  pool_size: 20
+  dial_timeout: 2s
+  password: shared
+  master_slave:
+    master: "127.0.0.1:6379"
+  sentinel:
+    master_name: mymaster
+    sentinels: ["127.0.0.1:26379"]
+  cluster:
+    nodes: ["127.0.0.1:7000"]
+  backends:
+    - name: sentinel
+      mode: RedisSentinel
+    - name: cluster-b
+      mode: RedisCluster
+      pool_size: 5
+      cluster:
+        nodes: ["10.0.0.1:7000", "10.0.0.2:7000"]
+`)
+	if err := config.Redis.resolveBackends(); err != nil {
+		t.Fatalf("resolveBackends() error = %v", err)
+	}
+	if got := config.BackendNames(); len(got) != 2 || got[0] != "sentinel" || got[1] != "cluster-b" {
+		t.Fatalf("Expected backends [sentinel cluster-b], got %v", got)
+	}
+
+	// 只設定 mode 的後端沿用 redis 區段的節點與共用連線設定
+	sentinel, err := config.Backend("sentinel")
+	if err != nil {
+		t.Fatalf("Backend() error = %v", err)
+	}
+	if sentinel.Redis.Mode != "RedisSentinel" || sentinel.Redis.Sentinel.MasterName != "mymaster" || sentinel.Redis.PoolSize != 20 {
+		t.Errorf("Expected sentinel backend to inherit redis settings, got %+v", sentinel.Redis)
+	}
+	if len(sentinel.Redis.Backends) != 0 {
+		t.Errorf("Backend config must not contain backends, got %v", sentinel.Redis.Backends)
+	}
+
+	// 有設定的區段整個覆寫 redis 區段
+	cluster, err := config.Backend("cluster-b")
+	if err != nil {
+		t.Fatalf("Backend() error = %v", err)
+	}
+	if len(cluster.Redis.Cluster.Nodes) != 2 || cluster.Redis.PoolSize != 5 {
+		t.Errorf("Expected cluster-b to use its own nodes and pool size, got %+v", cluster.Redis)
+	}
+	// 共用連線設定逐欄沿用，只覆寫後端有設定的欄位
+	if cluster.Redis.DialTimeout != 2*time.Second || cluster.Redis.Password != "shared" {
+		t.Errorf("Expected cluster-b to inherit dial_timeout and password, got %+v", cluster.Redis.ClientConfig)
+	}
+
+	if _, err := config.Backend("raft"); err == nil {
+		t.Error("Expected error for unknown backend")
+	}
+}
+
+func TestResolveBackends_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"missing name", "- mode: RedisCluster", "name must contain"},
+		{"name with slash", "- name: a/b\n  mode: RedisCluster", "name must contain"},
+		{"reserved name", "- name: default\n  mode: RedisCluster", "duplicate or reserved"},
+		{"duplicate name", "- name: a\n  mode: RedisCluster\n- name: a\n  mode: RedisRaft", "duplicate or reserved"},
+		{"invalid mode", "- name: a\n  mode: RedisMemcached", "invalid redis mode"},
+		{"nested backends", "- name: a\n  mode: RedisCluster\n  backends:\n    - name: b\n      mode: RedisRaft", "cannot be nested"},
+		{"invalid client config", "- name: a\n  mode: RedisCluster\n  pool_size: -1", "invalid redis client config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indented := "    " + strings.ReplaceAll(tt.yaml, "\n", "\n    ")
+			config := unmarshalConfig(t, "redis:\n  mode: RedisCluster\n  backends:\n"+indented+"\n")
+			err := config.Redis.resolveBackends()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("resolveBackends() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveBackends_OwnPasswordEnv(t *testing.T) {
+	t.Setenv("BACKEND_PASSWORD", "backend-secret")
+	config := unmarshalConfig(t, `
+redis:
+  mode: RedisCluster
+  password: shared
+  backends:
+    - name: a
+      mode: RedisCluster
+      password_env: BACKEND_PASSWORD
+`)
+	if err := config.Redis.resolveBackends(); err != nil {
+		t.Fatalf("resolveBackends() error = %v", err)
+	}
+	backend, err := config.Backend("a")
+	if err != nil {
+		t.Fatalf("Backend() error = %v", err)
+	}
+	if backend.Redis.Password != "backend-secret" {
+		t.Errorf("Expected the backend's own password, got %q", backend.Redis.Password)
+	}
+}
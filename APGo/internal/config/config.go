@@ -1,34 +1,81 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/AmandaChou/RedisLab/APGo/internal/redis"
+	"github.com/AmandaChou/RedisLab/APGo/internal/tracing"
 	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
 	"github.com/spf13/viper"
 )
 
 // Config 應用程式設定
 type Config struct {
-	Server ServerConfig `mapstructure:"server"`
-	Redis  RedisConfig  `mapstructure:"redis"`
+	Server  ServerConfig  `mapstructure:"server"`
+	Redis   RedisConfig   `mapstructure:"redis"`
+	Tracing TracingConfig `mapstructure:"tracing"`
 }
 
+// DefaultRequestTimeout 未設定 server.request_timeout 時每個請求的逾時
+const DefaultRequestTimeout = 10 * time.Second
+
+// DefaultShutdownTimeout 未設定 server.shutdown_timeout 時等待進行中請求完成的時間
+const DefaultShutdownTimeout = 30 * time.Second
+
 // ServerConfig 服務器設定
 type ServerConfig struct {
 	Port int    `mapstructure:"port"`
 	Mode string `mapstructure:"mode"` // debug, release, test
+	// RequestTimeout 每個請求的預設逾時（例如 "5s"），未設定使用 DefaultRequestTimeout，負數表示不限制
+	RequestTimeout time.Duration `mapstructure:"request_timeout"`
+	// RouteTimeouts 覆寫個別路由的逾時
+	RouteTimeouts []RouteTimeout `mapstructure:"route_timeouts"`
+	// ShutdownTimeout 收到 SIGINT / SIGTERM 後等待進行中請求完成的時間，超過時強制關閉連線
+	// 未設定使用 DefaultShutdownTimeout，負數表示等到所有請求完成
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// WatchConfig 監看設定檔，redis 設定變更時不需重新啟動即替換連線（也用 ShutdownTimeout 等待舊連線的請求）
+	WatchConfig bool `mapstructure:"watch_config"`
+	// Admin 管理 API 設定
+	Admin AdminConfig `mapstructure:"admin"`
+}
+
+// AdminConfig 管理 API 設定
+type AdminConfig struct {
+	// ModeSwitch 允許以 POST /admin/mode 切換 Redis 模式，預設停用
+	ModeSwitch bool `mapstructure:"mode_switch"`
+	// Token 設定後 POST /admin/mode 需要帶有 "Authorization: Bearer <token>"
+	// TokenFile / TokenEnv 從檔案或環境變數讀取，與 token 只能設定其中一種
+	Token     string `mapstructure:"token"`
+	TokenFile string `mapstructure:"token_file"`
+	TokenEnv  string `mapstructure:"token_env"`
+}
+
+// RouteTimeout 單一路由的逾時
+// 以列表而非 map 設定，因為路徑中的 "/" 與 "." 無法作為 viper 的鍵
+type RouteTimeout struct {
+	Route   string        `mapstructure:"route"`   // "METHOD /path"，例如 "GET /fillcluster"
+	Timeout time.Duration `mapstructure:"timeout"` // 負數表示不限制
 }
 
 // RedisConfig Redis 設定
 type RedisConfig struct {
-	Mode        string                 `mapstructure:"mode"`
-	MasterSlave MasterSlaveConfig      `mapstructure:"master_slave"`
-	Sentinel    SentinelConfig         `mapstructure:"sentinel"`
-	Cluster     ClusterConfig          `mapstructure:"cluster"`
-	Raft        RaftConfig             `mapstructure:"raft"`
+	Mode string `mapstructure:"mode"`
+	// ClientConfig 各模式共用的連線設定預設值，模式中有設定的欄位優先
+	ClientConfig `mapstructure:",squash"`
+
+	MasterSlave MasterSlaveConfig `mapstructure:"master_slave"`
+	Sentinel    SentinelConfig    `mapstructure:"sentinel"`
+	Cluster     ClusterConfig     `mapstructure:"cluster"`
+	Raft        RaftConfig        `mapstructure:"raft"`
+	InMemory    InMemoryConfig    `mapstructure:"in_memory"`
+
+	// Backends 與上述預設連線同時存在的具名連線，以 /backends/:name 存取
+	// 以列表而非 map 設定，因為 viper 的鍵不分大小寫
+	Backends []BackendConfig `mapstructure:"backends"`
 }
 
 // MasterSlaveConfig 主從模式設定
@@ -36,6 +83,50 @@ type MasterSlaveConfig struct {
 	Description string   `mapstructure:"description"`
 	Master      string   `mapstructure:"master"`
 	Slaves      []string `mapstructure:"slaves"`
+	// ReadPreference 讀取偏好：primary, primary_preferred, replica, replica_preferred（預設）,
+	// nearest, round_robin, weighted
+	ReadPreference string          `mapstructure:"read_preference"`
+	ReplicaWeights []ReplicaWeight `mapstructure:"replica_weights"` // weighted 使用
+	// HealthCheckInterval Replica 健康檢查間隔（例如 "5s"），未設定使用預設值，負數表示停用
+	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
+	// UnhealthyThreshold 連續失敗幾次後將 Replica 移出讀取輪替
+	UnhealthyThreshold int `mapstructure:"unhealthy_threshold"`
+	// MaxStaleness 預設可接受的 Replica 複寫時間落差（例如 "500ms"），未設定表示不限制
+	MaxStaleness time.Duration `mapstructure:"max_staleness"`
+	// MaxLagBytes 預設可接受的 Replica 複寫 offset 落差，未設定表示不限制
+	MaxLagBytes int64 `mapstructure:"max_lag_bytes"`
+
+	ClientConfig `mapstructure:",squash"`
+}
+
+// ClientConfig 連線池、逾時、重試與驗證設定
+// 未設定（零值）的欄位依序使用 redis 層級的共用設定與 go-redis 的預設值
+type ClientConfig struct {
+	Username string `mapstructure:"username"` // ACL 使用者名稱，需要同時設定 password
+	Password string `mapstructure:"password"`
+	// PasswordFile / PasswordEnv 從檔案或環境變數讀取密碼，與 password 只能設定其中一種
+	PasswordFile string `mapstructure:"password_file"`
+	PasswordEnv  string `mapstructure:"password_env"`
+
+	TLS TLSConfig `mapstructure:"tls"`
+
+	PoolSize     int `mapstructure:"pool_size"`      // 每個節點的連線池大小
+	MinIdleConns int `mapstructure:"min_idle_conns"` // 每個節點保留的最少閒置連線，不可超過 pool_size
+
+	DialTimeout  time.Duration `mapstructure:"dial_timeout"`  // 例如 "5s"
+	ReadTimeout  time.Duration `mapstructure:"read_timeout"`  // 負數表示不限制
+	WriteTimeout time.Duration `mapstructure:"write_timeout"` // 負數表示不限制
+
+	MaxRetries      int           `mapstructure:"max_retries"`       // -1 表示不重試
+	MinRetryBackoff time.Duration `mapstructure:"min_retry_backoff"` // 負數表示不等待
+	MaxRetryBackoff time.Duration `mapstructure:"max_retry_backoff"` // 負數表示不等待
+}
+
+// ReplicaWeight Replica 讀取權重
+// 以列表而非 map 設定，因為端點中的 "." 會被 viper 當成巢狀鍵
+type ReplicaWeight struct {
+	Endpoint string `mapstructure:"endpoint"`
+	Weight   int    `mapstructure:"weight"`
 }
 
 // SentinelConfig Sentinel 設定
@@ -43,37 +134,101 @@ type SentinelConfig struct {
 	Description string   `mapstructure:"description"`
 	MasterName  string   `mapstructure:"master_name"`
 	Sentinels   []string `mapstructure:"sentinels"`
+	// ReplicaReads 啟用後讀取改由 Sentinel 回報的 Replica 提供
+	ReplicaReads bool `mapstructure:"replica_reads"`
+	// ReplicaSelection Replica 選擇方式：random（預設）或 latency
+	ReplicaSelection string `mapstructure:"replica_selection"`
+	// HealthCheckInterval 啟用 replica_reads 時的 Replica 健康檢查與複寫落差量測間隔，未設定使用預設值，負數表示停用
+	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
+	// UnhealthyThreshold 連續失敗幾次後將 Replica 移出讀取輪替
+	UnhealthyThreshold int `mapstructure:"unhealthy_threshold"`
+	// MaxStaleness 啟用 replica_reads 時預設可接受的 Replica 複寫時間落差，未設定表示不限制
+	MaxStaleness time.Duration `mapstructure:"max_staleness"`
+	// MaxLagBytes 啟用 replica_reads 時預設可接受的 Replica 複寫 offset 落差，未設定表示不限制
+	MaxLagBytes int64 `mapstructure:"max_lag_bytes"`
+	// SentinelUsername / SentinelPassword Sentinel 本身的驗證，與 Master 的 username / password 分開
+	SentinelUsername     string `mapstructure:"sentinel_username"`
+	SentinelPassword     string `mapstructure:"sentinel_password"`
+	SentinelPasswordFile string `mapstructure:"sentinel_password_file"`
+	SentinelPasswordEnv  string `mapstructure:"sentinel_password_env"`
+
+	ClientConfig `mapstructure:",squash"`
 }
 
 // ClusterConfig Cluster 設定
 type ClusterConfig struct {
 	Description string   `mapstructure:"description"`
 	Nodes       []string `mapstructure:"nodes"`
+
+	ClientConfig `mapstructure:",squash"`
 }
 
 // RaftConfig Raft 設定
 type RaftConfig struct {
 	Description string   `mapstructure:"description"`
 	Nodes       []string `mapstructure:"nodes"`
+
+	ClientConfig `mapstructure:",squash"`
 }
 
-// LoadConfig 載入設定檔
-func LoadConfig() (*Config, error) {
+// TracingConfig OpenTelemetry tracing 設定
+type TracingConfig struct {
+	Exporter    string `mapstructure:"exporter"`     // none（預設）、stdout、file、otlp
+	File        string `mapstructure:"file"`         // file exporter 的輸出檔案
+	Endpoint    string `mapstructure:"endpoint"`     // otlp exporter 的 host:port（OTLP/HTTP）
+	Insecure    bool   `mapstructure:"insecure"`     // otlp exporter 不使用 TLS
+	ServiceName string `mapstructure:"service_name"` // 未設定為 apgo
+	// SampleRatio 取樣比例（0 ~ 1），未設定表示全部取樣
+	SampleRatio *float64 `mapstructure:"sample_ratio"`
+}
+
+// options 轉換為 tracing.Options
+func (t TracingConfig) options() tracing.Options {
+	ratio := 1.0
+	if t.SampleRatio != nil {
+		ratio = *t.SampleRatio
+	}
+	return tracing.Options{
+		Exporter:    t.Exporter,
+		File:        t.File,
+		Endpoint:    t.Endpoint,
+		Insecure:    t.Insecure,
+		ServiceName: t.ServiceName,
+		SampleRatio: ratio,
+	}
+}
+
+// InMemoryConfig 內嵌記憶體模式設定
+type InMemoryConfig struct {
+	Description    string        `mapstructure:"description"`
+	Master         string        `mapstructure:"master"`
+	Replicas       []string      `mapstructure:"replicas"`
+	ReplicationLag time.Duration `mapstructure:"replication_lag"` // 例如 "200ms"
+}
+
+// newViper 建立搜尋 name 設定檔的 viper
+func newViper(name string) *viper.Viper {
 	v := viper.New()
 
 	// 設定檔案名稱和類型（Go 慣例使用 YAML）
-	v.SetConfigName("config")
+	v.SetConfigName(name)
 	v.SetConfigType("yaml")
 
 	// 設定搜尋路徑
-	v.AddConfigPath(".")           // 當前目錄
-	v.AddConfigPath("./config")    // config 子目錄
-	v.AddConfigPath("/etc/apgo")   // 系統設定目錄
+	v.AddConfigPath(".")         // 當前目錄
+	v.AddConfigPath("./config")  // config 子目錄
+	v.AddConfigPath("/etc/apgo") // 系統設定目錄
 
 	// 支援環境變數覆蓋
 	v.SetEnvPrefix("APGO")
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
+	return v
+}
+
+// LoadConfig 載入設定檔
+func LoadConfig() (*Config, error) {
+	v := newViper("config")
 
 	// 讀取基本設定檔
 	if err := v.ReadInConfig(); err != nil {
@@ -117,6 +272,31 @@ func LoadConfig() (*Config, error) {
 	if config.Redis.Mode == "" {
 		config.Redis.Mode = "RedisMasterSlaves"
 	}
+	if config.Server.RequestTimeout == 0 {
+		config.Server.RequestTimeout = DefaultRequestTimeout
+	}
+	if config.Server.ShutdownTimeout == 0 {
+		config.Server.ShutdownTimeout = DefaultShutdownTimeout
+	}
+
+	if _, err := config.Server.RouteTimeoutMap(); err != nil {
+		return nil, err
+	}
+	if err := config.Server.Admin.resolveToken(); err != nil {
+		return nil, err
+	}
+	if err := config.Redis.resolveCredentials(); err != nil {
+		return nil, err
+	}
+	if err := config.Redis.Validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Redis.resolveBackends(); err != nil {
+		return nil, err
+	}
+	if err := config.Tracing.options().Validate(); err != nil {
+		return nil, err
+	}
 
 	return &config, nil
 }
@@ -138,6 +318,151 @@ func getEnv() string {
 	return ""
 }
 
+// RouteTimeoutMap 將 RouteTimeouts 轉為以 "METHOD /path" 為鍵的 map，並檢查格式
+func (s ServerConfig) RouteTimeoutMap() (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(s.RouteTimeouts))
+	for _, rt := range s.RouteTimeouts {
+		method, path, ok := strings.Cut(strings.TrimSpace(rt.Route), " ")
+		path = strings.TrimSpace(path)
+		if !ok || method == "" || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid route timeout %q: route must be \"METHOD /path\"", rt.Route)
+		}
+		if rt.Timeout == 0 {
+			return nil, fmt.Errorf("invalid route timeout %q: timeout is required", rt.Route)
+		}
+		route := strings.ToUpper(method) + " " + path
+		if _, dup := timeouts[route]; dup {
+			return nil, fmt.Errorf("invalid route timeout %q: duplicate route", rt.Route)
+		}
+		timeouts[route] = rt.Timeout
+	}
+	return timeouts, nil
+}
+
+// Validate 檢查共用與各模式合併後的連線設定
+func (r RedisConfig) Validate() error {
+	modes := []struct {
+		name   string
+		config ClientConfig
+	}{
+		{"redis", r.ClientConfig},
+		{"master_slave", r.MasterSlave.ClientConfig},
+		{"sentinel", r.Sentinel.ClientConfig},
+		{"cluster", r.Cluster.ClientConfig},
+		{"raft", r.Raft.ClientConfig},
+	}
+	for _, mode := range modes {
+		if err := mode.config.merge(r.ClientConfig).validate(); err != nil {
+			return fmt.Errorf("invalid %s client config: %w", mode.name, err)
+		}
+	}
+
+	bounds := []struct {
+		name         string
+		maxStaleness time.Duration
+		maxLagBytes  int64
+	}{
+		{"master_slave", r.MasterSlave.MaxStaleness, r.MasterSlave.MaxLagBytes},
+		{"sentinel", r.Sentinel.MaxStaleness, r.Sentinel.MaxLagBytes},
+	}
+	for _, bound := range bounds {
+		if bound.maxStaleness < 0 {
+			return fmt.Errorf("%s max_staleness must not be negative, got %s", bound.name, bound.maxStaleness)
+		}
+		if bound.maxLagBytes < 0 {
+			return fmt.Errorf("%s max_lag_bytes must not be negative, got %d", bound.name, bound.maxLagBytes)
+		}
+	}
+	return nil
+}
+
+// merge 以 defaults 補上未設定的欄位
+func (c ClientConfig) merge(defaults ClientConfig) ClientConfig {
+	// 帳號密碼成對覆寫，避免混用共用設定的使用者與模式設定的密碼
+	if c.Username == "" && c.Password == "" {
+		c.Username, c.Password = defaults.Username, defaults.Password
+	}
+	if c.TLS == (TLSConfig{}) {
+		c.TLS = defaults.TLS
+	}
+	if c.PoolSize == 0 {
+		c.PoolSize = defaults.PoolSize
+	}
+	if c.MinIdleConns == 0 {
+		c.MinIdleConns = defaults.MinIdleConns
+	}
+	if c.DialTimeout == 0 {
+		c.DialTimeout = defaults.DialTimeout
+	}
+	if c.ReadTimeout == 0 {
+		c.ReadTimeout = defaults.ReadTimeout
+	}
+	if c.WriteTimeout == 0 {
+		c.WriteTimeout = defaults.WriteTimeout
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = defaults.MaxRetries
+	}
+	if c.MinRetryBackoff == 0 {
+		c.MinRetryBackoff = defaults.MinRetryBackoff
+	}
+	if c.MaxRetryBackoff == 0 {
+		c.MaxRetryBackoff = defaults.MaxRetryBackoff
+	}
+	return c
+}
+
+// validate 檢查連線設定的範圍
+func (c ClientConfig) validate() error {
+	switch {
+	case c.Username != "" && c.Password == "":
+		return fmt.Errorf("username %q requires a password", c.Username)
+	case c.PoolSize < 0:
+		return fmt.Errorf("pool_size must not be negative, got %d", c.PoolSize)
+	case c.MinIdleConns < 0:
+		return fmt.Errorf("min_idle_conns must not be negative, got %d", c.MinIdleConns)
+	case c.PoolSize > 0 && c.MinIdleConns > c.PoolSize:
+		return fmt.Errorf("min_idle_conns (%d) must not exceed pool_size (%d)", c.MinIdleConns, c.PoolSize)
+	case c.DialTimeout < 0:
+		return fmt.Errorf("dial_timeout must not be negative, got %s", c.DialTimeout)
+	case c.MaxRetries < -1:
+		return fmt.Errorf("max_retries must be -1 (disabled) or greater, got %d", c.MaxRetries)
+	case c.MinRetryBackoff > 0 && c.MaxRetryBackoff > 0 && c.MinRetryBackoff > c.MaxRetryBackoff:
+		return fmt.Errorf("min_retry_backoff (%s) must not exceed max_retry_backoff (%s)", c.MinRetryBackoff, c.MaxRetryBackoff)
+	}
+	return c.TLS.validate()
+}
+
+// clientOptions 將模式的連線設定與共用設定合併，轉為 redis 層的 ClientOptions
+func (r RedisConfig) clientOptions(mode ClientConfig) (redis.ClientOptions, error) {
+	c := mode.merge(r.ClientConfig)
+	tlsConfig, err := c.TLS.build()
+	if err != nil {
+		return redis.ClientOptions{}, err
+	}
+	return redis.ClientOptions{
+		Username:        c.Username,
+		Password:        c.Password,
+		TLSConfig:       tlsConfig,
+		PoolSize:        c.PoolSize,
+		MinIdleConns:    c.MinIdleConns,
+		DialTimeout:     c.DialTimeout,
+		ReadTimeout:     noLimit(c.ReadTimeout),
+		WriteTimeout:    noLimit(c.WriteTimeout),
+		MaxRetries:      c.MaxRetries,
+		MinRetryBackoff: noLimit(c.MinRetryBackoff),
+		MaxRetryBackoff: noLimit(c.MaxRetryBackoff),
+	}, nil
+}
+
+// noLimit 將任何負數的時間轉為 go-redis 表示「不限制」的 -1
+func noLimit(d time.Duration) time.Duration {
+	if d < 0 {
+		return -1
+	}
+	return d
+}
+
 // GetRedisMode 取得 Redis 模式
 func (c *Config) GetRedisMode() (redislib.RedisMode, error) {
 	return redislib.ParseRedisMode(c.Redis.Mode)
@@ -148,6 +473,12 @@ func (c *Config) GetServerAddr() string {
 	return fmt.Sprintf(":%d", c.Server.Port)
 }
 
+// SetupTracing 根據設定建立 trace exporter 並註冊為全域的 TracerProvider
+// 未設定 tracing.exporter 時返回未啟用的 Provider
+func (c *Config) SetupTracing(ctx context.Context) (*tracing.Provider, error) {
+	return tracing.Setup(ctx, c.Tracing.options())
+}
+
 // ConnectRedis 根據設定建立對應的 Redis 連線
 // 對應 C# 的 RedisDI.AddRedisService
 func (c *Config) ConnectRedis() (redislib.IRedisConn, error) {
@@ -158,19 +489,65 @@ func (c *Config) ConnectRedis() (redislib.IRedisConn, error) {
 
 	switch mode {
 	case redislib.RedisMasterSlaves:
-		return redis.NewRedisMasterSlave(
+		client, err := c.Redis.clientOptions(c.Redis.MasterSlave.ClientConfig)
+		if err != nil {
+			return nil, err
+		}
+		weights := make(map[string]int, len(c.Redis.MasterSlave.ReplicaWeights))
+		for _, w := range c.Redis.MasterSlave.ReplicaWeights {
+			weights[w.Endpoint] = w.Weight
+		}
+		return redis.NewRedisMasterSlaveWithOptions(
 			c.Redis.MasterSlave.Master,
 			c.Redis.MasterSlave.Slaves,
+			redis.MasterSlaveOptions{
+				ReadPreference:      redislib.ReadPreference(c.Redis.MasterSlave.ReadPreference),
+				Weights:             weights,
+				HealthCheckInterval: c.Redis.MasterSlave.HealthCheckInterval,
+				UnhealthyThreshold:  c.Redis.MasterSlave.UnhealthyThreshold,
+				MaxStaleness:        c.Redis.MasterSlave.MaxStaleness,
+				MaxLagBytes:         c.Redis.MasterSlave.MaxLagBytes,
+				Client:              client,
+			},
 		)
 	case redislib.RedisSentinel:
-		return redis.NewRedisSentinel(
+		client, err := c.Redis.clientOptions(c.Redis.Sentinel.ClientConfig)
+		if err != nil {
+			return nil, err
+		}
+		return redis.NewRedisSentinelWithOptions(
 			c.Redis.Sentinel.MasterName,
 			c.Redis.Sentinel.Sentinels,
+			redis.SentinelOptions{
+				ReplicaReads:        c.Redis.Sentinel.ReplicaReads,
+				ReplicaSelection:    redis.ReplicaSelection(c.Redis.Sentinel.ReplicaSelection),
+				HealthCheckInterval: c.Redis.Sentinel.HealthCheckInterval,
+				UnhealthyThreshold:  c.Redis.Sentinel.UnhealthyThreshold,
+				MaxStaleness:        c.Redis.Sentinel.MaxStaleness,
+				MaxLagBytes:         c.Redis.Sentinel.MaxLagBytes,
+				Client:              client,
+				SentinelUsername:    c.Redis.Sentinel.SentinelUsername,
+				SentinelPassword:    c.Redis.Sentinel.SentinelPassword,
+			},
 		)
 	case redislib.RedisCluster:
-		return redis.NewRedisCluster(c.Redis.Cluster.Nodes)
+		client, err := c.Redis.clientOptions(c.Redis.Cluster.ClientConfig)
+		if err != nil {
+			return nil, err
+		}
+		return redis.NewRedisClusterWithOptions(c.Redis.Cluster.Nodes, redis.ClusterOptions{Client: client})
 	case redislib.RedisRaft:
-		return redis.NewRedisRaft(c.Redis.Raft.Nodes)
+		client, err := c.Redis.clientOptions(c.Redis.Raft.ClientConfig)
+		if err != nil {
+			return nil, err
+		}
+		return redis.NewRedisRaftWithOptions(c.Redis.Raft.Nodes, redis.RaftOptions{Client: client})
+	case redislib.RedisInMemory:
+		return redis.NewRedisInMemory(
+			c.Redis.InMemory.Master,
+			c.Redis.InMemory.Replicas,
+			c.Redis.InMemory.ReplicationLag,
+		)
 	default:
 		return nil, fmt.Errorf("unsupported redis mode: %s", mode)
 	}
@@ -1,8 +1,15 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	"github.com/spf13/viper"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -48,6 +55,7 @@ func TestGetRedisMode(t *testing.T) {
 		{"valid sentinel", "RedisSentinel", false},
 		{"valid cluster", "RedisCluster", false},
 		{"valid raft", "RedisRaft", false},
+		{"valid in-memory", "RedisInMemory", false},
 		{"invalid mode", "InvalidMode", true},
 	}
 
@@ -99,6 +107,44 @@ func TestConnectRedis_MasterSlaveEmptyMaster(t *testing.T) {
 	}
 }
 
+func TestConnectRedis_MasterSlaveInvalidReadPreference(t *testing.T) {
+	// 測試無效的讀取偏好在建立連線前就被拒絕
+	config := &Config{
+		Redis: RedisConfig{
+			Mode: "RedisMasterSlaves",
+			MasterSlave: MasterSlaveConfig{
+				Master:         "localhost:6379",
+				ReadPreference: "fastest",
+			},
+		},
+	}
+
+	_, err := config.ConnectRedis()
+	if !errors.Is(err, redislib.ErrInvalidReadPreference) {
+		t.Errorf("ConnectRedis() error = %v, want ErrInvalidReadPreference", err)
+	}
+}
+
+func TestConnectRedis_SentinelInvalidReplicaSelection(t *testing.T) {
+	// 測試無效的 Replica 選擇方式在建立連線前就被拒絕
+	config := &Config{
+		Redis: RedisConfig{
+			Mode: "RedisSentinel",
+			Sentinel: SentinelConfig{
+				MasterName:       "mymaster",
+				Sentinels:        []string{"localhost:26379"},
+				ReplicaReads:     true,
+				ReplicaSelection: "fastest",
+			},
+		},
+	}
+
+	_, err := config.ConnectRedis()
+	if !errors.Is(err, redislib.ErrInvalidReadPreference) {
+		t.Errorf("ConnectRedis() error = %v, want ErrInvalidReadPreference", err)
+	}
+}
+
 func TestConnectRedis_SentinelEmptyMasterName(t *testing.T) {
 	// 測試 Sentinel 模式缺少 MasterName 設定
 	config := &Config{
@@ -150,3 +196,203 @@ func TestConnectRedis_RaftEmptyNodes(t *testing.T) {
 		t.Error("ConnectRedis() should return error when raft nodes is empty")
 	}
 }
+
+func TestConnectRedis_InMemory(t *testing.T) {
+	// 測試內嵌記憶體模式不需要網路即可建立連線
+	config := &Config{
+		Redis: RedisConfig{
+			Mode: "RedisInMemory",
+			InMemory: InMemoryConfig{
+				Master:         "memory:master",
+				Replicas:       []string{"memory:replica-1"},
+				ReplicationLag: 100 * time.Millisecond,
+			},
+		},
+	}
+
+	conn, err := config.ConnectRedis()
+	if err != nil {
+		t.Fatalf("ConnectRedis() error = %v", err)
+	}
+	defer conn.Close()
+
+	if got := conn.GetSlaveEndpoint(); got != "memory:replica-1" {
+		t.Errorf("Expected slave endpoint memory:replica-1, got %s", got)
+	}
+}
+
+func TestLoadConfig_InMemoryEnv(t *testing.T) {
+	// 測試 GO_ENV=in-memory 載入 config.in-memory.yaml
+	os.Setenv("GO_ENV", "in-memory")
+	defer os.Unsetenv("GO_ENV")
+	t.Chdir("../..")
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if config.Redis.Mode != "RedisInMemory" {
+		t.Errorf("Expected mode RedisInMemory, got %s", config.Redis.Mode)
+	}
+	if config.Redis.InMemory.ReplicationLag != 200*time.Millisecond {
+		t.Errorf("Expected replication lag 200ms, got %s", config.Redis.InMemory.ReplicationLag)
+	}
+	if config.Server.ShutdownTimeout != DefaultShutdownTimeout {
+		t.Errorf("Expected default shutdown timeout %s, got %s", DefaultShutdownTimeout, config.Server.ShutdownTimeout)
+	}
+}
+
+func TestRouteTimeoutMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		routes  []RouteTimeout
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{"empty", nil, map[string]time.Duration{}, false},
+		{"normalized method", []RouteTimeout{
+			{Route: "get /fillcluster", Timeout: 10 * time.Minute},
+			{Route: "POST /cache/batch", Timeout: -1},
+		}, map[string]time.Duration{"GET /fillcluster": 10 * time.Minute, "POST /cache/batch": -1}, false},
+		{"missing method", []RouteTimeout{{Route: "/cache", Timeout: time.Second}}, nil, true},
+		{"missing timeout", []RouteTimeout{{Route: "GET /cache"}}, nil, true},
+		{"duplicate route", []RouteTimeout{
+			{Route: "GET /cache", Timeout: time.Second},
+			{Route: "get /cache", Timeout: 2 * time.Second},
+		}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ServerConfig{RouteTimeouts: tt.routes}.RouteTimeoutMap()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RouteTimeoutMap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RouteTimeoutMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientConfig_Unmarshal(t *testing.T) {
+	yaml := `
+redis:
+  mode: RedisCluster
+  pool_size: 20
+  read_timeout: 2s
+  username: app
+  password: shared
+  tls:
+    enabled: true
+    server_name: redis.local
+  cluster:
+    nodes: ["127.0.0.1:7000"]
+    pool_size: 50
+    max_retries: -1
+  raft:
+    password: raft-only
+`
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(yaml)); err != nil {
+		t.Fatalf("ReadConfig failed: %v", err)
+	}
+	var config Config
+	if err := v.Unmarshal(&config); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	cluster, err := config.Redis.clientOptions(config.Redis.Cluster.ClientConfig)
+	if err != nil {
+		t.Fatalf("clientOptions failed: %v", err)
+	}
+	if cluster.PoolSize != 50 || cluster.MaxRetries != -1 || cluster.ReadTimeout != 2*time.Second || cluster.Username != "app" {
+		t.Errorf("Unexpected cluster client options: %+v", cluster)
+	}
+	if cluster.TLSConfig == nil || cluster.TLSConfig.ServerName != "redis.local" {
+		t.Errorf("Expected shared TLS settings, got %+v", cluster.TLSConfig)
+	}
+
+	// 模式只設定密碼時不沿用共用的使用者名稱
+	raft, err := config.Redis.clientOptions(config.Redis.Raft.ClientConfig)
+	if err != nil {
+		t.Fatalf("clientOptions failed: %v", err)
+	}
+	if raft.Username != "" || raft.Password != "raft-only" || raft.PoolSize != 20 {
+		t.Errorf("Unexpected raft client options: %+v", raft)
+	}
+}
+
+func TestRedisConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  RedisConfig
+		wantErr bool
+	}{
+		{"empty", RedisConfig{}, false},
+		{"valid", RedisConfig{
+			ClientConfig: ClientConfig{PoolSize: 10, MinIdleConns: 2, ReadTimeout: -1, MaxRetries: -1},
+			Cluster:      ClusterConfig{ClientConfig: ClientConfig{MinRetryBackoff: time.Millisecond, MaxRetryBackoff: time.Second}},
+		}, false},
+		{"negative pool size", RedisConfig{ClientConfig: ClientConfig{PoolSize: -1}}, true},
+		{"min idle exceeds merged pool size", RedisConfig{
+			ClientConfig: ClientConfig{PoolSize: 5},
+			Raft:         RaftConfig{ClientConfig: ClientConfig{MinIdleConns: 6}},
+		}, true},
+		{"negative dial timeout", RedisConfig{Sentinel: SentinelConfig{ClientConfig: ClientConfig{DialTimeout: -time.Second}}}, true},
+		{"max retries below -1", RedisConfig{MasterSlave: MasterSlaveConfig{ClientConfig: ClientConfig{MaxRetries: -2}}}, true},
+		{"backoff range", RedisConfig{ClientConfig: ClientConfig{MinRetryBackoff: time.Second, MaxRetryBackoff: time.Millisecond}}, true},
+		{"username without password", RedisConfig{ClientConfig: ClientConfig{Username: "app"}}, true},
+		{"staleness bounds", RedisConfig{
+			MasterSlave: MasterSlaveConfig{MaxStaleness: time.Second, MaxLagBytes: 1024},
+			Sentinel:    SentinelConfig{MaxStaleness: 500 * time.Millisecond, MaxLagBytes: 512},
+		}, false},
+		{"negative master slave max staleness", RedisConfig{MasterSlave: MasterSlaveConfig{MaxStaleness: -time.Second}}, true},
+		{"negative sentinel max staleness", RedisConfig{Sentinel: SentinelConfig{MaxStaleness: -time.Second}}, true},
+		{"negative sentinel max lag bytes", RedisConfig{Sentinel: SentinelConfig{MaxLagBytes: -1}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTracingConfig_Unmarshal(t *testing.T) {
+	tests := []struct {
+		name      string
+		yaml      string
+		wantRatio float64
+	}{
+		{"sample ratio defaults to 1", "tracing:\n  exporter: stdout\n", 1},
+		{"explicit zero sample ratio", "tracing:\n  exporter: stdout\n  sample_ratio: 0\n", 0},
+		{"partial sample ratio", "tracing:\n  exporter: file\n  file: traces.jsonl\n  sample_ratio: 0.25\n", 0.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			v.SetConfigType("yaml")
+			if err := v.ReadConfig(strings.NewReader(tt.yaml)); err != nil {
+				t.Fatalf("ReadConfig failed: %v", err)
+			}
+			var config Config
+			if err := v.Unmarshal(&config); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+
+			opts := config.Tracing.options()
+			if opts.SampleRatio != tt.wantRatio {
+				t.Errorf("SampleRatio = %v, want %v", opts.SampleRatio, tt.wantRatio)
+			}
+			if err := opts.Validate(); err != nil {
+				t.Errorf("Validate() failed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TLSConfig TLS 連線設定
+type TLSConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	CAFile     string `mapstructure:"ca_file"`     // 驗證伺服器憑證的 CA，未設定使用系統 CA
+	CertFile   string `mapstructure:"cert_file"`   // 用戶端憑證（mTLS），需要同時設定 key_file
+	KeyFile    string `mapstructure:"key_file"`    // 用戶端私鑰
+	ServerName string `mapstructure:"server_name"` // 驗證憑證時使用的主機名稱，未設定使用連線位址
+	// InsecureSkipVerify 略過伺服器憑證驗證，僅限實驗環境使用
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+}
+
+// validate 檢查 TLS 設定的組合
+func (t TLSConfig) validate() error {
+	if !t.Enabled {
+		if t != (TLSConfig{}) {
+			return fmt.Errorf("tls settings require tls.enabled: true")
+		}
+		return nil
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("tls.cert_file and tls.key_file must be set together")
+	}
+	return nil
+}
+
+// build 載入憑證並建立 tls.Config，未啟用時返回 nil
+func (t TLSConfig) build() (*tls.Config, error) {
+	if !t.Enabled {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if t.InsecureSkipVerify {
+		fmt.Printf("Warning: TLS certificate verification is disabled (tls.insecure_skip_verify)\n")
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tls.ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls.ca_file %s contains no PEM certificates", t.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tls client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// resolveSecret 從設定值、檔案或環境變數取得密碼，三者只能設定其中一種
+// 檔案內容會去除結尾的換行，方便直接使用 Docker / Kubernetes secret
+func resolveSecret(name, value, file, env string) (string, error) {
+	sources := 0
+	for _, s := range []string{value, file, env} {
+		if s != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return "", fmt.Errorf("only one of %s, %s_file and %s_env may be set", name, name, name)
+	}
+
+	switch {
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s_file: %w", name, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case env != "":
+		secret, ok := os.LookupEnv(env)
+		if !ok || secret == "" {
+			return "", fmt.Errorf("environment variable %s for %s_env is not set", env, name)
+		}
+		return secret, nil
+	default:
+		return value, nil
+	}
+}
+
+// resolvePassword 將 password_file / password_env 讀入 Password
+func (c *ClientConfig) resolvePassword() error {
+	password, err := resolveSecret("password", c.Password, c.PasswordFile, c.PasswordEnv)
+	if err != nil {
+		return err
+	}
+	c.Password, c.PasswordFile, c.PasswordEnv = password, "", ""
+	return nil
+}
+
+// resolveToken 將 token_file / token_env 讀入 Token
+func (a *AdminConfig) resolveToken() error {
+	token, err := resolveSecret("token", a.Token, a.TokenFile, a.TokenEnv)
+	if err != nil {
+		return fmt.Errorf("invalid server.admin token: %w", err)
+	}
+	a.Token, a.TokenFile, a.TokenEnv = token, "", ""
+	return nil
+}
+
+// resolveCredentials 讀取所有模式以檔案或環境變數設定的密碼
+func (r *RedisConfig) resolveCredentials() error {
+	modes := []struct {
+		name   string
+		config *ClientConfig
+	}{
+		{"redis", &r.ClientConfig},
+		{"master_slave", &r.MasterSlave.ClientConfig},
+		{"sentinel", &r.Sentinel.ClientConfig},
+		{"cluster", &r.Cluster.ClientConfig},
+		{"raft", &r.Raft.ClientConfig},
+	}
+	for _, mode := range modes {
+		if err := mode.config.resolvePassword(); err != nil {
+			return fmt.Errorf("invalid %s credentials: %w", mode.name, err)
+		}
+	}
+
+	sentinelPassword, err := resolveSecret("sentinel_password",
+		r.Sentinel.SentinelPassword, r.Sentinel.SentinelPasswordFile, r.Sentinel.SentinelPasswordEnv)
+	if err != nil {
+		return fmt.Errorf("invalid sentinel credentials: %w", err)
+	}
+	r.Sentinel.SentinelPassword, r.Sentinel.SentinelPasswordFile, r.Sentinel.SentinelPasswordEnv = sentinelPassword, "", ""
+	if r.Sentinel.SentinelUsername != "" && r.Sentinel.SentinelPassword == "" {
+		return fmt.Errorf("invalid sentinel credentials: sentinel_username %q requires a sentinel_password", r.Sentinel.SentinelUsername)
+	}
+	return nil
+}
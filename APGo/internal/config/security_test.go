@@ -0,0 +1,177 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResolveSecret(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "password")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	t.Setenv("APGO_TEST_REDIS_PASSWORD", "from-env")
+
+	tests := []struct {
+		name    string
+		value   string
+		file    string
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{"none", "", "", "", "", false},
+		{"value", "plain", "", "", "plain", false},
+		{"file trims newline", "", file, "", "from-file", false},
+		{"env", "", "", "APGO_TEST_REDIS_PASSWORD", "from-env", false},
+		{"multiple sources", "plain", file, "", "", true},
+		{"missing file", "", filepath.Join(dir, "missing"), "", "", true},
+		{"unset env", "", "", "APGO_TEST_REDIS_PASSWORD_UNSET", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveSecret("password", tt.value, tt.file, tt.env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveSecret() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveCredentials(t *testing.T) {
+	t.Setenv("APGO_TEST_SENTINEL_PASSWORD", "sentinel-secret")
+
+	redisConfig := RedisConfig{
+		ClientConfig: ClientConfig{Username: "app", PasswordEnv: "APGO_TEST_SENTINEL_PASSWORD"},
+		Sentinel: SentinelConfig{
+			SentinelUsername:    "sentinel-user",
+			SentinelPasswordEnv: "APGO_TEST_SENTINEL_PASSWORD",
+		},
+	}
+	if err := redisConfig.resolveCredentials(); err != nil {
+		t.Fatalf("resolveCredentials failed: %v", err)
+	}
+	if redisConfig.Password != "sentinel-secret" || redisConfig.Sentinel.SentinelPassword != "sentinel-secret" {
+		t.Errorf("Expected passwords to be read from env, got %+v", redisConfig)
+	}
+
+	missing := RedisConfig{Sentinel: SentinelConfig{SentinelUsername: "sentinel-user"}}
+	if err := missing.resolveCredentials(); err == nil {
+		t.Error("Expected error for sentinel_username without sentinel_password")
+	}
+}
+
+func TestAdminConfig_ResolveToken(t *testing.T) {
+	t.Setenv("APGO_TEST_ADMIN_TOKEN", "admin-secret")
+
+	admin := AdminConfig{ModeSwitch: true, TokenEnv: "APGO_TEST_ADMIN_TOKEN"}
+	if err := admin.resolveToken(); err != nil {
+		t.Fatalf("resolveToken failed: %v", err)
+	}
+	if admin.Token != "admin-secret" || admin.TokenEnv != "" {
+		t.Errorf("Expected token to be read from env, got %+v", admin)
+	}
+
+	both := AdminConfig{Token: "plain", TokenEnv: "APGO_TEST_ADMIN_TOKEN"}
+	if err := both.resolveToken(); err == nil {
+		t.Error("Expected error when both token and token_env are set")
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+
+	tests := []struct {
+		name         string
+		tls          TLSConfig
+		wantNil      bool
+		wantValidErr bool
+		wantBuildErr bool
+	}{
+		{"disabled", TLSConfig{}, true, false, false},
+		{"settings without enabled", TLSConfig{CAFile: certFile}, true, true, false},
+		{"system CA", TLSConfig{Enabled: true, ServerName: "redis.local"}, false, false, false},
+		{"mutual TLS", TLSConfig{Enabled: true, CAFile: certFile, CertFile: certFile, KeyFile: keyFile}, false, false, false},
+		{"cert without key", TLSConfig{Enabled: true, CertFile: certFile}, false, true, false},
+		{"invalid CA", TLSConfig{Enabled: true, CAFile: keyFile}, false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.tls.validate(); (err != nil) != tt.wantValidErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantValidErr)
+			}
+			if tt.wantValidErr {
+				return
+			}
+
+			config, err := tt.tls.build()
+			if (err != nil) != tt.wantBuildErr {
+				t.Fatalf("build() error = %v, wantErr %v", err, tt.wantBuildErr)
+			}
+			if tt.wantBuildErr {
+				return
+			}
+			if (config == nil) != tt.wantNil {
+				t.Fatalf("build() = %v, wantNil %v", config, tt.wantNil)
+			}
+			if config != nil && config.ServerName != tt.tls.ServerName {
+				t.Errorf("ServerName = %q, want %q", config.ServerName, tt.tls.ServerName)
+			}
+			if tt.tls.CertFile != "" && (len(config.Certificates) != 1 || config.RootCAs == nil) {
+				t.Errorf("Expected client certificate and CA pool, got %+v", config)
+			}
+		})
+	}
+}
+
+// writeTestCertificate 產生自簽憑證與私鑰的 PEM 檔案
+func writeTestCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "redis.local"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "client.crt")
+	keyFile = filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return certFile, keyFile
+}
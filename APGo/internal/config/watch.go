@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// WatchConfig 監看 LoadConfig 使用的設定檔（config.yaml 與 config.{GO_ENV}.yaml），
+// 任一檔案變更時以 LoadConfig 重新載入完整設定並呼叫 onChange
+// 設定有誤時 cfg 為 nil、err 為載入錯誤；onChange 不會同時被呼叫
+//
+// 編輯器存檔時可能連續觸發多次，onChange 應自行忽略內容沒有變化的設定
+func WatchConfig(onChange func(cfg *Config, err error)) ([]string, error) {
+	files := configFiles()
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no config file to watch")
+	}
+
+	var mu sync.Mutex
+	for _, file := range files {
+		// 每個檔案使用獨立的 viper 監看，重新載入時仍經過 LoadConfig 合併所有檔案與環境變數
+		w := viper.New()
+		w.SetConfigFile(file)
+		w.OnConfigChange(func(fsnotify.Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			onChange(LoadConfig())
+		})
+		w.WatchConfig()
+	}
+	return files, nil
+}
+
+// configFiles 依載入順序列出存在的設定檔路徑
+func configFiles() []string {
+	names := []string{"config"}
+	if env := getEnv(); env != "" {
+		names = append(names, fmt.Sprintf("config.%s", env))
+	}
+
+	var files []string
+	for _, name := range names {
+		v := newViper(name)
+		if err := v.ReadInConfig(); err == nil {
+			files = append(files, v.ConfigFileUsed())
+		}
+	}
+	return files
+}
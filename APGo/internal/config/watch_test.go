@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("GO_ENV", "")
+	file := filepath.Join(dir, "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	write("redis:\n  mode: RedisInMemory\n")
+
+	type change struct {
+		cfg *Config
+		err error
+	}
+	changes := make(chan change, 16)
+	files, err := WatchConfig(func(cfg *Config, err error) {
+		changes <- change{cfg, err}
+	})
+	if err != nil {
+		t.Fatalf("WatchConfig() error = %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("Expected to watch config.yaml only, got %v", files)
+	}
+
+	// next 等待下一個符合條件的通知，忽略同一次存檔觸發的重複通知
+	next := func(match func(change) bool) change {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case c := <-changes:
+				if match(c) {
+					return c
+				}
+			case <-timeout:
+				t.Fatal("Timed out waiting for config change")
+			}
+		}
+	}
+
+	write("redis:\n  mode: RedisCluster\n  cluster:\n    nodes: [\"127.0.0.1:7000\"]\n")
+	c := next(func(c change) bool { return c.err == nil && c.cfg.Redis.Mode == "RedisCluster" })
+	if len(c.cfg.Redis.Cluster.Nodes) != 1 {
+		t.Errorf("Expected reloaded cluster nodes, got %v", c.cfg.Redis.Cluster.Nodes)
+	}
+
+	write("redis:\n  mode: RedisCluster\n  pool_size: -1\n")
+	next(func(c change) bool { return c.err != nil })
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	"github.com/gin-gonic/gin"
+)
+
+// ModeSwitcher 在執行期間切換 Redis 模式
+type ModeSwitcher interface {
+	// Mode 取得目前的 Redis 模式
+	Mode() string
+	// SwitchMode 建立並驗證 mode 的新連線後替換目前的連線，返回切換前的模式
+	SwitchMode(mode string) (string, error)
+}
+
+// AdminController 管理 API 控制器
+type AdminController struct {
+	switcher  ModeSwitcher
+	redisConn redislib.IRedisConn
+}
+
+// NewAdminController 建立新的管理 API 控制器，redisConn 為 switcher 替換的連線
+func NewAdminController(switcher ModeSwitcher, redisConn redislib.IRedisConn) *AdminController {
+	return &AdminController{
+		switcher:  switcher,
+		redisConn: redisConn,
+	}
+}
+
+// RequireModeSwitch 保護切換模式的路由：enabled 為 false 時回傳 403，
+// token 不為空時請求須帶有 "Authorization: Bearer <token>"，否則回傳 401
+func RequireModeSwitch(enabled bool, token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "mode switch disabled",
+				"message": "set server.admin.mode_switch to enable POST /admin/mode",
+			})
+			return
+		}
+		if token != "" {
+			given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				c.Header("WWW-Authenticate", "Bearer")
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"error":   "unauthorized",
+					"message": "a valid admin token is required",
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// ModeRequest 切換模式請求
+type ModeRequest struct {
+	Mode string `json:"mode" binding:"required"`
+}
+
+// GetMode 取得目前的 Redis 模式
+// @Summary 取得目前的 Redis 模式
+// @Description 回傳目前使用的模式與 Master / Slave 端點
+// @Tags Admin
+// @Success 200 {object} map[string]interface{} "目前的模式"
+// @Router /admin/mode [get]
+func (ac *AdminController) GetMode(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"mode":            ac.switcher.Mode(),
+		"master_endpoint": ac.redisConn.GetMasterEndpoint(),
+		"slave_endpoint":  ac.redisConn.GetSlaveEndpoint(),
+	})
+}
+
+// SwitchMode 切換 Redis 模式
+// @Summary 切換 Redis 模式
+// @Description 以已載入的 redis 設定建立新模式的連線，就緒檢查通過後替換目前的連線，不需重新啟動；失敗時繼續使用原本的連線。
+// @Description 需要啟用 server.admin.mode_switch，設定 server.admin.token 時需要帶有 Bearer token
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body ModeRequest true "目標模式"
+// @Success 200 {object} map[string]interface{} "切換成功"
+// @Failure 400 {object} map[string]interface{} "請求參數錯誤或無效的模式"
+// @Failure 401 {object} map[string]interface{} "缺少或錯誤的 token"
+// @Failure 403 {object} map[string]interface{} "未啟用切換模式"
+// @Failure 500 {object} map[string]interface{} "切換失敗"
+// @Failure 503 {object} map[string]interface{} "無法連線或新連線未就緒"
+// @Router /admin/mode [post]
+func (ac *AdminController) SwitchMode(c *gin.Context) {
+	var req ModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	previous, err := ac.switcher.SwitchMode(req.Mode)
+	if errors.Is(err, redislib.ErrInvalidRedisMode) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid mode",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		// 新模式的節點無法連線或未就緒是暫時性的狀況，與其他失敗區分
+		status := http.StatusInternalServerError
+		if errors.Is(err, redislib.ErrConnectionFailed) {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{
+			"error":   "mode switch failed",
+			"message": err.Error(),
+			"mode":    previous,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"mode":            req.Mode,
+		"previous_mode":   previous,
+		"changed":         previous != req.Mode,
+		"master_endpoint": ac.redisConn.GetMasterEndpoint(),
+		"slave_endpoint":  ac.redisConn.GetSlaveEndpoint(),
+	})
+}
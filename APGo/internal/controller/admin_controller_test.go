@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeSwitcher 記錄目前模式的 ModeSwitcher，switchErr 不為 nil 時切換失敗
+type fakeSwitcher struct {
+	mode      string
+	switchErr error
+}
+
+func (f *fakeSwitcher) Mode() string {
+	return f.mode
+}
+
+func (f *fakeSwitcher) SwitchMode(mode string) (string, error) {
+	previous := f.mode
+	if _, err := redislib.ParseRedisMode(mode); err != nil {
+		return "", fmt.Errorf("%w: %s", err, mode)
+	}
+	if f.switchErr != nil {
+		return previous, f.switchErr
+	}
+	f.mode = mode
+	return previous, nil
+}
+
+func setupAdminRouter(switcher ModeSwitcher) *gin.Engine {
+	return setupGuardedAdminRouter(switcher, true, "")
+}
+
+// setupGuardedAdminRouter 以 RequireModeSwitch 保護 POST /admin/mode
+func setupGuardedAdminRouter(switcher ModeSwitcher, enabled bool, token string) *gin.Engine {
+	controller := NewAdminController(switcher, &MockRedisConn{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin/mode", controller.GetMode)
+	router.POST("/admin/mode", RequireModeSwitch(enabled, token), controller.SwitchMode)
+	return router
+}
+
+func TestGetMode(t *testing.T) {
+	router := setupAdminRouter(&fakeSwitcher{mode: "RedisCluster"})
+
+	req, _ := http.NewRequest("GET", "/admin/mode", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response["mode"] != "RedisCluster" {
+		t.Errorf("Expected mode RedisCluster, got %v", response["mode"])
+	}
+}
+
+func TestSwitchMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		switchErr   error
+		wantStatus  int
+		wantMode    string
+		wantChanged bool
+	}{
+		{"switch to sentinel", `{"mode":"RedisSentinel"}`, nil, http.StatusOK, "RedisSentinel", true},
+		{"same mode", `{"mode":"RedisMasterSlaves"}`, nil, http.StatusOK, "RedisMasterSlaves", false},
+		{"missing mode", `{}`, nil, http.StatusBadRequest, "RedisMasterSlaves", false},
+		{"invalid mode", `{"mode":"RedisMemcached"}`, nil, http.StatusBadRequest, "RedisMasterSlaves", false},
+		{"connection failed", `{"mode":"RedisRaft"}`, fmt.Errorf("failed to connect to RedisRaft: %w", redislib.ErrConnectionFailed), http.StatusServiceUnavailable, "RedisMasterSlaves", false},
+		{"not ready", `{"mode":"RedisRaft"}`, fmt.Errorf("RedisRaft is not ready: %w: no leader", redislib.ErrConnectionFailed), http.StatusServiceUnavailable, "RedisMasterSlaves", false},
+		{"other failure", `{"mode":"RedisRaft"}`, errors.New("failed to swap connection"), http.StatusInternalServerError, "RedisMasterSlaves", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			switcher := &fakeSwitcher{mode: "RedisMasterSlaves", switchErr: tt.switchErr}
+			router := setupAdminRouter(switcher)
+
+			req, _ := http.NewRequest("POST", "/admin/mode", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if switcher.mode != tt.wantMode {
+				t.Errorf("Expected active mode %s, got %s", tt.wantMode, switcher.mode)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if response["changed"] != tt.wantChanged {
+				t.Errorf("Expected changed=%v, got %v", tt.wantChanged, response["changed"])
+			}
+			if response["previous_mode"] != "RedisMasterSlaves" {
+				t.Errorf("Expected previous_mode RedisMasterSlaves, got %v", response["previous_mode"])
+			}
+		})
+	}
+}
+
+func TestRequireModeSwitch(t *testing.T) {
+	tests := []struct {
+		name          string
+		enabled       bool
+		token         string
+		authorization string
+		wantStatus    int
+	}{
+		{"disabled", false, "", "", http.StatusForbidden},
+		{"disabled with token", false, "secret", "Bearer secret", http.StatusForbidden},
+		{"enabled without token", true, "", "", http.StatusOK},
+		{"valid token", true, "secret", "Bearer secret", http.StatusOK},
+		{"missing token", true, "secret", "", http.StatusUnauthorized},
+		{"wrong token", true, "secret", "Bearer guess", http.StatusUnauthorized},
+		{"wrong scheme", true, "secret", "Basic secret", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			switcher := &fakeSwitcher{mode: "RedisMasterSlaves"}
+			router := setupGuardedAdminRouter(switcher, tt.enabled, tt.token)
+
+			req, _ := http.NewRequest("POST", "/admin/mode", bytes.NewBufferString(`{"mode":"RedisCluster"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			// 被拒絕的請求不會切換模式
+			wantMode := "RedisMasterSlaves"
+			if tt.wantStatus == http.StatusOK {
+				wantMode = "RedisCluster"
+			}
+			if switcher.mode != wantMode {
+				t.Errorf("Expected active mode %s, got %s", wantMode, switcher.mode)
+			}
+			// 查詢模式不受限制
+			req, _ = http.NewRequest("GET", "/admin/mode", nil)
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Errorf("Expected GET /admin/mode to stay open, got %d", w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/AmandaChou/RedisLab/APGo/internal/redis"
+	"github.com/AmandaChou/RedisLab/APGo/internal/registry"
+	"github.com/gin-gonic/gin"
+)
+
+// BackendController 依路徑參數 :name 將請求交給對應後端的 CacheController
+type BackendController struct {
+	registry    *registry.Registry
+	controllers map[string]*CacheController
+}
+
+// NewBackendController 為 Registry 中的每個後端建立 CacheController
+func NewBackendController(backends *registry.Registry) *BackendController {
+	controllers := make(map[string]*CacheController)
+	for _, name := range backends.Names() {
+		conn, _ := backends.Get(name)
+		controllers[name] = NewCacheController(conn)
+	}
+	return &BackendController{
+		registry:    backends,
+		controllers: controllers,
+	}
+}
+
+// BackendInfo 後端資訊
+type BackendInfo struct {
+	Name           string `json:"name"`
+	Mode           string `json:"mode"`
+	MasterEndpoint string `json:"master_endpoint"`
+	SlaveEndpoint  string `json:"slave_endpoint"`
+}
+
+// ListBackends 列出所有後端
+// @Summary 列出所有後端
+// @Description 依設定順序回傳每個後端的名稱、模式與 Master / Slave 端點（default 為 redis 區段本身的連線）
+// @Tags Backends
+// @Success 200 {object} map[string]interface{} "後端列表"
+// @Router /backends [get]
+func (bc *BackendController) ListBackends(c *gin.Context) {
+	backends := make([]BackendInfo, 0, len(bc.controllers))
+	for _, name := range bc.registry.Names() {
+		conn, ok := bc.registry.Get(name)
+		if !ok {
+			continue
+		}
+		backends = append(backends, BackendInfo{
+			Name:           name,
+			Mode:           redis.ModeOf(conn).String(),
+			MasterEndpoint: conn.GetMasterEndpoint(),
+			SlaveEndpoint:  conn.GetSlaveEndpoint(),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"backends": backends})
+}
+
+// Handle 以 :name 後端的 CacheController 處理請求，例如 Handle((*CacheController).GetCache)
+// 後端不存在時回傳 404
+func (bc *BackendController) Handle(handler func(*CacheController, *gin.Context)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("name")
+		cc, ok := bc.controllers[name]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":    "backend not found",
+				"message":  fmt.Sprintf("unknown backend %q", name),
+				"backends": bc.registry.Names(),
+			})
+			return
+		}
+		handler(cc, c)
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AmandaChou/RedisLab/APGo/internal/redis"
+	"github.com/AmandaChou/RedisLab/APGo/internal/registry"
+	"github.com/gin-gonic/gin"
+)
+
+func setupBackendRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	backends := registry.New()
+	for _, name := range []string{"default", "other"} {
+		conn, err := redis.NewRedisInMemory("memory:"+name, nil, 0)
+		if err != nil {
+			t.Fatalf("NewRedisInMemory() error = %v", err)
+		}
+		backends.Register(name, conn)
+	}
+	t.Cleanup(func() { backends.Close() })
+	controller := NewBackendController(backends)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/backends", controller.ListBackends)
+	router.GET("/backends/:name/cache", controller.Handle((*CacheController).GetCache))
+	router.POST("/backends/:name/cache", controller.Handle((*CacheController).UpdateCache))
+	return router
+}
+
+func TestListBackends(t *testing.T) {
+	router := setupBackendRouter(t)
+
+	req, _ := http.NewRequest("GET", "/backends", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var response struct {
+		Backends []BackendInfo `json:"backends"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	want := []BackendInfo{
+		{Name: "default", Mode: "RedisInMemory", MasterEndpoint: "memory:default", SlaveEndpoint: "memory:default"},
+		{Name: "other", Mode: "RedisInMemory", MasterEndpoint: "memory:other", SlaveEndpoint: "memory:other"},
+	}
+	if len(response.Backends) != len(want) {
+		t.Fatalf("Expected %d backends, got %+v", len(want), response.Backends)
+	}
+	for i := range want {
+		if response.Backends[i] != want[i] {
+			t.Errorf("Backend %d = %+v, want %+v", i, response.Backends[i], want[i])
+		}
+	}
+}
+
+func TestBackendCache_IsolatedByName(t *testing.T) {
+	router := setupBackendRouter(t)
+
+	body, _ := json.Marshal(CacheRequest{Key: "shared", Value: "from-default"})
+	req, _ := http.NewRequest("POST", "/backends/default/cache", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{"written backend", "/backends/default/cache?key=shared", http.StatusOK},
+		{"other backend", "/backends/other/cache?key=shared", http.StatusNotFound},
+		{"unknown backend", "/backends/raft/cache?key=shared", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if tt.name == "unknown backend" && response["error"] != "backend not found" {
+				t.Errorf("Expected backend not found error, got %v", response)
+			}
+		})
+	}
+}
@@ -2,8 +2,12 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/AmandaChou/RedisLab/APGo/internal/redis"
 	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
@@ -26,13 +30,31 @@ func NewCacheController(redisConn redislib.IRedisConn) *CacheController {
 type CacheRequest struct {
 	Key   string `json:"key" binding:"required"`
 	Value string `json:"value" binding:"required"`
+	// TTLSeconds 過期秒數（選填，0 或未填表示永不過期）
+	TTLSeconds int64 `json:"ttl_seconds" binding:"min=0"`
+}
+
+// BatchCacheRequest 批次快取請求
+type BatchCacheRequest struct {
+	Items []CacheRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// TTLRequest 過期時間更新請求
+type TTLRequest struct {
+	Key string `json:"key" binding:"required"`
+	// TTLSeconds 新的過期秒數（0 表示移除過期時間）
+	TTLSeconds int64 `json:"ttl_seconds" binding:"min=0"`
 }
 
 // GetCache 讀取快取
 // @Summary 讀取快取
-// @Description 從 Redis 讀取指定 key 的值（從 Slave/Replica 讀取）
+// @Description 從 Redis 讀取指定 key 的值（依讀取偏好選擇節點，預設從 Slave/Replica 讀取）
 // @Tags Cache
 // @Param key query string true "快取鍵"
+// @Param read_preference query string false "覆寫讀取偏好（primary, replica, nearest, round_robin, weighted...）"
+// @Param max_staleness_ms query int false "可接受的最大複寫時間落差（毫秒），超過的 Replica 會被略過；0 只接受已追上的 Replica，-1 不限制"
+// @Param max_lag_bytes query int false "可接受的最大複寫 offset 落差（位元組），超過的 Replica 會被略過；0 只接受已追上的 Replica，-1 不限制"
+// @Param consistency_token query string false "POST /cache 回傳的一致性 Token，保證讀到該次寫入"
 // @Success 200 {object} map[string]interface{} "成功讀取"
 // @Failure 404 {object} map[string]interface{} "找不到鍵"
 // @Failure 500 {object} map[string]interface{} "讀取失敗"
@@ -47,43 +69,119 @@ func (cc *CacheController) GetCache(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
-	value, err := cc.redisConn.ReadAsync(ctx, key)
+	opts, err := parseReadOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid read options",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	result, err := cc.redisConn.ReadWithOptionsAsync(ctx, key, opts)
+	readFrom := result.Node
+	if readFrom == "" {
+		readFrom = cc.redisConn.GetSlaveEndpoint()
+	}
 	if err != nil {
 		if err == redislib.ErrKeyNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error":     "key not found",
 				"key":       key,
 				"message":   fmt.Sprintf("key '%s' not found", key),
-				"read_from": cc.redisConn.GetSlaveEndpoint(),
+				"read_from": readFrom,
 			})
 			return
 		}
+		if requestTimedOut(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":     "read failed",
 			"key":       key,
 			"message":   err.Error(),
-			"read_from": cc.redisConn.GetSlaveEndpoint(),
+			"read_from": readFrom,
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"key":       key,
-		"value":     value,
-		"message":   fmt.Sprintf("value: %s", value),
-		"read_from": cc.redisConn.GetSlaveEndpoint(),
+		"value":     result.Value,
+		"message":   fmt.Sprintf("value: %s", result.Value),
+		"read_from": readFrom,
+		"lag_bytes": result.LagBytes,
+		"lag_ms":    lagMilliseconds(result.Staleness),
 	})
 }
 
+// parseReadOptions 解析讀取相關的查詢參數，未指定的項目由連線使用自己的設定值
+func parseReadOptions(c *gin.Context) (redislib.ReadOptions, error) {
+	var opts redislib.ReadOptions
+	if pref := c.Query("read_preference"); pref != "" {
+		preference, err := redislib.ParseReadPreference(pref)
+		if err != nil {
+			return opts, err
+		}
+		opts.Preference = preference
+	}
+	if raw := c.Query("max_staleness_ms"); raw != "" {
+		ms, err := parseLagLimit("max_staleness_ms", raw)
+		if err != nil {
+			return opts, err
+		}
+		if ms > 0 {
+			ms *= int64(time.Millisecond)
+		}
+		opts.MaxStaleness = time.Duration(ms)
+	}
+	if raw := c.Query("max_lag_bytes"); raw != "" {
+		bytes, err := parseLagLimit("max_lag_bytes", raw)
+		if err != nil {
+			return opts, err
+		}
+		opts.MaxLagBytes = bytes
+	}
+	token, err := redislib.ParseConsistencyToken(c.Query("consistency_token"))
+	if err != nil {
+		return opts, err
+	}
+	opts.After = token
+	return opts, nil
+}
+
+// parseLagLimit 解析複寫落差上限的查詢參數：0 表示只接受已追上的 Replica，-1 表示不限制（兩者都會覆寫設定值）
+func parseLagLimit(name, raw string) (int64, error) {
+	limit, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || limit < -1 {
+		return 0, fmt.Errorf("%s must be an integer >= -1, got %q", name, raw)
+	}
+	switch limit {
+	case 0:
+		return redislib.ZeroLag, nil
+	case -1:
+		return redislib.NoLagLimit, nil
+	}
+	return limit, nil
+}
+
+// lagMilliseconds 將複寫時間落差轉為毫秒，未知時返回 -1
+func lagMilliseconds(staleness time.Duration) int64 {
+	if staleness < 0 {
+		return -1
+	}
+	return staleness.Milliseconds()
+}
+
 // UpdateCache 更新快取
 // @Summary 更新快取
-// @Description 寫入資料到 Redis（寫入 Master）
+// @Description 寫入資料到 Redis（寫入 Master），可選擇設定過期秒數
 // @Tags Cache
 // @Accept json
 // @Produce json
 // @Param request body CacheRequest true "快取請求"
-// @Success 200 {object} map[string]interface{} "成功寫入"
+// @Success 200 {object} map[string]interface{} "成功寫入（包含讀取時使用的 consistency_token）"
 // @Failure 400 {object} map[string]interface{} "請求參數錯誤"
 // @Failure 500 {object} map[string]interface{} "寫入失敗"
 // @Router /cache [post]
@@ -97,8 +195,12 @@ func (cc *CacheController) UpdateCache(c *gin.Context) {
 		return
 	}
 
-	ctx := context.Background()
-	success, err := cc.redisConn.WriteAsync(ctx, req.Key, req.Value)
+	ctx := c.Request.Context()
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	success, err := cc.redisConn.WriteWithTTLAsync(ctx, req.Key, req.Value, ttl)
+	if requestTimedOut(c, err) {
+		return
+	}
 	if err != nil || !success {
 		errMsg := "write failed"
 		if err != nil {
@@ -114,49 +216,774 @@ func (cc *CacheController) UpdateCache(c *gin.Context) {
 		return
 	}
 
+	response := gin.H{
+		"key":         req.Key,
+		"value":       req.Value,
+		"ttl_seconds": req.TTLSeconds,
+		"message":     fmt.Sprintf("key '%s', value '%s' well saved", req.Key, req.Value),
+		"written_to":  cc.redisConn.GetMasterEndpoint(),
+	}
+
+	// 寫入已成功，取得 Token 失敗只影響後續讀取的一致性保證
+	token, err := cc.redisConn.ConsistencyTokenAsync(ctx)
+	if err != nil {
+		fmt.Printf("Warning: failed to get consistency token after writing '%s': %v\n", req.Key, err)
+	} else {
+		response["consistency_token"] = token.String()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetCacheTTL 讀取快取剩餘存活時間
+// @Summary 讀取快取剩餘存活時間
+// @Description 取得指定 key 的剩餘過期秒數（-1 表示永不過期）
+// @Tags Cache
+// @Param key query string true "快取鍵"
+// @Success 200 {object} map[string]interface{} "成功讀取"
+// @Failure 404 {object} map[string]interface{} "找不到鍵"
+// @Failure 500 {object} map[string]interface{} "讀取失敗"
+// @Router /cache/ttl [get]
+func (cc *CacheController) GetCacheTTL(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "key is required",
+			"message": "請提供 key 參數",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	ttl, err := cc.redisConn.GetTTLAsync(ctx, key)
+	if err != nil {
+		if errors.Is(err, redislib.ErrKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "key not found",
+				"key":     key,
+				"message": fmt.Sprintf("key '%s' not found", key),
+			})
+			return
+		}
+		if requestTimedOut(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "ttl read failed",
+			"key":     key,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ttlSeconds := int64(-1)
+	if ttl != redislib.NoTTL {
+		ttlSeconds = int64(ttl / time.Second)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"key":        req.Key,
-		"value":      req.Value,
-		"message":    fmt.Sprintf("key '%s', value '%s' well saved", req.Key, req.Value),
+		"key":         key,
+		"ttl_seconds": ttlSeconds,
+		"message":     fmt.Sprintf("ttl: %d", ttlSeconds),
+	})
+}
+
+// UpdateCacheTTL 更新快取過期時間
+// @Summary 更新快取過期時間
+// @Description 變更指定 key 的過期秒數，ttl_seconds 為 0 時移除過期時間
+// @Tags Cache
+// @Accept json
+// @Produce json
+// @Param request body TTLRequest true "過期時間請求"
+// @Success 200 {object} map[string]interface{} "成功更新"
+// @Failure 400 {object} map[string]interface{} "請求參數錯誤"
+// @Failure 404 {object} map[string]interface{} "找不到鍵"
+// @Failure 500 {object} map[string]interface{} "更新失敗"
+// @Router /cache/ttl [post]
+func (cc *CacheController) UpdateCacheTTL(c *gin.Context) {
+	var req TTLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var changed bool
+	var err error
+	if req.TTLSeconds == 0 {
+		changed, err = cc.redisConn.PersistAsync(ctx, req.Key)
+	} else {
+		changed, err = cc.redisConn.ExpireAsync(ctx, req.Key, time.Duration(req.TTLSeconds)*time.Second)
+	}
+	if err != nil {
+		if errors.Is(err, redislib.ErrKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "key not found",
+				"key":     req.Key,
+				"message": fmt.Sprintf("key '%s' not found", req.Key),
+			})
+			return
+		}
+		if requestTimedOut(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "ttl update failed",
+			"key":        req.Key,
+			"message":    err.Error(),
+			"written_to": cc.redisConn.GetMasterEndpoint(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"key":         req.Key,
+		"ttl_seconds": req.TTLSeconds,
+		"changed":     changed,
+		"message":     fmt.Sprintf("key '%s' ttl updated", req.Key),
+		"written_to":  cc.redisConn.GetMasterEndpoint(),
+	})
+}
+
+// DeleteCache 刪除快取
+// @Summary 刪除快取
+// @Description 從 Redis 刪除一個或多個 key（寫入 Master），可重複 key 參數一次刪除多個
+// @Tags Cache
+// @Param key query []string true "快取鍵（可重複）"
+// @Success 200 {object} map[string]interface{} "成功刪除"
+// @Failure 400 {object} map[string]interface{} "請求參數錯誤"
+// @Failure 404 {object} map[string]interface{} "找不到鍵"
+// @Failure 500 {object} map[string]interface{} "刪除失敗"
+// @Router /cache [delete]
+func (cc *CacheController) DeleteCache(c *gin.Context) {
+	keys := c.QueryArray("key")
+	if len(keys) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "key is required",
+			"message": "請提供 key 參數",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var deleted int64
+	var err error
+	if len(keys) == 1 {
+		var ok bool
+		ok, err = cc.redisConn.DeleteAsync(ctx, keys[0])
+		if ok {
+			deleted = 1
+		}
+	} else {
+		deleted, err = cc.redisConn.DeleteManyAsync(ctx, keys)
+	}
+	if err != nil {
+		if requestTimedOut(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "delete failed",
+			"keys":       keys,
+			"deleted":    deleted,
+			"message":    err.Error(),
+			"written_to": cc.redisConn.GetMasterEndpoint(),
+		})
+		return
+	}
+
+	if len(keys) == 1 && deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "key not found",
+			"key":     keys[0],
+			"message": fmt.Sprintf("key '%s' not found", keys[0]),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys":       keys,
+		"deleted":    deleted,
+		"message":    fmt.Sprintf("%d of %d keys deleted", deleted, len(keys)),
 		"written_to": cc.redisConn.GetMasterEndpoint(),
 	})
 }
 
+// HeadCache 檢查快取是否存在
+// @Summary 檢查快取是否存在
+// @Description 檢查指定 key 是否存在（從 Slave/Replica 檢查），只回傳狀態碼
+// @Tags Cache
+// @Param key query string true "快取鍵"
+// @Success 200 "key 存在"
+// @Failure 404 "key 不存在"
+// @Failure 500 "檢查失敗"
+// @Router /cache [head]
+func (cc *CacheController) HeadCache(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	ctx := c.Request.Context()
+	found, err := cc.redisConn.ExistsAsync(ctx, key)
+	c.Header("X-Read-From", cc.redisConn.GetSlaveEndpoint())
+	if requestTimedOut(c, err) {
+		return
+	}
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// GetCacheBatch 批次讀取快取
+// @Summary 批次讀取快取
+// @Description 一次讀取多個 key，Cluster 模式會依 hash slot 分組平行讀取，部分失敗時回傳 207
+// @Tags Cache
+// @Param keys query string true "以逗號分隔的快取鍵"
+// @Success 200 {object} map[string]interface{} "全部成功"
+// @Success 207 {object} map[string]interface{} "部分失敗"
+// @Failure 400 {object} map[string]interface{} "請求參數錯誤"
+// @Failure 500 {object} map[string]interface{} "讀取失敗"
+// @Router /cache/batch [get]
+func (cc *CacheController) GetCacheBatch(c *gin.Context) {
+	var keys []string
+	for _, param := range c.QueryArray("keys") {
+		for _, key := range strings.Split(param, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "keys is required",
+			"message": "請提供以逗號分隔的 keys 參數",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	results, err := cc.redisConn.BatchReadAsync(ctx, keys)
+	if err != nil {
+		if requestTimedOut(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "batch read failed",
+			"keys":    keys,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	items := make([]gin.H, len(results))
+	found := 0
+	for i, result := range results {
+		item := gin.H{
+			"key":       result.Key,
+			"found":     result.Found,
+			"read_from": result.Node,
+		}
+		if result.Found {
+			item["value"] = result.Value
+			found++
+		}
+		if result.Err != nil {
+			item["error"] = result.Err.Error()
+		}
+		items[i] = item
+	}
+
+	failed := redislib.CountFailed(results)
+	if failed == len(results) && requestTimedOut(c, firstResultError(results)) {
+		return
+	}
+	c.JSON(batchStatus(len(results), failed), gin.H{
+		"results": items,
+		"total":   len(results),
+		"found":   found,
+		"failed":  failed,
+		"message": fmt.Sprintf("%d of %d keys found, %d failed", found, len(results), failed),
+	})
+}
+
+// UpdateCacheBatch 批次更新快取
+// @Summary 批次更新快取
+// @Description 一次寫入多筆資料（寫入 Master），Cluster 模式會依 hash slot 分組平行寫入，部分失敗時回傳 207
+// @Tags Cache
+// @Accept json
+// @Produce json
+// @Param request body BatchCacheRequest true "批次快取請求"
+// @Success 200 {object} map[string]interface{} "全部成功"
+// @Success 207 {object} map[string]interface{} "部分失敗"
+// @Failure 400 {object} map[string]interface{} "請求參數錯誤"
+// @Failure 500 {object} map[string]interface{} "寫入失敗"
+// @Router /cache/batch [post]
+func (cc *CacheController) UpdateCacheBatch(c *gin.Context) {
+	var req BatchCacheRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	entries := make([]redislib.KeyValue, len(req.Items))
+	for i, item := range req.Items {
+		entries[i] = redislib.KeyValue{
+			Key:   item.Key,
+			Value: item.Value,
+			TTL:   time.Duration(item.TTLSeconds) * time.Second,
+		}
+	}
+
+	ctx := c.Request.Context()
+	results, err := cc.redisConn.BatchWriteAsync(ctx, entries)
+	if err != nil {
+		if requestTimedOut(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "batch write failed",
+			"message":    err.Error(),
+			"written_to": cc.redisConn.GetMasterEndpoint(),
+		})
+		return
+	}
+
+	items := make([]gin.H, len(results))
+	for i, result := range results {
+		item := gin.H{
+			"key":        result.Key,
+			"ok":         result.Err == nil,
+			"written_to": result.Node,
+		}
+		if result.Err != nil {
+			item["error"] = result.Err.Error()
+		}
+		items[i] = item
+	}
+
+	failed := redislib.CountFailed(results)
+	if failed == len(results) && requestTimedOut(c, firstResultError(results)) {
+		return
+	}
+	c.JSON(batchStatus(len(results), failed), gin.H{
+		"results":   items,
+		"total":     len(results),
+		"succeeded": len(results) - failed,
+		"failed":    failed,
+		"message":   fmt.Sprintf("%d of %d keys saved", len(results)-failed, len(results)),
+	})
+}
+
+// batchStatus 依批次結果決定 HTTP 狀態碼：全部成功 200、部分失敗 207、全部失敗 500
+func batchStatus(total, failed int) int {
+	switch {
+	case failed == 0:
+		return http.StatusOK
+	case failed < total:
+		return http.StatusMultiStatus
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// firstResultError 返回批次結果中第一個錯誤
+func firstResultError(results []redislib.BatchResult) error {
+	for _, result := range results {
+		if result.Err != nil {
+			return result.Err
+		}
+	}
+	return nil
+}
+
 // FillCluster 填充 Cluster 測試資料
 // @Summary 填充 Cluster 測試資料
-// @Description 批次填充測試資料到 Redis Cluster（僅 Cluster 模式支援）
+// @Description 依 slot 分組後以 Pipeline 平行填充測試資料到 Redis Cluster，並回報各 Master 分到的 Key 數量、分配偏斜與寫入失敗的 Key（僅 Cluster 模式支援）。
+// @Description 請求帶有 Accept: text/event-stream 時以 Server-Sent Events 串流進度
 // @Tags Cache
+// @Param count query int false "填充筆數（預設 100）"
+// @Param prefix query string false "Key 前綴（預設 cluster:test:key）"
+// @Param value_size query int false "每筆資料的大小（位元組）"
+// @Param hash_tag query string false "所有 Key 共用的 hash tag"
+// @Param concurrency query int false "同時執行的 Pipeline 數量（預設 8）"
+// @Param batch_size query int false "每個 Pipeline 寫入的筆數（預設 500）"
+// @Param include_keys query bool false "列出每個 Key 的 slot 與負責的 Master（count 上限 10000），未指定時 count 不超過 10000 即列出"
 // @Success 200 {object} map[string]interface{} "成功填充"
-// @Failure 400 {object} map[string]interface{} "不支援的模式"
+// @Success 207 {object} map[string]interface{} "部分寫入失敗"
+// @Failure 400 {object} map[string]interface{} "不支援的模式或無效的參數"
 // @Failure 500 {object} map[string]interface{} "填充失敗"
 // @Router /fillcluster [get]
 func (cc *CacheController) FillCluster(c *gin.Context) {
 	// 檢查是否為 Cluster 模式
-	clusterConn, ok := cc.redisConn.(*redis.RedisCluster)
-	if !ok {
+	if _, ok := redislib.Unwrap(cc.redisConn).(*redis.RedisCluster); !ok {
+		cc.unsupportedMode(c, "FillCluster only supports RedisCluster mode")
+		return
+	}
+
+	opts, err := parseFillOptions(c)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "unsupported mode",
-			"message": "FillCluster only supports RedisCluster mode",
-			"mode":    fmt.Sprintf("%T", cc.redisConn),
+			"error":   "invalid fill options",
+			"message": err.Error(),
 		})
 		return
 	}
 
-	ctx := context.Background()
-	count := 100 // 預設填充 100 筆資料
-
-	if err := clusterConn.FillCluster(ctx, count); err != nil {
+	// 填充期間借用連線，熱重載替換連線時會等到填充結束才關閉舊連線
+	stream := strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+	var report redislib.FillReport
+	// 用戶端中斷連線時透過請求的 context 停止寫入
+	supported, err := borrowAs(c.Request.Context(), cc.redisConn, "fill_cluster",
+		func(ctx context.Context, clusterConn *redis.RedisCluster) error {
+			fill := func(ctx context.Context, progress func(redislib.FillProgress)) (redislib.FillReport, error) {
+				return clusterConn.FillCluster(ctx, opts, progress)
+			}
+			if stream {
+				return streamFill(ctx, c, fill)
+			}
+			var err error
+			report, err = fill(ctx, nil)
+			return err
+		})
+	if !supported {
+		cc.unsupportedMode(c, "FillCluster only supports RedisCluster mode")
+		return
+	}
+	// 中途停止時一併回報已寫入、失敗與沒有送出的數量
+	if stream || requestTimedOutWith(c, err, gin.H{"report": report}) {
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "fill failed",
 			"message": err.Error(),
-			"count":   count,
+			"count":   opts.Count,
+			"report":  report,
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("Successfully filled %d test records to cluster", count),
-		"count":   count,
+	c.JSON(batchStatus(report.Count, report.Failed), gin.H{
+		"message": fillMessage(report),
 		"mode":    "RedisCluster",
+		"report":  report,
+	})
+}
+
+// borrowAs 借出 conn 的原始連線給 fn（計入進行中的操作、指標與 tracing），返回 fn 的錯誤
+// 原始連線不是 T（不支援的模式，或檢查後連線被替換成其他模式）時不呼叫 fn 並返回 false
+func borrowAs[T redislib.IRedisConn](ctx context.Context, conn redislib.IRedisConn, operation string, fn func(ctx context.Context, conn T) error) (bool, error) {
+	supported := true
+	err := redislib.Borrow(ctx, conn, operation, func(ctx context.Context, inner redislib.IRedisConn) error {
+		typed, ok := inner.(T)
+		if !ok {
+			supported = false
+			return nil
+		}
+		return fn(ctx, typed)
+	})
+	return supported, err
+}
+
+// unsupportedMode 回應目前模式不支援此功能
+func (cc *CacheController) unsupportedMode(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   "unsupported mode",
+		"message": message,
+		"mode":    fmt.Sprintf("%T", redislib.Unwrap(cc.redisConn)),
 	})
 }
+
+// streamFill 以 Server-Sent Events 回報填充進度，返回填充的錯誤
+// 事件依序為數個 progress，最後是 done（內容為報告）或 error；用戶端中斷連線時停止寫入，並等待填充結束才返回
+func streamFill(ctx context.Context, c *gin.Context, fill func(ctx context.Context, progress func(redislib.FillProgress)) (redislib.FillReport, error)) error {
+	type fillResult struct {
+		report redislib.FillReport
+		err    error
+	}
+
+	progress := make(chan redislib.FillProgress, 16)
+	done := make(chan fillResult, 1)
+	go func() {
+		report, err := fill(ctx, func(p redislib.FillProgress) {
+			// 用戶端讀取太慢時略過中間的進度，不阻塞寫入
+			select {
+			case progress <- p:
+			default:
+			}
+		})
+		done <- fillResult{report: report, err: err}
+	}()
+
+	c.Header("Cache-Control", "no-cache")
+	for {
+		select {
+		case p := <-progress:
+			c.SSEvent("progress", p)
+			c.Writer.Flush()
+		case result := <-done:
+			for len(progress) > 0 {
+				c.SSEvent("progress", <-progress)
+			}
+			if result.err != nil {
+				c.SSEvent("error", gin.H{
+					"error":   "fill failed",
+					"message": result.err.Error(),
+					"report":  result.report,
+				})
+			} else {
+				c.SSEvent("done", gin.H{
+					"message": fillMessage(result.report),
+					"mode":    "RedisCluster",
+					"report":  result.report,
+				})
+			}
+			c.Writer.Flush()
+			return result.err
+		case <-ctx.Done():
+			// fill 已隨 ctx 取消，等它結束後才歸還連線
+			return (<-done).err
+		}
+	}
+}
+
+// fillMessage 產生填充結果的說明文字
+func fillMessage(report redislib.FillReport) string {
+	if report.Failed == 0 {
+		return fmt.Sprintf("Successfully filled %d test records to cluster", report.Count)
+	}
+	return fmt.Sprintf("Filled %d of %d test records to cluster, %d failed", report.Written, report.Count, report.Failed)
+}
+
+// parseFillOptions 解析 FillCluster 的查詢參數，並套用預設值與範圍檢查
+func parseFillOptions(c *gin.Context) (redislib.FillOptions, error) {
+	opts := redislib.FillOptions{
+		Prefix:  c.Query("prefix"),
+		HashTag: c.Query("hash_tag"),
+	}
+	if raw := c.Query("count"); raw != "" {
+		count, err := strconv.Atoi(raw)
+		if err != nil || count <= 0 {
+			return opts, fmt.Errorf("%w: count must be a positive integer, got %q", redislib.ErrInvalidFillOptions, raw)
+		}
+		opts.Count = count
+	}
+	if raw := c.Query("value_size"); raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil {
+			return opts, fmt.Errorf("%w: value_size must be an integer, got %q", redislib.ErrInvalidFillOptions, raw)
+		}
+		opts.ValueSize = size
+	}
+	if raw := c.Query("concurrency"); raw != "" {
+		concurrency, err := strconv.Atoi(raw)
+		if err != nil || concurrency <= 0 {
+			return opts, fmt.Errorf("%w: concurrency must be a positive integer, got %q", redislib.ErrInvalidFillOptions, raw)
+		}
+		opts.Concurrency = concurrency
+	}
+	if raw := c.Query("batch_size"); raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size <= 0 {
+			return opts, fmt.Errorf("%w: batch_size must be a positive integer, got %q", redislib.ErrInvalidFillOptions, raw)
+		}
+		opts.BatchSize = size
+	}
+	opts = opts.WithDefaults()
+	// 未指定 include_keys 時，筆數在上限內就列出每個 Key 的落點
+	opts.IncludeKeys = opts.Count <= redislib.MaxFillReportKeys
+	if raw := c.Query("include_keys"); raw != "" {
+		include, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, fmt.Errorf("%w: include_keys must be a boolean, got %q", redislib.ErrInvalidFillOptions, raw)
+		}
+		opts.IncludeKeys = include
+	}
+	return opts, opts.Validate()
+}
+
+// GetFailoverHistory 取得 Sentinel Failover 歷史
+// @Summary 取得 Sentinel Failover 歷史
+// @Description 列出 Sentinel 回報的 Master 切換與節點上下線事件（僅 Sentinel 模式支援）
+// @Tags Sentinel
+// @Success 200 {object} map[string]interface{} "事件列表（由舊到新）"
+// @Failure 400 {object} map[string]interface{} "不支援的模式"
+// @Router /sentinel/failovers [get]
+func (cc *CacheController) GetFailoverHistory(c *gin.Context) {
+	supported, _ := borrowAs(c.Request.Context(), cc.redisConn, "failover_history",
+		func(ctx context.Context, sentinelConn *redis.RedisSentinel) error {
+			events := sentinelConn.FailoverHistory()
+			c.JSON(http.StatusOK, gin.H{
+				"master_endpoint": sentinelConn.GetMasterEndpoint(),
+				"slave_endpoint":  sentinelConn.GetSlaveEndpoint(),
+				"count":           len(events),
+				"events":          events,
+			})
+			return nil
+		})
+	if !supported {
+		cc.unsupportedMode(c, "failover history only supports RedisSentinel mode")
+	}
+}
+
+// GetTopology 取得部署拓樸
+// @Summary 取得部署拓樸
+// @Description 以 JSON 回傳各節點的位址、角色、健康狀態、複寫來源與 slot / Raft 資訊（所有模式皆支援）。
+// @Description detail=true 時改為回傳各模式原始的資訊：Cluster 為 CLUSTER INFO 與 CLUSTER NODES，Raft 為各節點的 RAFT.INFO（僅 Cluster 與 Raft 模式支援）
+// @Tags Topology
+// @Param detail query bool false "回傳各模式原始的拓樸資訊"
+// @Success 200 {object} redislib.Topology "拓樸資訊"
+// @Failure 400 {object} map[string]interface{} "無效的參數或 detail 不支援的模式"
+// @Failure 500 {object} map[string]interface{} "查詢失敗"
+// @Router /topology [get]
+func (cc *CacheController) GetTopology(c *gin.Context) {
+	detail := false
+	if raw := c.Query("detail"); raw != "" {
+		var err error
+		if detail, err = strconv.ParseBool(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid detail",
+				"message": fmt.Sprintf("detail must be a boolean, got %q", raw),
+			})
+			return
+		}
+	}
+	if detail {
+		cc.topologyDetail(c)
+		return
+	}
+
+	topology, err := cc.redisConn.Topology(c.Request.Context())
+	if requestTimedOut(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "topology failed",
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, topology)
+}
+
+// topologyDetail 回傳各模式原始的拓樸資訊
+// Cluster 為 CLUSTER INFO 與 CLUSTER NODES（包含 flags、config epoch 與搬移中的 slot），
+// Raft 為 Leader 的 RAFT.INFO 與各節點自己回報的 RAFT.INFO
+func (cc *CacheController) topologyDetail(c *gin.Context) {
+	var detail gin.H
+	err := redislib.Borrow(c.Request.Context(), cc.redisConn, "topology", func(ctx context.Context, conn redislib.IRedisConn) error {
+		var err error
+		switch conn := conn.(type) {
+		case *redis.RedisCluster:
+			detail, err = clusterTopologyDetail(ctx, conn)
+		case *redis.RedisRaft:
+			detail, err = raftTopologyDetail(ctx, conn)
+		}
+		return err
+	})
+	if requestTimedOut(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "topology failed",
+			"message": err.Error(),
+		})
+		return
+	}
+	if detail == nil {
+		cc.unsupportedMode(c, "topology detail only supports RedisCluster and RedisRaft mode")
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// clusterTopologyDetail 取得 CLUSTER INFO 與 CLUSTER NODES
+func clusterTopologyDetail(ctx context.Context, conn *redis.RedisCluster) (gin.H, error) {
+	info, err := conn.GetClusterInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := conn.GetClusterNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"mode":    redislib.RedisCluster.String(),
+		"cluster": info,
+		"nodes":   nodes,
+	}, nil
+}
+
+// raftTopologyDetail 取得 Leader 的 RAFT.INFO 與各節點自己回報的 RAFT.INFO，無法連線的節點只回報錯誤
+func raftTopologyDetail(ctx context.Context, conn *redis.RedisRaft) (gin.H, error) {
+	info, err := conn.GetRaftInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := conn.GetRaftNodes()
+	nodes := make([]gin.H, 0, len(addrs))
+	for _, addr := range addrs {
+		node := gin.H{"endpoint": addr}
+		if nodeInfo, err := conn.GetRaftNode(ctx, addr); err != nil {
+			node["error"] = err.Error()
+		} else {
+			node["info"] = nodeInfo
+		}
+		nodes = append(nodes, node)
+	}
+	return gin.H{
+		"mode":   redislib.RedisRaft.String(),
+		"leader": conn.GetMasterEndpoint(),
+		"raft":   info,
+		"nodes":  nodes,
+	}, nil
+}
+
+// ReadinessResponse 就緒檢查回應
+type ReadinessResponse struct {
+	Status string `json:"status"` // ready 或 not ready
+	redislib.Readiness
+}
+
+// GetLiveness 存活檢查，只確認程序可以處理請求，不存取 Redis
+// @Summary 存活檢查
+// @Description 程序可以處理 HTTP 請求即回傳 200，不會因為 Redis 故障而失敗（適用於 livenessProbe）
+// @Tags Health
+// @Success 200 {object} map[string]interface{} "程序存活"
+// @Router /health/live [get]
+func (cc *CacheController) GetLiveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "alive",
+	})
+}
+
+// GetReadiness 就緒檢查，依模式探測 Master / Replica / Sentinel / Cluster 節點 / Raft 節點並回傳各節點的狀態與延遲
+// @Summary 就緒檢查
+// @Description 失去寫入能力（Master / Leader 無法寫入）或失去多數派（Sentinel quorum、cluster_state、Raft 多數節點）時回傳 503（適用於 readinessProbe）
+// @Tags Health
+// @Success 200 {object} ReadinessResponse "已就緒"
+// @Failure 503 {object} ReadinessResponse "未就緒"
+// @Router /health/ready [get]
+func (cc *CacheController) GetReadiness(c *gin.Context) {
+	readiness := cc.redisConn.Readiness(c.Request.Context())
+	if !readiness.Ready {
+		c.JSON(http.StatusServiceUnavailable, ReadinessResponse{Status: "not ready", Readiness: readiness})
+		return
+	}
+	c.JSON(http.StatusOK, ReadinessResponse{Status: "ready", Readiness: readiness})
+}
@@ -1,13 +1,24 @@
 package controller
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/AmandaChou/RedisLab/APGo/internal/redis"
+	"github.com/AmandaChou/RedisLab/APGo/internal/reload"
 	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
 	"github.com/gin-gonic/gin"
 )
@@ -16,8 +27,17 @@ import (
 type MockRedisConn struct {
 	readFunc   func(ctx context.Context, key string) (string, error)
 	writeFunc  func(ctx context.Context, key, value string) (bool, error)
-	masterAddr string
-	slaveAddr  string
+	ttlWrite   func(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	ttlFunc    func(ctx context.Context, key string) (time.Duration, error)
+	deleteFunc func(ctx context.Context, keys []string) (int64, error)
+	existsFunc func(ctx context.Context, key string) (bool, error)
+	batchWrite func(ctx context.Context, entries []redislib.KeyValue) ([]redislib.BatchResult, error)
+	// topologyFunc 未設定時回傳一個 Master 與一個 Replica
+	topologyFunc func(ctx context.Context) (redislib.Topology, error)
+	// readinessFunc 未設定時回傳健康的 Master 與 Replica
+	readinessFunc func(ctx context.Context) redislib.Readiness
+	masterAddr    string
+	slaveAddr     string
 }
 
 func (m *MockRedisConn) ReadAsync(ctx context.Context, key string) (string, error) {
@@ -27,6 +47,11 @@ func (m *MockRedisConn) ReadAsync(ctx context.Context, key string) (string, erro
 	return "", redislib.ErrKeyNotFound
 }
 
+func (m *MockRedisConn) ReadWithOptionsAsync(ctx context.Context, key string, opts redislib.ReadOptions) (redislib.ReadResult, error) {
+	val, err := m.ReadAsync(ctx, key)
+	return redislib.ReadResult{Value: val, Node: m.GetSlaveEndpoint()}, err
+}
+
 func (m *MockRedisConn) WriteAsync(ctx context.Context, key, value string) (bool, error) {
 	if m.writeFunc != nil {
 		return m.writeFunc(ctx, key, value)
@@ -34,6 +59,102 @@ func (m *MockRedisConn) WriteAsync(ctx context.Context, key, value string) (bool
 	return true, nil
 }
 
+func (m *MockRedisConn) WriteWithTTLAsync(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	if m.ttlWrite != nil {
+		return m.ttlWrite(ctx, key, value, ttl)
+	}
+	return m.WriteAsync(ctx, key, value)
+}
+
+func (m *MockRedisConn) ConsistencyTokenAsync(ctx context.Context) (redislib.ConsistencyToken, error) {
+	return 0, nil
+}
+
+func (m *MockRedisConn) Topology(ctx context.Context) (redislib.Topology, error) {
+	if m.topologyFunc != nil {
+		return m.topologyFunc(ctx)
+	}
+	return redislib.Topology{
+		Mode: "Mock",
+		Nodes: []redislib.TopologyNode{
+			{Address: m.GetMasterEndpoint(), Role: redislib.RoleMaster, Healthy: true},
+			{Address: m.GetSlaveEndpoint(), Role: redislib.RoleReplica, Healthy: true, Parent: m.GetMasterEndpoint()},
+		},
+	}, nil
+}
+
+func (m *MockRedisConn) Readiness(ctx context.Context) redislib.Readiness {
+	if m.readinessFunc != nil {
+		return m.readinessFunc(ctx)
+	}
+	return redislib.NewReadiness("Mock", []redislib.NodeCheck{
+		{Address: m.GetMasterEndpoint(), Role: redislib.RoleMaster, Healthy: true, LatencyMs: 0.4},
+		{Address: m.GetSlaveEndpoint(), Role: redislib.RoleReplica, Healthy: true, LatencyMs: 0.6},
+	})
+}
+
+func (m *MockRedisConn) GetTTLAsync(ctx context.Context, key string) (time.Duration, error) {
+	if m.ttlFunc != nil {
+		return m.ttlFunc(ctx, key)
+	}
+	return redislib.NoTTL, nil
+}
+
+func (m *MockRedisConn) ExpireAsync(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (m *MockRedisConn) PersistAsync(ctx context.Context, key string) (bool, error) {
+	return true, nil
+}
+
+func (m *MockRedisConn) DeleteAsync(ctx context.Context, key string) (bool, error) {
+	n, err := m.DeleteManyAsync(ctx, []string{key})
+	return n > 0, err
+}
+
+func (m *MockRedisConn) DeleteManyAsync(ctx context.Context, keys []string) (int64, error) {
+	if m.deleteFunc != nil {
+		return m.deleteFunc(ctx, keys)
+	}
+	return int64(len(keys)), nil
+}
+
+func (m *MockRedisConn) ExistsAsync(ctx context.Context, key string) (bool, error) {
+	if m.existsFunc != nil {
+		return m.existsFunc(ctx, key)
+	}
+	return false, nil
+}
+
+func (m *MockRedisConn) BatchReadAsync(ctx context.Context, keys []string) ([]redislib.BatchResult, error) {
+	results := make([]redislib.BatchResult, len(keys))
+	for i, key := range keys {
+		results[i] = redislib.BatchResult{Key: key, Node: m.GetSlaveEndpoint()}
+		val, err := m.ReadAsync(ctx, key)
+		switch {
+		case err == redislib.ErrKeyNotFound:
+		case err != nil:
+			results[i].Err = err
+		default:
+			results[i].Value = val
+			results[i].Found = true
+		}
+	}
+	return results, nil
+}
+
+func (m *MockRedisConn) BatchWriteAsync(ctx context.Context, entries []redislib.KeyValue) ([]redislib.BatchResult, error) {
+	if m.batchWrite != nil {
+		return m.batchWrite(ctx, entries)
+	}
+	results := make([]redislib.BatchResult, len(entries))
+	for i, entry := range entries {
+		results[i] = redislib.BatchResult{Key: entry.Key, Node: m.GetMasterEndpoint()}
+	}
+	return results, nil
+}
+
 func (m *MockRedisConn) GetRandomCache(ctx context.Context, key string) (string, error) {
 	return m.ReadAsync(ctx, key)
 }
@@ -138,6 +259,161 @@ func TestGetCache_MissingKey(t *testing.T) {
 	}
 }
 
+func TestGetCache_ReadPreference(t *testing.T) {
+	conn, err := redis.NewRedisInMemory("memory:master", []string{"memory:replica-1"}, 0)
+	if err != nil {
+		t.Fatalf("Failed to create RedisInMemory: %v", err)
+	}
+	defer conn.Close()
+	conn.WriteAsync(context.Background(), "k", "v")
+
+	controller := NewCacheController(conn)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/cache", controller.GetCache)
+
+	tests := []struct {
+		query        string
+		wantStatus   int
+		wantReadFrom string
+	}{
+		{"?key=k", http.StatusOK, "memory:replica-1"},
+		{"?key=k&read_preference=primary", http.StatusOK, "memory:master"},
+		{"?key=k&read_preference=bogus", http.StatusBadRequest, ""},
+		{"?key=k&max_staleness_ms=100&max_lag_bytes=10", http.StatusOK, "memory:replica-1"},
+		{"?key=k&max_staleness_ms=0", http.StatusOK, "memory:replica-1"},
+		{"?key=k&max_staleness_ms=-1", http.StatusOK, "memory:replica-1"},
+		{"?key=k&max_staleness_ms=-2", http.StatusBadRequest, ""},
+		{"?key=k&max_lag_bytes=abc", http.StatusBadRequest, ""},
+		{"?key=k&consistency_token=1", http.StatusOK, "memory:replica-1"},
+		{"?key=k&consistency_token=-5", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/cache"+tt.query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("GET /cache%s: expected status %d, got %d", tt.query, tt.wantStatus, w.Code)
+			continue
+		}
+		if tt.wantReadFrom == "" {
+			continue
+		}
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response["read_from"] != tt.wantReadFrom {
+			t.Errorf("GET /cache%s: expected read_from %s, got %v", tt.query, tt.wantReadFrom, response["read_from"])
+		}
+	}
+}
+
+func TestGetCache_LagLimitOverrides(t *testing.T) {
+	// 複寫延遲一小時，寫入後 Replica 一直落後
+	conn, err := redis.NewRedisInMemory("memory:master", []string{"memory:replica-1"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create RedisInMemory: %v", err)
+	}
+	defer conn.Close()
+	conn.WriteAsync(context.Background(), "k", "v")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/cache", NewCacheController(conn).GetCache)
+
+	tests := []struct {
+		query        string
+		wantStatus   int
+		wantReadFrom string
+	}{
+		{"?key=k&max_staleness_ms=0", http.StatusOK, "memory:master"},
+		{"?key=k&max_lag_bytes=0", http.StatusOK, "memory:master"},
+		{"?key=k&max_staleness_ms=0&read_preference=replica", http.StatusInternalServerError, ""},
+		// 不限制落差時由落後的 Replica 提供（Key 尚未複寫）
+		{"?key=k&max_staleness_ms=-1&max_lag_bytes=-1", http.StatusNotFound, "memory:replica-1"},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/cache"+tt.query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("GET /cache%s: expected status %d, got %d: %s", tt.query, tt.wantStatus, w.Code, w.Body.String())
+			continue
+		}
+		if tt.wantReadFrom == "" {
+			continue
+		}
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response["read_from"] != tt.wantReadFrom {
+			t.Errorf("GET /cache%s: expected read_from %s, got %v", tt.query, tt.wantReadFrom, response["read_from"])
+		}
+	}
+}
+
+func TestCache_ReadYourWrites(t *testing.T) {
+	conn, err := redis.NewRedisInMemory("memory:master", []string{"memory:replica-1"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create RedisInMemory: %v", err)
+	}
+	defer conn.Close()
+
+	controller := NewCacheController(conn)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/cache", controller.GetCache)
+	router.POST("/cache", controller.UpdateCache)
+
+	body, _ := json.Marshal(CacheRequest{Key: "k", Value: "v"})
+	req, _ := http.NewRequest("POST", "/cache", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var written map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &written); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	token, ok := written["consistency_token"].(string)
+	if !ok || token == "" || token == "0" {
+		t.Fatalf("Expected consistency_token in response, got %v", written["consistency_token"])
+	}
+
+	// 沒有 Token 時由尚未複寫的 Replica 提供
+	req, _ = http.NewRequest("GET", "/cache?key=k", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected stale replica read to return 404, got %d", w.Code)
+	}
+
+	// 帶入 Token 時 Replica 尚未追上，改由 Master 提供
+	req, _ = http.NewRequest("GET", "/cache?key=k&consistency_token="+token, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected read-your-writes read to return 200, got %d", w.Code)
+	}
+	var read map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &read); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if read["value"] != "v" || read["read_from"] != "memory:master" {
+		t.Errorf("Expected value v from master, got %v from %v", read["value"], read["read_from"])
+	}
+}
+
 func TestUpdateCache_Success(t *testing.T) {
 	mockConn := &MockRedisConn{
 		writeFunc: func(ctx context.Context, key, value string) (bool, error) {
@@ -194,3 +470,761 @@ func TestUpdateCache_InvalidRequest(t *testing.T) {
 		t.Errorf("Expected status 400, got %d", w.Code)
 	}
 }
+
+func TestUpdateCache_WithTTL(t *testing.T) {
+	var gotTTL time.Duration
+	mockConn := &MockRedisConn{
+		ttlWrite: func(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+			gotTTL = ttl
+			return true, nil
+		},
+	}
+
+	controller := NewCacheController(mockConn)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/cache", controller.UpdateCache)
+
+	req, _ := http.NewRequest("POST", "/cache",
+		bytes.NewBufferString(`{"key":"session:1","value":"v","ttl_seconds":30}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if gotTTL != 30*time.Second {
+		t.Errorf("Expected ttl 30s, got %s", gotTTL)
+	}
+}
+
+func TestUpdateCache_NegativeTTL(t *testing.T) {
+	mockConn := &MockRedisConn{}
+	controller := NewCacheController(mockConn)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/cache", controller.UpdateCache)
+
+	req, _ := http.NewRequest("POST", "/cache",
+		bytes.NewBufferString(`{"key":"session:1","value":"v","ttl_seconds":-5}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestGetCacheTTL(t *testing.T) {
+	mockConn := &MockRedisConn{
+		ttlFunc: func(ctx context.Context, key string) (time.Duration, error) {
+			switch key {
+			case "session:1":
+				return 42 * time.Second, nil
+			case "forever":
+				return redislib.NoTTL, nil
+			}
+			return 0, redislib.ErrKeyNotFound
+		},
+	}
+
+	controller := NewCacheController(mockConn)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/cache/ttl", controller.GetCacheTTL)
+
+	tests := []struct {
+		key        string
+		wantStatus int
+		wantTTL    float64
+	}{
+		{"session:1", http.StatusOK, 42},
+		{"forever", http.StatusOK, -1},
+		{"missing", http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/cache/ttl?key="+tt.key, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if response["ttl_seconds"] != tt.wantTTL {
+				t.Errorf("Expected ttl_seconds %v, got %v", tt.wantTTL, response["ttl_seconds"])
+			}
+		})
+	}
+}
+
+func TestUpdateCacheTTL_KeyNotFound(t *testing.T) {
+	conn, err := redis.NewRedisInMemory("memory-master", nil, 0)
+	if err != nil {
+		t.Fatalf("Failed to create in-memory connection: %v", err)
+	}
+	defer conn.Close()
+	controller := NewCacheController(conn)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/cache/ttl", controller.UpdateCacheTTL)
+
+	// 設定與移除過期時間對不存在的 key 回傳相同的狀態
+	for _, body := range []string{`{"key":"missing","ttl_seconds":10}`, `{"key":"missing","ttl_seconds":0}`} {
+		req, _ := http.NewRequest("POST", "/cache/ttl", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestDeleteCache(t *testing.T) {
+	mockConn := &MockRedisConn{
+		deleteFunc: func(ctx context.Context, keys []string) (int64, error) {
+			var n int64
+			for _, key := range keys {
+				if key != "missing" {
+					n++
+				}
+			}
+			return n, nil
+		},
+	}
+
+	controller := NewCacheController(mockConn)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.DELETE("/cache", controller.DeleteCache)
+
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantDeleted float64
+	}{
+		{"single key", "?key=a", http.StatusOK, 1},
+		{"multiple keys", "?key=a&key=b&key=missing", http.StatusOK, 2},
+		{"single missing key", "?key=missing", http.StatusNotFound, 0},
+		{"no key", "", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("DELETE", "/cache"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if response["deleted"] != tt.wantDeleted {
+				t.Errorf("Expected deleted %v, got %v", tt.wantDeleted, response["deleted"])
+			}
+		})
+	}
+}
+
+func TestHeadCache(t *testing.T) {
+	mockConn := &MockRedisConn{
+		existsFunc: func(ctx context.Context, key string) (bool, error) {
+			return key == "present", nil
+		},
+	}
+
+	controller := NewCacheController(mockConn)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.HEAD("/cache", controller.HeadCache)
+
+	tests := []struct {
+		query      string
+		wantStatus int
+	}{
+		{"?key=present", http.StatusOK},
+		{"?key=absent", http.StatusNotFound},
+		{"", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("HEAD", "/cache"+tt.query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("HEAD /cache%s: expected status %d, got %d", tt.query, tt.wantStatus, w.Code)
+		}
+	}
+}
+
+func TestGetCacheBatch(t *testing.T) {
+	mockConn := &MockRedisConn{
+		readFunc: func(ctx context.Context, key string) (string, error) {
+			switch key {
+			case "a":
+				return "1", nil
+			case "broken":
+				return "", redislib.ErrReadFailed
+			}
+			return "", redislib.ErrKeyNotFound
+		},
+	}
+
+	controller := NewCacheController(mockConn)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/cache/batch", controller.GetCacheBatch)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{"all ok", "?keys=a,missing", http.StatusOK},
+		{"partial failure", "?keys=a,broken", http.StatusMultiStatus},
+		{"all failed", "?keys=broken", http.StatusInternalServerError},
+		{"no keys", "?keys=,", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/cache/batch"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestUpdateCacheBatch(t *testing.T) {
+	var got []redislib.KeyValue
+	mockConn := &MockRedisConn{
+		batchWrite: func(ctx context.Context, entries []redislib.KeyValue) ([]redislib.BatchResult, error) {
+			got = entries
+			results := make([]redislib.BatchResult, len(entries))
+			for i, entry := range entries {
+				results[i] = redislib.BatchResult{Key: entry.Key}
+			}
+			results[1].Err = redislib.ErrWriteFailed
+			return results, nil
+		},
+	}
+
+	controller := NewCacheController(mockConn)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/cache/batch", controller.UpdateCacheBatch)
+
+	body := `{"items":[{"key":"a","value":"1","ttl_seconds":5},{"key":"b","value":"2"}]}`
+	req, _ := http.NewRequest("POST", "/cache/batch", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("Expected status 207, got %d", w.Code)
+	}
+	if len(got) != 2 || got[0].TTL != 5*time.Second || got[1].TTL != 0 {
+		t.Errorf("Unexpected entries passed to BatchWriteAsync: %+v", got)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response["failed"] != float64(1) {
+		t.Errorf("Expected failed 1, got %v", response["failed"])
+	}
+
+	// 空的批次與缺少 value 的項目都應回傳 400
+	for _, body := range []string{`{"items":[]}`, `{"items":[{"key":"a"}]}`} {
+		req, _ := http.NewRequest("POST", "/cache/batch", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Body %s: expected status 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestCacheRoundTrip_InMemory(t *testing.T) {
+	// 使用內嵌記憶體模式完整走過寫入與讀取流程
+	conn, err := redis.NewRedisInMemory("memory:master", []string{"memory:replica-1"}, 0)
+	if err != nil {
+		t.Fatalf("Failed to create RedisInMemory: %v", err)
+	}
+	defer conn.Close()
+
+	controller := NewCacheController(conn)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/cache", controller.GetCache)
+	router.POST("/cache", controller.UpdateCache)
+
+	req, _ := http.NewRequest("POST", "/cache",
+		bytes.NewBufferString(`{"key":"product:001","value":"Laptop"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on write, got %d", w.Code)
+	}
+
+	req, _ = http.NewRequest("GET", "/cache?key=product:001", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on read, got %d", w.Code)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response["value"] != "Laptop" {
+		t.Errorf("Expected value 'Laptop', got %v", response["value"])
+	}
+	if response["read_from"] != "memory:replica-1" {
+		t.Errorf("Expected read_from 'memory:replica-1', got %v", response["read_from"])
+	}
+}
+
+func TestParseFillOptions(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    redislib.FillOptions
+		wantErr bool
+	}{
+		{"", redislib.FillOptions{Count: 100, Prefix: "cluster:test:key", Concurrency: 8, BatchSize: 500, IncludeKeys: true}, false},
+		{"?count=5&prefix=load&value_size=64&hash_tag=user&concurrency=2&batch_size=50",
+			redislib.FillOptions{Count: 5, Prefix: "load", ValueSize: 64, HashTag: "user", Concurrency: 2, BatchSize: 50, IncludeKeys: true}, false},
+		{"?count=0", redislib.FillOptions{}, true},
+		{"?count=abc", redislib.FillOptions{}, true},
+		{"?value_size=-1", redislib.FillOptions{}, true},
+		{"?hash_tag={x}", redislib.FillOptions{}, true},
+		{"?concurrency=0", redislib.FillOptions{}, true},
+		{"?concurrency=1000", redislib.FillOptions{}, true},
+		{"?batch_size=x", redislib.FillOptions{}, true},
+		{"?count=10&include_keys=true",
+			redislib.FillOptions{Count: 10, Prefix: "cluster:test:key", Concurrency: 8, BatchSize: 500, IncludeKeys: true}, false},
+		{"?count=10&include_keys=false",
+			redislib.FillOptions{Count: 10, Prefix: "cluster:test:key", Concurrency: 8, BatchSize: 500}, false},
+		{"?count=20000", redislib.FillOptions{Count: 20000, Prefix: "cluster:test:key", Concurrency: 8, BatchSize: 500}, false},
+		{"?include_keys=yes", redislib.FillOptions{}, true},
+		{"?count=20000&include_keys=1", redislib.FillOptions{}, true},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest("GET", "/fillcluster"+tt.query, nil)
+
+		got, err := parseFillOptions(c)
+		if tt.wantErr {
+			if !errors.Is(err, redislib.ErrInvalidFillOptions) {
+				t.Errorf("parseFillOptions(%q) error = %v, want ErrInvalidFillOptions", tt.query, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseFillOptions(%q) = %+v, %v, want %+v", tt.query, got, err, tt.want)
+		}
+	}
+}
+
+func TestFillCluster_UnsupportedMode(t *testing.T) {
+	controller := NewCacheController(&MockRedisConn{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/fillcluster", controller.FillCluster)
+
+	req, _ := http.NewRequest("GET", "/fillcluster?count=10", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+// fakeClusterNode 只回應建立 Cluster 連線所需指令的單節點 Cluster
+// CLUSTER NODES 會等到 release 關閉才回應錯誤，用來讓 FillCluster 停在執行中
+type fakeClusterNode struct {
+	listener net.Listener
+	started  chan struct{}
+	release  chan struct{}
+	once     sync.Once
+}
+
+func newFakeClusterNode(t *testing.T) *fakeClusterNode {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	node := &fakeClusterNode{listener: listener, started: make(chan struct{}), release: make(chan struct{})}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go node.serve(conn)
+		}
+	}()
+	return node
+}
+
+func (n *fakeClusterNode) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	host, port, _ := net.SplitHostPort(n.listener.Addr().String())
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		var reply string
+		switch strings.ToUpper(strings.Join(args, " ")) {
+		case "HELLO 3":
+			reply = "-ERR unknown command 'HELLO'\r\n"
+		case "PING":
+			reply = "+PONG\r\n"
+		case "CLUSTER SLOTS":
+			reply = fmt.Sprintf("*1\r\n*3\r\n:0\r\n:16383\r\n*2\r\n$%d\r\n%s\r\n:%s\r\n", len(host), host, port)
+		case "CLUSTER NODES":
+			n.once.Do(func() { close(n.started) })
+			<-n.release
+			reply = "-ERR released\r\n"
+		default:
+			reply = "+OK\r\n"
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand 讀取一個 RESP 陣列格式的指令
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, count)
+	for i := range args {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+// closeRecorder 記錄被包裝的連線是否已被關閉
+type closeRecorder struct {
+	redislib.IRedisConn
+	closed atomic.Bool
+}
+
+func (c *closeRecorder) Unwrap() redislib.IRedisConn {
+	return c.IRedisConn
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed.Store(true)
+	return c.IRedisConn.Close()
+}
+
+func TestFillCluster_SwapWaitsForFill(t *testing.T) {
+	node := newFakeClusterNode(t)
+	cluster, err := redis.NewRedisClusterWithOptions([]string{node.listener.Addr().String()},
+		redis.ClusterOptions{Client: redis.ClientOptions{ReadTimeout: -1, MaxRetries: -1}})
+	if err != nil {
+		t.Fatalf("NewRedisClusterWithOptions() error = %v", err)
+	}
+	old := &closeRecorder{IRedisConn: cluster}
+	next, err := redis.NewRedisInMemory("memory:next", nil, 0)
+	if err != nil {
+		t.Fatalf("NewRedisInMemory() error = %v", err)
+	}
+	conn := reload.NewSwappableConn(old, 0)
+	defer conn.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/fillcluster", NewCacheController(conn).FillCluster)
+
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req, _ := http.NewRequest("GET", "/fillcluster?count=10", nil)
+		router.ServeHTTP(w, req)
+	}()
+	<-node.started
+
+	if err := conn.Swap(next); err != nil {
+		t.Fatalf("Swap() error = %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if old.closed.Load() {
+		t.Fatal("Expected the cluster connection to stay open while FillCluster is running")
+	}
+
+	close(node.release)
+	<-done
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 from the released fill, got %d: %s", w.Code, w.Body.String())
+	}
+	deadline := time.Now().Add(time.Second)
+	for !old.closed.Load() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !old.closed.Load() {
+		t.Error("Expected the cluster connection to be closed after FillCluster returned")
+	}
+}
+
+func TestStreamFill(t *testing.T) {
+	tests := []struct {
+		name      string
+		fillErr   error
+		wantEvent string
+	}{
+		{"done", nil, "event:done"},
+		{"error", context.Canceled, "event:error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fill := func(ctx context.Context, progress func(redislib.FillProgress)) (redislib.FillReport, error) {
+				progress(redislib.FillProgress{Total: 2, Written: 1})
+				progress(redislib.FillProgress{Total: 2, Written: 2})
+				return redislib.FillReport{Count: 2}, tt.fillErr
+			}
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/fillcluster", func(c *gin.Context) { streamFill(c.Request.Context(), c, fill) })
+
+			req, _ := http.NewRequest("GET", "/fillcluster", nil)
+			req.Header.Set("Accept", "text/event-stream")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			body := w.Body.String()
+			if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/event-stream") {
+				t.Errorf("Expected text/event-stream, got %q", got)
+			}
+			if n := strings.Count(body, "event:progress"); n != 2 {
+				t.Errorf("Expected 2 progress events, got %d in %q", n, body)
+			}
+			if !strings.Contains(body, tt.wantEvent) {
+				t.Errorf("Expected %s in %q", tt.wantEvent, body)
+			}
+			if strings.LastIndex(body, "event:progress") > strings.Index(body, tt.wantEvent) {
+				t.Errorf("Expected progress events before %s in %q", tt.wantEvent, body)
+			}
+		})
+	}
+}
+
+func TestGetFailoverHistory_UnsupportedMode(t *testing.T) {
+	controller := NewCacheController(&MockRedisConn{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/sentinel/failovers", controller.GetFailoverHistory)
+
+	req, _ := http.NewRequest("GET", "/sentinel/failovers", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestGetTopology(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		topology   func(ctx context.Context) (redislib.Topology, error)
+		wantStatus int
+		wantNodes  int
+	}{
+		{"default topology", "", nil, http.StatusOK, 2},
+		{"detail disabled", "?detail=false", nil, http.StatusOK, 2},
+		{"topology failed", "", func(ctx context.Context) (redislib.Topology, error) {
+			return redislib.Topology{}, redislib.ErrConnectionFailed
+		}, http.StatusInternalServerError, 0},
+		// detail 只支援 Cluster 與 Raft
+		{"detail unsupported mode", "?detail=true", nil, http.StatusBadRequest, 0},
+		{"invalid detail", "?detail=maybe", nil, http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := NewCacheController(&MockRedisConn{topologyFunc: tt.topology})
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/topology", controller.GetTopology)
+
+			req, _ := http.NewRequest("GET", "/topology"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var topology redislib.Topology
+			if err := json.Unmarshal(w.Body.Bytes(), &topology); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if len(topology.Nodes) != tt.wantNodes {
+				t.Errorf("Expected %d nodes, got %d", tt.wantNodes, len(topology.Nodes))
+			}
+		})
+	}
+}
+
+func TestGetLiveness(t *testing.T) {
+	controller := NewCacheController(&MockRedisConn{readinessFunc: func(ctx context.Context) redislib.Readiness {
+		t.Error("liveness must not check Redis")
+		return redislib.Readiness{}
+	}})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/health/live", controller.GetLiveness)
+
+	req, _ := http.NewRequest("GET", "/health/live", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestGetReadiness(t *testing.T) {
+	lostMaster := func(ctx context.Context) redislib.Readiness {
+		readiness := redislib.NewReadiness("Mock", []redislib.NodeCheck{
+			{Address: "master:6379", Role: redislib.RoleMaster, Error: "connection refused"},
+		})
+		readiness.LoseWrites("master master:6379 is not writable")
+		return readiness
+	}
+	lostQuorum := func(ctx context.Context) redislib.Readiness {
+		readiness := redislib.NewReadiness("Mock", []redislib.NodeCheck{
+			{Address: "master:6379", Role: redislib.RoleMaster, Healthy: true},
+			{Address: "sentinel:26379", Role: redislib.RoleSentinel, Error: "NOQUORUM"},
+		})
+		readiness.LoseQuorum("no sentinel reports quorum")
+		return readiness
+	}
+
+	tests := []struct {
+		name         string
+		readiness    func(ctx context.Context) redislib.Readiness
+		wantStatus   int
+		wantText     string
+		wantWritable bool
+		wantQuorum   bool
+		wantNodes    int
+	}{
+		{"all nodes healthy", nil, http.StatusOK, "ready", true, true, 2},
+		{"master lost", lostMaster, http.StatusServiceUnavailable, "not ready", false, true, 1},
+		{"quorum lost", lostQuorum, http.StatusServiceUnavailable, "not ready", true, false, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := NewCacheController(&MockRedisConn{readinessFunc: tt.readiness})
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/health/ready", controller.GetReadiness)
+
+			req, _ := http.NewRequest("GET", "/health/ready", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+
+			var response ReadinessResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if response.Status != tt.wantText {
+				t.Errorf("Expected status %q, got %q", tt.wantText, response.Status)
+			}
+			if response.Writable != tt.wantWritable || response.Quorum != tt.wantQuorum {
+				t.Errorf("Expected writable=%v quorum=%v, got writable=%v quorum=%v",
+					tt.wantWritable, tt.wantQuorum, response.Writable, response.Quorum)
+			}
+			if len(response.Nodes) != tt.wantNodes {
+				t.Errorf("Expected %d nodes, got %d", tt.wantNodes, len(response.Nodes))
+			}
+			if tt.wantStatus != http.StatusOK && len(response.Reasons) == 0 {
+				t.Error("Expected reasons when not ready")
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestTimeoutKey gin.Context 中記錄本次請求逾時時間的鍵
+const requestTimeoutKey = "request_timeout"
+
+// RequestTimeout 為每個請求的 context 設定逾時
+// routes 以 "METHOD /path"（與註冊路由時的路徑相同）覆寫個別路由的逾時，逾時 <= 0 表示不限制
+func RequestTimeout(defaultTimeout time.Duration, routes map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := defaultTimeout
+		if t, ok := routes[c.Request.Method+" "+c.FullPath()]; ok {
+			timeout = t
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(requestTimeoutKey, timeout)
+		c.Next()
+	}
+}
+
+// requestTimedOut 請求已超過逾時時間時回傳 504 並返回 true
+// 除了 err 本身也檢查請求的 context，因為部分連線實作包裝錯誤時不會保留原始錯誤
+func requestTimedOut(c *gin.Context, err error) bool {
+	return requestTimedOutWith(c, err, nil)
+}
+
+// requestTimedOutWith 與 requestTimedOut 相同，504 回應另外帶有 extra 的欄位（例如逾時前的部分結果）
+func requestTimedOutWith(c *gin.Context, err error, extra gin.H) bool {
+	if err == nil {
+		return false
+	}
+	if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		return false
+	}
+
+	message := "request deadline exceeded"
+	if timeout, ok := c.Get(requestTimeoutKey); ok {
+		message = fmt.Sprintf("request did not complete within %s", timeout)
+	}
+	body := gin.H{
+		"error":   "request timeout",
+		"message": message,
+		"route":   c.Request.Method + " " + c.FullPath(),
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	c.AbortWithStatusJSON(http.StatusGatewayTimeout, body)
+	return true
+}
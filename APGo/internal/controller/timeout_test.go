@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	// 模擬會一直等到 context 結束的讀取，且包裝錯誤時不保留原始錯誤
+	slowRead := func(ctx context.Context, key string) (string, error) {
+		<-ctx.Done()
+		return "", fmt.Errorf("%w: %v", redislib.ErrReadFailed, ctx.Err())
+	}
+
+	tests := []struct {
+		name       string
+		timeout    time.Duration
+		routes     map[string]time.Duration
+		read       func(ctx context.Context, key string) (string, error)
+		wantStatus int
+	}{
+		{"default timeout", 20 * time.Millisecond, nil, slowRead, http.StatusGatewayTimeout},
+		{"route override", time.Hour, map[string]time.Duration{"GET /cache": 20 * time.Millisecond}, slowRead, http.StatusGatewayTimeout},
+		{"other error", 20 * time.Millisecond, nil, func(ctx context.Context, key string) (string, error) {
+			return "", redislib.ErrReadFailed
+		}, http.StatusInternalServerError},
+		{"route without timeout", 20 * time.Millisecond, map[string]time.Duration{"GET /cache": -1}, func(ctx context.Context, key string) (string, error) {
+			if _, ok := ctx.Deadline(); ok {
+				return "", redislib.ErrReadFailed
+			}
+			return "v", nil
+		}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := NewCacheController(&MockRedisConn{readFunc: tt.read})
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(RequestTimeout(tt.timeout, tt.routes))
+			router.GET("/cache", controller.GetCache)
+
+			req, _ := http.NewRequest("GET", "/cache?key=k", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusGatewayTimeout {
+				return
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if response["error"] != "request timeout" || response["route"] != "GET /cache" {
+				t.Errorf("Unexpected timeout body: %v", response)
+			}
+		})
+	}
+}
+
+func TestRequestTimeout_Batch(t *testing.T) {
+	controller := NewCacheController(&MockRedisConn{
+		batchWrite: func(ctx context.Context, entries []redislib.KeyValue) ([]redislib.BatchResult, error) {
+			<-ctx.Done()
+			results := make([]redislib.BatchResult, len(entries))
+			for i, entry := range entries {
+				results[i] = redislib.BatchResult{Key: entry.Key, Err: fmt.Errorf("%w: %w", redislib.ErrWriteFailed, ctx.Err())}
+			}
+			return results, nil
+		},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestTimeout(20*time.Millisecond, nil))
+	router.POST("/cache/batch", controller.UpdateCacheBatch)
+
+	body := `{"items":[{"key":"a","value":"1"},{"key":"b","value":"2"}]}`
+	req, _ := http.NewRequest("POST", "/cache/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected status 504, got %d: %s", w.Code, w.Body.String())
+	}
+}
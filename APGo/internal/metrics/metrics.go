@@ -0,0 +1,105 @@
+// Package metrics 以 Prometheus 格式提供 Redis 操作、連線池與 HTTP 請求的指標
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 所有指標名稱的前綴
+const namespace = "apgo"
+
+// unmatchedRoute 沒有對應路由的請求（404）使用的 route 標籤，避免任意路徑造成標籤數量暴增
+const unmatchedRoute = "unmatched"
+
+// Metrics 指標的註冊表與收集器
+type Metrics struct {
+	registry *prometheus.Registry
+
+	operations *prometheus.CounterVec
+	latency    *prometheus.HistogramVec
+	errors     *prometheus.CounterVec
+	pools      *poolCollector
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+}
+
+// NewMetrics 建立指標註冊表（包含 Go runtime 與 process 指標）
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "redis",
+			Name:      "operations_total",
+			Help:      "Redis operations by mode, endpoint, operation and result (ok, not_found, error).",
+		}, []string{"mode", "endpoint", "operation", "result"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "redis",
+			Name:      "operation_duration_seconds",
+			Help:      "Redis operation latency by mode, endpoint and operation.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14), // 0.5ms ~ 4s
+		}, []string{"mode", "endpoint", "operation"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "redis",
+			Name:      "operation_errors_total",
+			Help:      "Failed Redis operations by mode, endpoint, operation and error class.",
+		}, []string{"mode", "endpoint", "operation", "class"}),
+		pools: newPoolCollector(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.operations, m.latency, m.errors, m.pools,
+		m.requests, m.requestDuration,
+	)
+	return m
+}
+
+// Registry 取得指標註冊表，供測試或註冊其他指標使用
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler 以 Prometheus 文字格式輸出所有指標的 handler
+func (m *Metrics) Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// Middleware 記錄每個 HTTP 請求的數量與延遲
+// route 使用註冊路由時的路徑（例如 /cache），而不是實際請求的 URL
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.requests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		m.requestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewMetrics()
+	router := gin.New()
+	router.Use(m.Middleware())
+	router.GET("/cache", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/cache", func(c *gin.Context) { c.Status(http.StatusBadRequest) })
+
+	requests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/cache?key=a"},
+		{http.MethodGet, "/cache?key=b"},
+		{http.MethodPost, "/cache"},
+		{http.MethodGet, "/does-not-exist"},
+	}
+	for _, r := range requests {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(r.method, r.path, nil))
+	}
+
+	assertMetrics(t, scrape(t, m),
+		`apgo_http_requests_total{method="GET",route="/cache",status="200"} 2`,
+		`apgo_http_requests_total{method="POST",route="/cache",status="400"} 1`,
+		`apgo_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`apgo_http_request_duration_seconds_count{method="GET",route="/cache"} 2`,
+	)
+}
+
+func TestHandler_RuntimeMetrics(t *testing.T) {
+	assertMetrics(t, scrape(t, NewMetrics()), "# TYPE go_goroutines gauge", "# TYPE process_cpu_seconds_total counter")
+}
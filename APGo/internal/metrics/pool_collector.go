@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolSource 一個提供連線池統計的連線
+type poolSource struct {
+	mode     string
+	provider redislib.PoolStatsProvider
+}
+
+// poolKey 連線池指標的標籤
+type poolKey struct {
+	mode     string
+	endpoint string
+}
+
+// poolCollector 在每次輸出指標時讀取各連線的連線池統計
+type poolCollector struct {
+	mu      sync.Mutex
+	sources map[*InstrumentedConn]poolSource
+
+	hits     *prometheus.Desc
+	misses   *prometheus.Desc
+	timeouts *prometheus.Desc
+	stale    *prometheus.Desc
+	total    *prometheus.Desc
+	idle     *prometheus.Desc
+}
+
+// newPoolCollector 建立連線池指標收集器
+func newPoolCollector() *poolCollector {
+	labels := []string{"mode", "endpoint"}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis_pool", name), help, labels, nil)
+	}
+	return &poolCollector{
+		sources:  make(map[*InstrumentedConn]poolSource),
+		hits:     desc("hits_total", "Times a free connection was found in the pool."),
+		misses:   desc("misses_total", "Times a free connection was not found in the pool."),
+		timeouts: desc("timeouts_total", "Times waiting for a pool connection timed out."),
+		stale:    desc("stale_connections_total", "Stale connections removed from the pool."),
+		total:    desc("connections", "Connections currently in the pool."),
+		idle:     desc("idle_connections", "Idle connections currently in the pool."),
+	}
+}
+
+// add 開始收集 conn 的連線池統計
+func (p *poolCollector) add(conn *InstrumentedConn, source poolSource) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sources[conn] = source
+}
+
+// remove 停止收集 conn 的連線池統計（連線關閉時呼叫）
+func (p *poolCollector) remove(conn *InstrumentedConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.sources, conn)
+}
+
+// Describe 實作 prometheus.Collector
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{p.hits, p.misses, p.timeouts, p.stale, p.total, p.idle} {
+		ch <- desc
+	}
+}
+
+// Collect 實作 prometheus.Collector
+// 相同模式與端點的統計會加總，避免多個連線（例如重新載入設定時新舊連線並存）輸出重複的指標
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	p.mu.Lock()
+	sources := make([]poolSource, 0, len(p.sources))
+	for _, source := range p.sources {
+		sources = append(sources, source)
+	}
+	p.mu.Unlock()
+
+	totals := make(map[poolKey]redislib.PoolStats)
+	for _, source := range sources {
+		for _, stats := range source.provider.PoolStats() {
+			key := poolKey{mode: source.mode, endpoint: stats.Endpoint}
+			sum := totals[key]
+			sum.Hits += stats.Hits
+			sum.Misses += stats.Misses
+			sum.Timeouts += stats.Timeouts
+			sum.StaleConns += stats.StaleConns
+			sum.TotalConns += stats.TotalConns
+			sum.IdleConns += stats.IdleConns
+			totals[key] = sum
+		}
+	}
+
+	for key, stats := range totals {
+		metric := func(desc *prometheus.Desc, valueType prometheus.ValueType, value uint64) {
+			ch <- prometheus.MustNewConstMetric(desc, valueType, float64(value), key.mode, key.endpoint)
+		}
+		metric(p.hits, prometheus.CounterValue, stats.Hits)
+		metric(p.misses, prometheus.CounterValue, stats.Misses)
+		metric(p.timeouts, prometheus.CounterValue, stats.Timeouts)
+		metric(p.stale, prometheus.CounterValue, stats.StaleConns)
+		metric(p.total, prometheus.GaugeValue, stats.TotalConns)
+		metric(p.idle, prometheus.GaugeValue, stats.IdleConns)
+	}
+}
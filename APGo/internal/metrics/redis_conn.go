@@ -0,0 +1,290 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// 操作結果（result 標籤）
+const (
+	resultOK       = "ok"
+	resultNotFound = "not_found"
+	resultError    = "error"
+)
+
+// errNotReady 就緒檢查未通過
+var errNotReady = errors.New("not ready")
+
+// 錯誤分類（class 標籤）
+const (
+	errorClassTimeout    = "timeout"
+	errorClassCanceled   = "canceled"
+	errorClassConnection = "connection"
+	errorClassInvalid    = "invalid_argument"
+	errorClassRedis      = "redis" // Redis 回傳的錯誤回應，例如 WRONGTYPE、READONLY
+	errorClassOther      = "other"
+)
+
+// InstrumentedConn 記錄每個操作的次數、延遲與錯誤分類的 IRedisConn 裝飾器
+// 可以包裝任何 IRedisConn；實作 redislib.PoolStatsProvider 的連線也會輸出連線池統計
+//
+// endpoint 標籤：讀取類操作使用 GetSlaveEndpoint（ReadWithOptionsAsync 使用實際提供資料的節點），
+// 其他操作使用 GetMasterEndpoint
+type InstrumentedConn struct {
+	conn    redislib.IRedisConn
+	mode    string
+	metrics *Metrics
+}
+
+// Instrument 以 mode 標籤包裝 conn，關閉返回的連線時會一併停止收集連線池統計
+// conn 為其他裝飾器時，連線池統計取自最內層的連線
+func (m *Metrics) Instrument(conn redislib.IRedisConn, mode string) *InstrumentedConn {
+	ic := &InstrumentedConn{conn: conn, mode: mode, metrics: m}
+	if provider, ok := redislib.Unwrap(conn).(redislib.PoolStatsProvider); ok {
+		m.pools.add(ic, poolSource{mode: mode, provider: provider})
+	}
+	return ic
+}
+
+// Unwrap 取得被包裝的連線
+func (c *InstrumentedConn) Unwrap() redislib.IRedisConn {
+	return c.conn
+}
+
+// Borrow 借出被包裝的連線給 fn，以 operation 標籤記錄 fn 的結果與延遲
+func (c *InstrumentedConn) Borrow(ctx context.Context, operation string, fn func(ctx context.Context, conn redislib.IRedisConn) error) error {
+	start := time.Now()
+	err := fn(ctx, c.conn)
+	c.observe(ctx, operation, c.writeEndpoint(), start, err)
+	return err
+}
+
+// observe 記錄一次操作的結果與延遲
+func (c *InstrumentedConn) observe(ctx context.Context, operation, endpoint string, start time.Time, err error) {
+	m := c.metrics
+	m.latency.WithLabelValues(c.mode, endpoint, operation).Observe(time.Since(start).Seconds())
+
+	result := resultOK
+	switch {
+	case err == nil:
+	case errors.Is(err, redislib.ErrKeyNotFound) || errors.Is(err, goredis.Nil):
+		result = resultNotFound
+	default:
+		result = resultError
+		m.errors.WithLabelValues(c.mode, endpoint, operation, classifyError(ctx, err)).Inc()
+	}
+	m.operations.WithLabelValues(c.mode, endpoint, operation, result).Inc()
+}
+
+// endpoint 單一 Key 操作的 endpoint 標籤：連線回報的實際處理節點（例如讀取選到的 Replica 或 Raft Leader），
+// 沒有回報時 Cluster 為負責該 Key 的 Master，其他模式為 fallback 的端點
+func (c *InstrumentedConn) endpoint(ctx context.Context, served *redislib.ServedBy, key string, fallback func() string) string {
+	return redislib.ServedNode(ctx, c.conn, served, key, fallback)
+}
+
+// readEndpoint 讀取類操作沒有回報節點時的 endpoint 標籤
+func (c *InstrumentedConn) readEndpoint() string {
+	return c.conn.GetSlaveEndpoint()
+}
+
+// writeEndpoint 寫入與其他操作沒有回報節點時的 endpoint 標籤
+func (c *InstrumentedConn) writeEndpoint() string {
+	return c.conn.GetMasterEndpoint()
+}
+
+// classifyError 將錯誤分類為 class 標籤
+// 除了 err 本身也檢查 ctx，因為部分連線實作包裝錯誤時不會保留原始錯誤
+func classifyError(ctx context.Context, err error) string {
+	var netErr net.Error
+	var redisErr goredis.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return errorClassTimeout
+	case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+		return errorClassCanceled
+	case errors.Is(err, redislib.ErrInvalidTTL),
+		errors.Is(err, redislib.ErrInvalidReadPreference),
+		errors.Is(err, redislib.ErrInvalidConsistencyToken),
+		errors.Is(err, redislib.ErrInvalidFillOptions):
+		return errorClassInvalid
+	case errors.As(err, &netErr) && netErr.Timeout(), errors.Is(err, goredis.ErrPoolTimeout):
+		return errorClassTimeout
+	case errors.As(err, &netErr),
+		errors.Is(err, io.EOF),
+		errors.Is(err, goredis.ErrClosed),
+		errors.Is(err, redislib.ErrConnectionFailed):
+		return errorClassConnection
+	case errors.As(err, &redisErr):
+		return errorClassRedis
+	default:
+		return errorClassOther
+	}
+}
+
+// ReadAsync 從 Redis 讀取資料
+func (c *InstrumentedConn) ReadAsync(ctx context.Context, key string) (string, error) {
+	ctx, served := redislib.WithServedBy(ctx)
+	start := time.Now()
+	value, err := c.conn.ReadAsync(ctx, key)
+	c.observe(ctx, "read", c.endpoint(ctx, served, key, c.readEndpoint), start, err)
+	return value, err
+}
+
+// ReadWithOptionsAsync 依讀取選項讀取資料，endpoint 標籤為實際提供資料的節點
+func (c *InstrumentedConn) ReadWithOptionsAsync(ctx context.Context, key string, opts redislib.ReadOptions) (redislib.ReadResult, error) {
+	ctx, served := redislib.WithServedBy(ctx)
+	start := time.Now()
+	result, err := c.conn.ReadWithOptionsAsync(ctx, key, opts)
+	endpoint := result.Node
+	if endpoint == "" {
+		endpoint = c.endpoint(ctx, served, key, c.readEndpoint)
+	}
+	c.observe(ctx, "read_with_options", endpoint, start, err)
+	return result, err
+}
+
+// WriteAsync 寫入資料到 Redis
+func (c *InstrumentedConn) WriteAsync(ctx context.Context, key string, value string) (bool, error) {
+	ctx, served := redislib.WithServedBy(ctx)
+	start := time.Now()
+	ok, err := c.conn.WriteAsync(ctx, key, value)
+	c.observe(ctx, "write", c.endpoint(ctx, served, key, c.writeEndpoint), start, err)
+	return ok, err
+}
+
+// WriteWithTTLAsync 寫入資料並設定過期時間
+func (c *InstrumentedConn) WriteWithTTLAsync(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	ctx, served := redislib.WithServedBy(ctx)
+	start := time.Now()
+	ok, err := c.conn.WriteWithTTLAsync(ctx, key, value, ttl)
+	c.observe(ctx, "write_with_ttl", c.endpoint(ctx, served, key, c.writeEndpoint), start, err)
+	return ok, err
+}
+
+// ConsistencyTokenAsync 取得目前 Master 的一致性 Token
+func (c *InstrumentedConn) ConsistencyTokenAsync(ctx context.Context) (redislib.ConsistencyToken, error) {
+	start := time.Now()
+	token, err := c.conn.ConsistencyTokenAsync(ctx)
+	c.observe(ctx, "consistency_token", c.writeEndpoint(), start, err)
+	return token, err
+}
+
+// GetTTLAsync 取得 Key 的剩餘存活時間
+func (c *InstrumentedConn) GetTTLAsync(ctx context.Context, key string) (time.Duration, error) {
+	ctx, served := redislib.WithServedBy(ctx)
+	start := time.Now()
+	ttl, err := c.conn.GetTTLAsync(ctx, key)
+	c.observe(ctx, "get_ttl", c.endpoint(ctx, served, key, c.readEndpoint), start, err)
+	return ttl, err
+}
+
+// ExpireAsync 變更 Key 的過期時間
+func (c *InstrumentedConn) ExpireAsync(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ctx, served := redislib.WithServedBy(ctx)
+	start := time.Now()
+	ok, err := c.conn.ExpireAsync(ctx, key, ttl)
+	c.observe(ctx, "expire", c.endpoint(ctx, served, key, c.writeEndpoint), start, err)
+	return ok, err
+}
+
+// PersistAsync 移除 Key 的過期時間
+func (c *InstrumentedConn) PersistAsync(ctx context.Context, key string) (bool, error) {
+	ctx, served := redislib.WithServedBy(ctx)
+	start := time.Now()
+	ok, err := c.conn.PersistAsync(ctx, key)
+	c.observe(ctx, "persist", c.endpoint(ctx, served, key, c.writeEndpoint), start, err)
+	return ok, err
+}
+
+// DeleteAsync 刪除單一 Key
+func (c *InstrumentedConn) DeleteAsync(ctx context.Context, key string) (bool, error) {
+	ctx, served := redislib.WithServedBy(ctx)
+	start := time.Now()
+	ok, err := c.conn.DeleteAsync(ctx, key)
+	c.observe(ctx, "delete", c.endpoint(ctx, served, key, c.writeEndpoint), start, err)
+	return ok, err
+}
+
+// DeleteManyAsync 刪除多個 Key
+func (c *InstrumentedConn) DeleteManyAsync(ctx context.Context, keys []string) (int64, error) {
+	start := time.Now()
+	n, err := c.conn.DeleteManyAsync(ctx, keys)
+	c.observe(ctx, "delete_many", c.writeEndpoint(), start, err)
+	return n, err
+}
+
+// ExistsAsync 檢查 Key 是否存在
+func (c *InstrumentedConn) ExistsAsync(ctx context.Context, key string) (bool, error) {
+	ctx, served := redislib.WithServedBy(ctx)
+	start := time.Now()
+	ok, err := c.conn.ExistsAsync(ctx, key)
+	c.observe(ctx, "exists", c.endpoint(ctx, served, key, c.readEndpoint), start, err)
+	return ok, err
+}
+
+// BatchReadAsync 批次讀取多個 Key（只記錄整個批次的結果）
+func (c *InstrumentedConn) BatchReadAsync(ctx context.Context, keys []string) ([]redislib.BatchResult, error) {
+	start := time.Now()
+	results, err := c.conn.BatchReadAsync(ctx, keys)
+	c.observe(ctx, "batch_read", c.readEndpoint(), start, err)
+	return results, err
+}
+
+// BatchWriteAsync 批次寫入多筆資料（只記錄整個批次的結果）
+func (c *InstrumentedConn) BatchWriteAsync(ctx context.Context, entries []redislib.KeyValue) ([]redislib.BatchResult, error) {
+	start := time.Now()
+	results, err := c.conn.BatchWriteAsync(ctx, entries)
+	c.observe(ctx, "batch_write", c.writeEndpoint(), start, err)
+	return results, err
+}
+
+// GetRandomCache 隨機取得快取資料
+func (c *InstrumentedConn) GetRandomCache(ctx context.Context, key string) (string, error) {
+	ctx, served := redislib.WithServedBy(ctx)
+	start := time.Now()
+	value, err := c.conn.GetRandomCache(ctx, key)
+	c.observe(ctx, "get_random_cache", c.endpoint(ctx, served, key, c.readEndpoint), start, err)
+	return value, err
+}
+
+// Topology 取得部署拓樸
+func (c *InstrumentedConn) Topology(ctx context.Context) (redislib.Topology, error) {
+	start := time.Now()
+	topology, err := c.conn.Topology(ctx)
+	c.observe(ctx, "topology", c.writeEndpoint(), start, err)
+	return topology, err
+}
+
+// Readiness 執行就緒檢查，未就緒時 result 標籤為 error（class 為 other）
+func (c *InstrumentedConn) Readiness(ctx context.Context) redislib.Readiness {
+	start := time.Now()
+	readiness := c.conn.Readiness(ctx)
+	var err error
+	if !readiness.Ready {
+		err = errNotReady
+	}
+	c.observe(ctx, "readiness", c.writeEndpoint(), start, err)
+	return readiness
+}
+
+// GetMasterEndpoint 取得 Master 端點資訊
+func (c *InstrumentedConn) GetMasterEndpoint() string {
+	return c.conn.GetMasterEndpoint()
+}
+
+// GetSlaveEndpoint 取得 Slave 端點資訊
+func (c *InstrumentedConn) GetSlaveEndpoint() string {
+	return c.conn.GetSlaveEndpoint()
+}
+
+// Close 停止收集連線池統計並關閉被包裝的連線
+func (c *InstrumentedConn) Close() error {
+	c.metrics.pools.remove(c)
+	return c.conn.Close()
+}
@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AmandaChou/RedisLab/APGo/internal/redis"
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// scrape 取得 /metrics 的輸出
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/metrics", m.Handler())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 from /metrics, got %d", w.Code)
+	}
+	return w.Body.String()
+}
+
+// assertMetrics 檢查輸出包含所有指定的指標行
+func assertMetrics(t *testing.T, body string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected metric line %q in output", line)
+		}
+	}
+}
+
+// poolConn 在 RedisInMemory 上加入固定的連線池統計
+type poolConn struct {
+	*redis.RedisInMemory
+	stats []redislib.PoolStats
+}
+
+func (p *poolConn) PoolStats() []redislib.PoolStats {
+	return p.stats
+}
+
+func newInMemory(t *testing.T) *redis.RedisInMemory {
+	t.Helper()
+	conn, err := redis.NewRedisInMemory("memory-master", []string{"memory-replica"}, 0)
+	if err != nil {
+		t.Fatalf("Failed to create in-memory connection: %v", err)
+	}
+	return conn
+}
+
+func TestInstrumentedConn(t *testing.T) {
+	m := NewMetrics()
+	conn := m.Instrument(newInMemory(t), "RedisInMemory")
+	defer conn.Close()
+	ctx := context.Background()
+
+	if _, err := conn.WriteAsync(ctx, "k", "v"); err != nil {
+		t.Fatalf("WriteAsync failed: %v", err)
+	}
+	if value, err := conn.ReadAsync(ctx, "k"); err != nil || value != "v" {
+		t.Fatalf("ReadAsync() = %q, %v", value, err)
+	}
+	if _, err := conn.ReadAsync(ctx, "missing"); !errors.Is(err, redislib.ErrKeyNotFound) {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := conn.ReadAsync(canceled, "k"); err == nil {
+		t.Fatalf("Expected error for canceled context")
+	}
+
+	body := scrape(t, m)
+	assertMetrics(t, body,
+		`apgo_redis_operations_total{endpoint="memory-master",mode="RedisInMemory",operation="write",result="ok"} 1`,
+		`apgo_redis_operations_total{endpoint="memory-replica",mode="RedisInMemory",operation="read",result="ok"} 1`,
+		`apgo_redis_operations_total{endpoint="memory-replica",mode="RedisInMemory",operation="read",result="not_found"} 1`,
+		`apgo_redis_operations_total{endpoint="memory-replica",mode="RedisInMemory",operation="read",result="error"} 1`,
+		`apgo_redis_operation_errors_total{class="canceled",endpoint="memory-replica",mode="RedisInMemory",operation="read"} 1`,
+		`apgo_redis_operation_duration_seconds_count{endpoint="memory-replica",mode="RedisInMemory",operation="read"} 3`,
+	)
+	if strings.Contains(body, "apgo_redis_pool_") {
+		t.Errorf("Expected no pool metrics for a connection without PoolStats")
+	}
+}
+
+// routedConn 在 RedisInMemory 上模擬依 Key 路由的連線（例如 Cluster）
+type routedConn struct {
+	*redis.RedisInMemory
+}
+
+func (routedConn) NodeForKey(ctx context.Context, key string) string {
+	return "node-" + key
+}
+
+func TestInstrumentedConn_ServedEndpoint(t *testing.T) {
+	m := NewMetrics()
+	conn := m.Instrument(routedConn{newInMemory(t)}, "RedisCluster")
+	defer conn.Close()
+	ctx := context.Background()
+
+	if _, err := conn.WriteAsync(ctx, "a", "v"); err != nil {
+		t.Fatalf("WriteAsync failed: %v", err)
+	}
+	if _, err := conn.DeleteAsync(ctx, "b"); err != nil {
+		t.Fatalf("DeleteAsync failed: %v", err)
+	}
+	if _, err := conn.ReadAsync(ctx, "a"); err != nil {
+		t.Fatalf("ReadAsync failed: %v", err)
+	}
+
+	// 沒有回報節點的操作使用負責該 Key 的節點，讀取使用實際選到的節點
+	assertMetrics(t, scrape(t, m),
+		`apgo_redis_operations_total{endpoint="node-a",mode="RedisCluster",operation="write",result="ok"} 1`,
+		`apgo_redis_operations_total{endpoint="node-b",mode="RedisCluster",operation="delete",result="ok"} 1`,
+		`apgo_redis_operations_total{endpoint="memory-replica",mode="RedisCluster",operation="read",result="ok"} 1`,
+	)
+}
+
+func TestInstrumentedConn_PoolStats(t *testing.T) {
+	m := NewMetrics()
+	stats := []redislib.PoolStats{{Endpoint: "10.0.0.1:6379", Hits: 5, Misses: 2, Timeouts: 1, TotalConns: 4, IdleConns: 3}}
+	first := m.Instrument(&poolConn{RedisInMemory: newInMemory(t), stats: stats}, "RedisMasterSlaves")
+	second := m.Instrument(&poolConn{RedisInMemory: newInMemory(t), stats: stats}, "RedisMasterSlaves")
+
+	// 相同模式與端點的統計會加總
+	assertMetrics(t, scrape(t, m),
+		`apgo_redis_pool_hits_total{endpoint="10.0.0.1:6379",mode="RedisMasterSlaves"} 10`,
+		`apgo_redis_pool_misses_total{endpoint="10.0.0.1:6379",mode="RedisMasterSlaves"} 4`,
+		`apgo_redis_pool_timeouts_total{endpoint="10.0.0.1:6379",mode="RedisMasterSlaves"} 2`,
+		`apgo_redis_pool_connections{endpoint="10.0.0.1:6379",mode="RedisMasterSlaves"} 8`,
+		`apgo_redis_pool_idle_connections{endpoint="10.0.0.1:6379",mode="RedisMasterSlaves"} 6`,
+	)
+
+	// 關閉後不再輸出該連線的統計
+	first.Close()
+	assertMetrics(t, scrape(t, m), `apgo_redis_pool_hits_total{endpoint="10.0.0.1:6379",mode="RedisMasterSlaves"} 5`)
+	second.Close()
+	if body := scrape(t, m); strings.Contains(body, "apgo_redis_pool_hits_total{") {
+		t.Errorf("Expected no pool metrics after all connections are closed")
+	}
+}
+
+func TestInstrumentedConn_Unwrap(t *testing.T) {
+	inner := newInMemory(t)
+	conn := NewMetrics().Instrument(inner, "RedisInMemory")
+	defer conn.Close()
+
+	if got := redislib.Unwrap(conn); got != inner {
+		t.Errorf("Unwrap() = %T, want the wrapped connection", got)
+	}
+	if got := redislib.Unwrap(inner); got != inner {
+		t.Errorf("Unwrap() of an unwrapped connection should return it unchanged")
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want string
+	}{
+		{"deadline", context.Background(), fmt.Errorf("%w: %w", redislib.ErrReadFailed, context.DeadlineExceeded), errorClassTimeout},
+		{"canceled context with wrapped error", canceled, fmt.Errorf("%w: %v", redislib.ErrReadFailed, context.Canceled), errorClassCanceled},
+		{"invalid ttl", context.Background(), redislib.ErrInvalidTTL, errorClassInvalid},
+		{"net timeout", context.Background(), &net.OpError{Op: "read", Err: timeoutError{}}, errorClassTimeout},
+		{"pool timeout", context.Background(), goredis.ErrPoolTimeout, errorClassTimeout},
+		{"connection refused", context.Background(), &net.OpError{Op: "dial", Err: errors.New("connection refused")}, errorClassConnection},
+		{"eof", context.Background(), io.EOF, errorClassConnection},
+		{"connection failed", context.Background(), fmt.Errorf("%w: no reachable node", redislib.ErrConnectionFailed), errorClassConnection},
+		{"redis error reply", context.Background(), goredisError("READONLY You can't write against a read only replica."), errorClassRedis},
+		{"other", context.Background(), errors.New("boom"), errorClassOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("classifyError(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// timeoutError 模擬 net 逾時錯誤
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// goredisError 模擬 Redis 回傳的錯誤回應
+type goredisError string
+
+func (e goredisError) Error() string { return string(e) }
+func (goredisError) RedisError()     {}
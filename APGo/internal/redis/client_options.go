@@ -0,0 +1,88 @@
+package redis
+
+import (
+	"crypto/tls"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// ClientOptions 連線池、逾時、重試與驗證設定，套用到各模式建立的 go-redis 連線
+// 零值欄位使用 go-redis 的預設值
+type ClientOptions struct {
+	Username string // ACL 使用者名稱，空字串表示 default 使用者
+	Password string
+	// TLSConfig 不為 nil 時以 TLS 連線
+	TLSConfig *tls.Config
+
+	PoolSize     int // 每個節點的連線池大小，0 表示 10 * GOMAXPROCS
+	MinIdleConns int // 每個節點保留的最少閒置連線
+
+	DialTimeout  time.Duration // 0 表示 5s
+	ReadTimeout  time.Duration // 0 表示 3s，-1 表示不限制
+	WriteTimeout time.Duration // 0 表示與 ReadTimeout 相同，-1 表示不限制
+
+	MaxRetries      int           // 0 表示 3 次，-1 表示不重試
+	MinRetryBackoff time.Duration // 0 表示 8ms，-1 表示不等待
+	MaxRetryBackoff time.Duration // 0 表示 512ms，-1 表示不等待
+}
+
+// options 建立單一節點的 go-redis 設定
+// 一律啟用 ContextTimeoutEnabled，讓請求的逾時套用到 Redis 操作
+func (o ClientOptions) options(addr string) *goredis.Options {
+	return &goredis.Options{
+		Addr:                  addr,
+		Username:              o.Username,
+		Password:              o.Password,
+		TLSConfig:             o.TLSConfig,
+		PoolSize:              o.PoolSize,
+		MinIdleConns:          o.MinIdleConns,
+		DialTimeout:           o.DialTimeout,
+		ReadTimeout:           o.ReadTimeout,
+		WriteTimeout:          o.WriteTimeout,
+		MaxRetries:            o.MaxRetries,
+		MinRetryBackoff:       o.MinRetryBackoff,
+		MaxRetryBackoff:       o.MaxRetryBackoff,
+		ContextTimeoutEnabled: true,
+	}
+}
+
+// clusterOptions 建立 Cluster 模式的 go-redis 設定
+// ContextTimeoutEnabled 讓請求的逾時也套用在 MOVED / ASK 重新導向上
+func (o ClientOptions) clusterOptions(addrs []string) *goredis.ClusterOptions {
+	return &goredis.ClusterOptions{
+		Addrs:                 addrs,
+		Username:              o.Username,
+		Password:              o.Password,
+		TLSConfig:             o.TLSConfig,
+		PoolSize:              o.PoolSize,
+		MinIdleConns:          o.MinIdleConns,
+		DialTimeout:           o.DialTimeout,
+		ReadTimeout:           o.ReadTimeout,
+		WriteTimeout:          o.WriteTimeout,
+		MaxRetries:            o.MaxRetries,
+		MinRetryBackoff:       o.MinRetryBackoff,
+		MaxRetryBackoff:       o.MaxRetryBackoff,
+		ContextTimeoutEnabled: true,
+	}
+}
+
+// failoverOptions 建立 Sentinel 模式的 go-redis 設定（驗證資訊只套用在 Master / Replica）
+func (o ClientOptions) failoverOptions(masterName string, sentinels []string) *goredis.FailoverOptions {
+	return &goredis.FailoverOptions{
+		MasterName:            masterName,
+		SentinelAddrs:         sentinels,
+		Username:              o.Username,
+		Password:              o.Password,
+		TLSConfig:             o.TLSConfig,
+		PoolSize:              o.PoolSize,
+		MinIdleConns:          o.MinIdleConns,
+		DialTimeout:           o.DialTimeout,
+		ReadTimeout:           o.ReadTimeout,
+		WriteTimeout:          o.WriteTimeout,
+		MaxRetries:            o.MaxRetries,
+		MinRetryBackoff:       o.MinRetryBackoff,
+		MaxRetryBackoff:       o.MaxRetryBackoff,
+		ContextTimeoutEnabled: true,
+	}
+}
@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClientOptions(t *testing.T) {
+	opts := ClientOptions{
+		Username:        "app",
+		Password:        "secret",
+		PoolSize:        20,
+		MinIdleConns:    4,
+		DialTimeout:     time.Second,
+		ReadTimeout:     -1,
+		WriteTimeout:    2 * time.Second,
+		MaxRetries:      -1,
+		MinRetryBackoff: time.Millisecond,
+		MaxRetryBackoff: time.Second,
+	}
+
+	single := opts.options("127.0.0.1:6379")
+	if single.Addr != "127.0.0.1:6379" || single.Username != "app" || single.Password != "secret" ||
+		single.PoolSize != 20 || single.MinIdleConns != 4 || single.DialTimeout != time.Second ||
+		single.ReadTimeout != -1 || single.WriteTimeout != 2*time.Second || single.MaxRetries != -1 ||
+		single.MinRetryBackoff != time.Millisecond || single.MaxRetryBackoff != time.Second || !single.ContextTimeoutEnabled {
+		t.Errorf("Unexpected options: %+v", single)
+	}
+
+	cluster := opts.clusterOptions([]string{"a", "b"})
+	if len(cluster.Addrs) != 2 || cluster.PoolSize != 20 || cluster.Password != "secret" || !cluster.ContextTimeoutEnabled {
+		t.Errorf("Unexpected cluster options: %+v", cluster)
+	}
+
+	failover := opts.failoverOptions("mymaster", []string{"s1"})
+	if failover.MasterName != "mymaster" || failover.Username != "app" || failover.MaxRetries != -1 || !failover.ContextTimeoutEnabled {
+		t.Errorf("Unexpected failover options: %+v", failover)
+	}
+
+	// 零值使用 go-redis 的預設值
+	if zero := (ClientOptions{}).options("x"); zero.PoolSize != 0 || zero.ReadTimeout != 0 || !zero.ContextTimeoutEnabled {
+		t.Errorf("Expected zero options to leave go-redis defaults, got %+v", zero)
+	}
+}
+
+func TestRedisSentinel_SentinelClientOptions(t *testing.T) {
+	r := &RedisSentinel{
+		clientOpts:       ClientOptions{Username: "app", Password: "master-secret", PoolSize: 5},
+		sentinelUsername: "sentinel-user",
+		sentinelPassword: "sentinel-secret",
+	}
+
+	// Sentinel 使用自己的帳號密碼，其餘設定與 Master 相同
+	opts := r.sentinelClientOptions("127.0.0.1:26379")
+	if opts.Username != "sentinel-user" || opts.Password != "sentinel-secret" || opts.PoolSize != 5 {
+		t.Errorf("Unexpected sentinel options: %+v", opts)
+	}
+}
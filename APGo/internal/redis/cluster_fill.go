@@ -0,0 +1,141 @@
+package redis
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+// fillProgressInterval FillCluster 回報進度的最短間隔
+const fillProgressInterval = 250 * time.Millisecond
+
+// fillChunk 同一個 Master 上一次 Pipeline 寫入的 Key（落點中的位置）
+type fillChunk struct {
+	node    string
+	indexes []int
+}
+
+// planFillChunks 依 slot 順序將 Key 歸到負責的 Master，再切成最多 batchSize 筆的 chunk
+// 各節點的 chunk 交錯排列，讓平行寫入時各節點的負載平均
+func planFillChunks(keys []redislib.FillKey, batchSize int) []fillChunk {
+	order := make([]int, 0, len(keys))
+	for i, key := range keys {
+		if key.Node != "" {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return keys[order[a]].Slot < keys[order[b]].Slot })
+
+	var nodes []string
+	byNode := make(map[string][]int)
+	for _, i := range order {
+		node := keys[i].Node
+		if _, ok := byNode[node]; !ok {
+			nodes = append(nodes, node)
+		}
+		byNode[node] = append(byNode[node], i)
+	}
+
+	var chunks []fillChunk
+	for remaining := true; remaining; {
+		remaining = false
+		for _, node := range nodes {
+			indexes := byNode[node]
+			if len(indexes) == 0 {
+				continue
+			}
+			n := min(batchSize, len(indexes))
+			chunks = append(chunks, fillChunk{node: node, indexes: indexes[:n]})
+			byNode[node] = indexes[n:]
+			remaining = remaining || len(indexes) > n
+		}
+	}
+	return chunks
+}
+
+// fillRun 平行寫入 chunk 並統計進度
+type fillRun struct {
+	total    int
+	start    time.Time
+	now      func() time.Time
+	progress func(redislib.FillProgress)
+
+	mu           sync.Mutex
+	written      int
+	failed       int
+	skipped      int
+	lastProgress time.Time
+}
+
+// newFillRun 建立寫入統計，progress 為 nil 時不回報進度
+func newFillRun(total int, progress func(redislib.FillProgress)) *fillRun {
+	now := time.Now
+	return &fillRun{total: total, start: now(), now: now, progress: progress}
+}
+
+// add 累計一個 chunk 的結果，距離上次回報超過 fillProgressInterval 時回報進度
+func (f *fillRun) add(written, failed int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.written += written
+	f.failed += failed
+	if now := f.now(); f.progress != nil && now.Sub(f.lastProgress) >= fillProgressInterval {
+		f.lastProgress = now
+		f.progress(redislib.NewFillProgress(f.total, f.written, f.failed, f.skipped, now.Sub(f.start)))
+	}
+}
+
+// finish 回報最終進度並返回最終統計
+func (f *fillRun) finish() redislib.FillProgress {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	progress := redislib.NewFillProgress(f.total, f.written, f.failed, f.skipped, f.now().Sub(f.start))
+	if f.progress != nil {
+		f.progress(progress)
+	}
+	return progress
+}
+
+// run 以最多 concurrency 個 worker 執行 write，ctx 取消時不再開始新的 chunk
+// write 返回 chunk 中寫入失敗的數量；返回因取消而沒有送出的 chunk，其 Key 計入 skipped
+func (f *fillRun) run(ctx context.Context, chunks []fillChunk, concurrency int, write func(ctx context.Context, chunk fillChunk) int) []fillChunk {
+	jobs := make(chan fillChunk)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(concurrency, len(chunks)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range jobs {
+				failed := write(ctx, chunk)
+				f.add(len(chunk.indexes)-failed, failed)
+			}
+		}()
+	}
+
+	dispatched := 0
+dispatch:
+	for _, chunk := range chunks {
+		select {
+		case jobs <- chunk:
+			dispatched++
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	skipped := chunks[dispatched:]
+	f.mu.Lock()
+	for _, chunk := range skipped {
+		f.skipped += len(chunk.indexes)
+	}
+	f.mu.Unlock()
+	return skipped
+}
@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+func TestPlanFillChunks(t *testing.T) {
+	keys := []redislib.FillKey{
+		{Key: "a", Slot: 300, Node: "n1"},
+		{Key: "b", Slot: 9000, Node: "n2"},
+		{Key: "c", Slot: 100, Node: "n1"},
+		{Key: "d", Slot: 200, Node: "n1"},
+		{Key: "e", Slot: 50, Node: ""},
+		{Key: "f", Slot: 9100, Node: "n2"},
+	}
+
+	chunks := planFillChunks(keys, 2)
+	want := []fillChunk{
+		{node: "n1", indexes: []int{2, 3}},
+		{node: "n2", indexes: []int{1, 5}},
+		{node: "n1", indexes: []int{0}},
+	}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("planFillChunks() = %+v, want %+v", chunks, want)
+	}
+
+	if chunks := planFillChunks(nil, 10); len(chunks) != 0 {
+		t.Errorf("Expected no chunks for no keys, got %+v", chunks)
+	}
+}
+
+func TestFillRun(t *testing.T) {
+	chunks := make([]fillChunk, 20)
+	for i := range chunks {
+		chunks[i] = fillChunk{node: "n1", indexes: []int{i * 2, i*2 + 1}}
+	}
+
+	var mu sync.Mutex
+	var reports []redislib.FillProgress
+	run := newFillRun(40, func(p redislib.FillProgress) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, p)
+	})
+
+	var active, peak atomic.Int32
+	run.run(context.Background(), chunks, 3, func(ctx context.Context, chunk fillChunk) int {
+		n := active.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		active.Add(-1)
+		return chunk.indexes[0] % 4 / 2 // 每兩個 chunk 有一個失敗一筆
+	})
+	final := run.finish()
+
+	if peak.Load() > 3 {
+		t.Errorf("Expected at most 3 concurrent writes, got %d", peak.Load())
+	}
+	if final.Total != 40 || final.Written != 30 || final.Failed != 10 {
+		t.Errorf("Unexpected final progress: %+v", final)
+	}
+	if len(reports) < 2 || reports[len(reports)-1] != final {
+		t.Errorf("Expected intermediate and final progress reports, got %+v", reports)
+	}
+}
+
+func TestFillRun_Cancel(t *testing.T) {
+	chunks := make([]fillChunk, 100)
+	for i := range chunks {
+		chunks[i] = fillChunk{node: "n1", indexes: []int{i}}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := newFillRun(len(chunks), nil)
+	var calls atomic.Int32
+	skipped := run.run(ctx, chunks, 1, func(ctx context.Context, chunk fillChunk) int {
+		if calls.Add(1) == 5 {
+			cancel()
+		}
+		return 0
+	})
+
+	// 取消後最多再送出一個已在途中的 chunk
+	if got := calls.Load(); got < 5 || got > 6 {
+		t.Errorf("Expected writes to stop after cancel, got %d calls", got)
+	}
+	// 沒有送出的 chunk 計入 skipped，與寫入的數量加總為全部
+	final := run.finish()
+	if final.Written != int(calls.Load()) || final.Skipped != len(skipped) || final.Written+final.Skipped != len(chunks) {
+		t.Errorf("Expected %d written and the rest skipped, got %+v (%d chunks skipped)", calls.Load(), final, len(skipped))
+	}
+}
@@ -0,0 +1,149 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// 以下為各模式共用的指令實作，傳入的 client 可以是
+// *goredis.Client、*goredis.ClusterClient 或 Pipeline
+// 包裝錯誤時保留原始錯誤，讓呼叫端可以判斷重定向或連線錯誤
+
+// setWithTTL 寫入資料並設定過期時間（ttl <= 0 表示永不過期）
+func setWithTTL(ctx context.Context, client goredis.Cmdable, key, value string, ttl time.Duration) (bool, error) {
+	if ttl < 0 {
+		ttl = 0
+	}
+	if err := client.Set(ctx, key, value, ttl).Err(); err != nil {
+		return false, fmt.Errorf("%w: %w", redislib.ErrWriteFailed, err)
+	}
+	return true, nil
+}
+
+// getTTL 取得 Key 的剩餘存活時間
+func getTTL(ctx context.Context, client goredis.Cmdable, key string) (time.Duration, error) {
+	ttl, err := client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", redislib.ErrReadFailed, err)
+	}
+	// TTL 指令：-2 表示 Key 不存在，-1 表示沒有過期時間
+	switch ttl {
+	case -2:
+		return 0, redislib.ErrKeyNotFound
+	case -1:
+		return redislib.NoTTL, nil
+	}
+	return ttl, nil
+}
+
+// expire 變更 Key 的過期時間
+func expire(ctx context.Context, client goredis.Cmdable, key string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		return false, fmt.Errorf("%w: ttl must be positive, got %s", redislib.ErrInvalidTTL, ttl)
+	}
+	ok, err := client.Expire(ctx, key, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", redislib.ErrWriteFailed, err)
+	}
+	if !ok {
+		return false, redislib.ErrKeyNotFound
+	}
+	return true, nil
+}
+
+// persist 移除 Key 的過期時間，本來就沒有過期時間時返回 false，Key 不存在時返回 ErrKeyNotFound（與 expire 相同）
+// PERSIST 對這兩種情況都回傳 0，因此在同一個 MULTI 中以 EXISTS 區分
+func persist(ctx context.Context, client goredis.Cmdable, key string) (bool, error) {
+	var exists *goredis.IntCmd
+	var persisted *goredis.BoolCmd
+	_, err := client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		exists = pipe.Exists(ctx, key)
+		persisted = pipe.Persist(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", redislib.ErrWriteFailed, err)
+	}
+	if exists.Val() == 0 {
+		return false, redislib.ErrKeyNotFound
+	}
+	return persisted.Val(), nil
+}
+
+// deleteKeys 刪除多個 Key，返回實際刪除的數量
+// 所有 Key 必須位於同一個節點（Cluster 模式請先依 hash slot 分組）
+func deleteKeys(ctx context.Context, client goredis.Cmdable, keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	n, err := client.Del(ctx, keys...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", redislib.ErrWriteFailed, err)
+	}
+	return n, nil
+}
+
+// exists 檢查 Key 是否存在
+func exists(ctx context.Context, client goredis.Cmdable, key string) (bool, error) {
+	n, err := client.Exists(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", redislib.ErrReadFailed, err)
+	}
+	return n > 0, nil
+}
+
+// pipelineGet 以 Pipeline 批次讀取多個 Key
+// 個別 Key 的錯誤記錄在對應的 BatchResult 中，不會中斷整個批次
+func pipelineGet(ctx context.Context, client goredis.Cmdable, node string, keys []string) []redislib.BatchResult {
+	cmds := make([]*goredis.StringCmd, len(keys))
+	// Pipelined 回傳的是第一個失敗指令的錯誤（包含 redis.Nil），逐一檢查各指令即可
+	_, _ = client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		return nil
+	})
+
+	results := make([]redislib.BatchResult, len(keys))
+	for i, cmd := range cmds {
+		results[i] = redislib.BatchResult{Key: keys[i], Node: node}
+		val, err := cmd.Result()
+		switch {
+		case err == goredis.Nil:
+		case err != nil:
+			results[i].Err = fmt.Errorf("%w: %w", redislib.ErrReadFailed, err)
+		default:
+			results[i].Value = val
+			results[i].Found = true
+		}
+	}
+	return results
+}
+
+// pipelineSet 以 Pipeline 批次寫入多筆資料
+func pipelineSet(ctx context.Context, client goredis.Cmdable, node string, entries []redislib.KeyValue) []redislib.BatchResult {
+	cmds := make([]*goredis.StatusCmd, len(entries))
+	_, _ = client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for i, entry := range entries {
+			ttl := entry.TTL
+			if ttl < 0 {
+				ttl = 0
+			}
+			cmds[i] = pipe.Set(ctx, entry.Key, entry.Value, ttl)
+		}
+		return nil
+	})
+
+	results := make([]redislib.BatchResult, len(entries))
+	for i, cmd := range cmds {
+		results[i] = redislib.BatchResult{Key: entries[i].Key, Node: node}
+		if err := cmd.Err(); err != nil {
+			results[i].Err = fmt.Errorf("%w: %w", redislib.ErrWriteFailed, err)
+		}
+	}
+	return results
+}
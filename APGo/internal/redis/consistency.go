@@ -0,0 +1,83 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// replicationOffset 以 INFO replication 取得節點目前的複寫 offset
+// Master 返回 master_repl_offset，Replica 返回 slave_repl_offset
+func replicationOffset(ctx context.Context, client goredis.Cmdable) (int64, error) {
+	info, err := client.Info(ctx, "replication").Result()
+	if err != nil {
+		return 0, err
+	}
+
+	fields := redislib.ParseInfo(info)
+	field := "slave_repl_offset"
+	if fields["role"] == "master" {
+		field = "master_repl_offset"
+	}
+	offset := redislib.InfoInt(fields, field)
+	if offset < 0 {
+		return 0, fmt.Errorf("%s not found in INFO replication", field)
+	}
+	return offset, nil
+}
+
+// consistencyToken 取得 Master 目前的複寫 offset 作為一致性 Token
+func consistencyToken(ctx context.Context, master goredis.Cmdable) (redislib.ConsistencyToken, error) {
+	offset, err := replicationOffset(ctx, master)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", redislib.ErrReadFailed, err)
+	}
+	return redislib.ConsistencyToken(offset), nil
+}
+
+// caughtUp 判斷節點的複寫 offset 是否已達到 Token
+// 先使用健康檢查記錄的 offset，不足時才即時查詢節點
+func (n *readNode) caughtUp(ctx context.Context, token redislib.ConsistencyToken) bool {
+	if n.offset.Load() >= int64(token) {
+		return true
+	}
+	if n.client == nil {
+		return false
+	}
+
+	offset, err := replicationOffset(ctx, n.client)
+	if err != nil {
+		return false
+	}
+	n.offset.Store(offset)
+	return offset >= int64(token)
+}
+
+// filterCaughtUp 只保留複寫 offset 已達到 Token 的 Replica
+// 找到第一個追上的 Replica 後就不再查詢其他 Replica，
+// Master 一定已追上，若排序中沒有 Master 則加在最後作為備援
+func filterCaughtUp(ctx context.Context, nodes []*readNode, master *readNode, token redislib.ConsistencyToken) []*readNode {
+	if token <= 0 {
+		return nodes
+	}
+
+	result := make([]*readNode, 0, 2)
+	hasMaster, found := false, false
+	for _, node := range nodes {
+		if node == master {
+			hasMaster = true
+			result = append(result, node)
+			continue
+		}
+		if !found && node.caughtUp(ctx, token) {
+			found = true
+			result = append(result, node)
+		}
+	}
+	if !hasMaster {
+		result = append(result, master)
+	}
+	return result
+}
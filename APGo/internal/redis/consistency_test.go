@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+func TestFilterCaughtUp(t *testing.T) {
+	master := newPrimaryReadNode("m", nil)
+	behind := newReadNode("behind", nil, 1)
+	behind.offset.Store(100)
+	ahead := newReadNode("ahead", nil, 1)
+	ahead.offset.Store(500)
+	ahead2 := newReadNode("ahead2", nil, 1)
+	ahead2.offset.Store(600)
+	unknown := newReadNode("unknown", nil, 1)
+
+	tests := []struct {
+		name  string
+		nodes []*readNode
+		token redislib.ConsistencyToken
+		want  []string
+	}{
+		{"no token", []*readNode{behind, ahead, master}, 0, []string{"behind", "ahead", "m"}},
+		{"first caught up replica only", []*readNode{behind, ahead, ahead2, master}, 300, []string{"ahead", "m"}},
+		{"exact offset", []*readNode{ahead, master}, 500, []string{"ahead", "m"}},
+		{"none caught up", []*readNode{behind, unknown, master}, 1000, []string{"m"}},
+		{"master added for replica only", []*readNode{behind, ahead}, 300, []string{"ahead", "m"}},
+		{"master first", []*readNode{master, ahead}, 300, []string{"m", "ahead"}},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := endpoints(filterCaughtUp(ctx, tt.nodes, master, tt.token))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterCaughtUp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedisInMemory_ConsistencyToken(t *testing.T) {
+	rim, clock := newTestInMemory(t, []string{"memory:replica-1"}, time.Second)
+	defer rim.Close()
+
+	ctx := context.Background()
+	if _, err := rim.WriteAsync(ctx, "k", "v1"); err != nil {
+		t.Fatalf("WriteAsync failed: %v", err)
+	}
+	token, err := rim.ConsistencyTokenAsync(ctx)
+	if err != nil || token != 1 {
+		t.Fatalf("Expected token 1, got %d (%v)", token, err)
+	}
+
+	// Replica 尚未追上 Token，由 Master 提供
+	result, err := rim.ReadWithOptionsAsync(ctx, "k", redislib.ReadOptions{After: token})
+	if err != nil || result.Value != "v1" || result.Node != "memory:master" {
+		t.Errorf("Expected master read, got %+v (%v)", result, err)
+	}
+
+	// Replica 追上後改由 Replica 提供
+	clock.Advance(time.Second)
+	result, err = rim.ReadWithOptionsAsync(ctx, "k", redislib.ReadOptions{After: token})
+	if err != nil || result.Value != "v1" || result.Node != "memory:replica-1" {
+		t.Errorf("Expected caught-up replica read, got %+v (%v)", result, err)
+	}
+}
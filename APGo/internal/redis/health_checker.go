@@ -0,0 +1,194 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+const (
+	// defaultHealthCheckInterval 預設的健康檢查間隔
+	defaultHealthCheckInterval = 5 * time.Second
+	// defaultUnhealthyThreshold 預設連續失敗幾次後將 Replica 移出輪替
+	defaultUnhealthyThreshold = 2
+)
+
+// ReplicaHealth Replica 的健康狀態快照
+type ReplicaHealth struct {
+	Endpoint         string        `json:"endpoint"`
+	Healthy          bool          `json:"healthy"`
+	Latency          time.Duration `json:"latency"`
+	MasterLinkStatus string        `json:"master_link_status"` // INFO replication 的 master_link_status
+	Offset           int64         `json:"offset"`             // slave_repl_offset
+	LagBytes         int64         `json:"lag_bytes"`          // 與 Master offset 的落差，-1 表示未知
+	Staleness        time.Duration `json:"staleness"`          // 複寫時間落差，-1 表示未知
+	LastChecked      time.Time     `json:"last_checked"`
+	LastError        string        `json:"last_error,omitempty"`
+}
+
+// probeResult 單次健康檢查的結果
+type probeResult struct {
+	latency          time.Duration
+	masterLinkStatus string
+	offset           int64 // Master 為 master_repl_offset，Replica 為 slave_repl_offset，-1 表示未知
+	err              error
+}
+
+// probeNode 以 PING 量測延遲，並以 INFO replication 取得複寫 offset 與 Replica 的 Master 連線狀態
+func probeNode(ctx context.Context, node *readNode) probeResult {
+	start := time.Now()
+	if err := node.client.Ping(ctx).Err(); err != nil {
+		return probeResult{offset: -1, err: err}
+	}
+	result := probeResult{latency: time.Since(start), offset: -1}
+
+	info, err := node.client.Info(ctx, "replication").Result()
+	if err != nil {
+		result.err = err
+		return result
+	}
+	fields := redislib.ParseInfo(info)
+	if fields["role"] == "master" {
+		result.offset = redislib.InfoInt(fields, "master_repl_offset")
+		return result
+	}
+
+	result.offset = redislib.InfoInt(fields, "slave_repl_offset")
+	result.masterLinkStatus = fields["master_link_status"]
+	if result.masterLinkStatus != "up" {
+		result.err = fmt.Errorf("master link is %s", result.masterLinkStatus)
+	}
+	return result
+}
+
+// healthChecker 在背景定期檢查 Replica，
+// 將連續失敗的 Replica 移出讀取輪替，恢復後再加回，
+// 並比對 Master 與 Replica 的複寫 offset 計算複寫落差
+type healthChecker struct {
+	master    *readNode          // 可為 nil，此時不計算複寫落差
+	nodes     func() []*readNode // 每一輪要檢查的 Replica（Sentinel 的 Replica 會隨 Failover 變動）
+	interval  time.Duration
+	threshold int
+	probe     func(ctx context.Context, node *readNode) probeResult
+	history   offsetHistory
+	now       func() time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// newHealthChecker 建立健康檢查器（尚未啟動）
+func newHealthChecker(master *readNode, nodes []*readNode, interval time.Duration, threshold int) *healthChecker {
+	if interval == 0 {
+		interval = defaultHealthCheckInterval
+	}
+	if threshold <= 0 {
+		threshold = defaultUnhealthyThreshold
+	}
+	return &healthChecker{
+		master:    master,
+		nodes:     func() []*readNode { return nodes },
+		interval:  interval,
+		threshold: threshold,
+		probe:     probeNode,
+		now:       time.Now,
+	}
+}
+
+// Start 啟動背景檢查
+func (h *healthChecker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.checkAll(ctx)
+			}
+		}
+	}()
+}
+
+// Stop 停止背景檢查並等待進行中的檢查結束
+func (h *healthChecker) Stop() {
+	if h.cancel != nil {
+		h.cancel()
+	}
+	h.wg.Wait()
+}
+
+// checkAll 先取樣 Master offset，再平行檢查所有 Replica
+func (h *healthChecker) checkAll(ctx context.Context) {
+	// 單次檢查不超過檢查間隔，避免卡住的節點拖慢下一輪
+	ctx, cancel := context.WithTimeout(ctx, h.interval)
+	defer cancel()
+
+	if h.master != nil {
+		if result := h.probe(ctx, h.master); result.err == nil && result.offset >= 0 {
+			h.history.add(h.now(), result.offset)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, node := range h.nodes() {
+		wg.Add(1)
+		go func(node *readNode) {
+			defer wg.Done()
+			h.record(node, h.probe(ctx, node))
+		}(node)
+	}
+	wg.Wait()
+}
+
+// record 記錄檢查結果並更新節點是否在讀取輪替中
+func (h *healthChecker) record(node *readNode, result probeResult) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	now := h.now()
+	node.health.LastChecked = now
+	node.health.MasterLinkStatus = result.masterLinkStatus
+	node.health.Offset = result.offset
+	if result.offset >= 0 {
+		node.offset.Store(result.offset)
+	}
+
+	// 無法取得 offset 時落差視為未知，設定了落差上限的讀取會略過此節點
+	if bytes, staleness, ok := h.history.lag(result.offset, now); ok {
+		node.setLag(bytes, staleness)
+	} else {
+		node.setLag(-1, -1)
+	}
+	if result.latency > 0 {
+		node.health.Latency = result.latency
+		node.observeLatency(result.latency)
+	}
+
+	if result.err != nil {
+		node.failures++
+		node.health.LastError = result.err.Error()
+		if node.failures >= h.threshold && node.healthy.Load() {
+			node.healthy.Store(false)
+			fmt.Printf("Warning: replica %s removed from rotation: %v\n", node.endpoint, result.err)
+		}
+		return
+	}
+
+	node.failures = 0
+	node.health.LastError = ""
+	if !node.healthy.Load() {
+		node.healthy.Store(true)
+		fmt.Printf("Info: replica %s recovered and added back to rotation\n", node.endpoint)
+	}
+}
@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+func TestHealthChecker_EjectAndRecover(t *testing.T) {
+	master := newReadNode("m", nil, 0)
+	replica := newReadNode("r1", nil, 1)
+
+	var failing atomic.Bool
+	h := newHealthChecker(nil, []*readNode{replica}, time.Second, 2)
+	h.probe = func(ctx context.Context, node *readNode) probeResult {
+		if failing.Load() {
+			return probeResult{masterLinkStatus: "down", err: errors.New("master link is down")}
+		}
+		return probeResult{latency: time.Millisecond, masterLinkStatus: "up"}
+	}
+
+	var s readSelector
+	inRotation := func() bool {
+		for _, node := range s.order(redislib.ReadReplicaPreferred, master, []*readNode{replica}) {
+			if node == replica {
+				return true
+			}
+		}
+		return false
+	}
+
+	ctx := context.Background()
+	failing.Store(true)
+
+	// 第一次失敗仍在輪替中，達到門檻後才移出
+	h.checkAll(ctx)
+	if !inRotation() {
+		t.Fatal("Replica ejected before reaching threshold")
+	}
+	h.checkAll(ctx)
+	if inRotation() {
+		t.Fatal("Replica should be ejected after 2 consecutive failures")
+	}
+
+	health := replica.healthSnapshot()
+	if health.Healthy || health.MasterLinkStatus != "down" || health.LastError == "" {
+		t.Errorf("Unexpected health snapshot: %+v", health)
+	}
+
+	// 恢復後加回輪替
+	failing.Store(false)
+	h.checkAll(ctx)
+	if !inRotation() {
+		t.Fatal("Replica should be added back after recovery")
+	}
+	if health := replica.healthSnapshot(); !health.Healthy || health.Latency != time.Millisecond {
+		t.Errorf("Unexpected health snapshot after recovery: %+v", health)
+	}
+}
+
+func TestHealthChecker_StartStop(t *testing.T) {
+	replica := newReadNode("r1", nil, 1)
+
+	var probes atomic.Int32
+	h := newHealthChecker(nil, []*readNode{replica}, 10*time.Millisecond, 1)
+	h.probe = func(ctx context.Context, node *readNode) probeResult {
+		probes.Add(1)
+		return probeResult{latency: time.Millisecond}
+	}
+
+	h.Start()
+	time.Sleep(50 * time.Millisecond)
+	h.Stop()
+
+	after := probes.Load()
+	if after == 0 {
+		t.Fatal("Expected background checks to run")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if probes.Load() != after {
+		t.Error("Expected no checks after Stop")
+	}
+}
+
+func TestHealthChecker_ReplicationLag(t *testing.T) {
+	master := newPrimaryReadNode("m", nil)
+	replica := newReadNode("r1", nil, 1)
+
+	now := time.Unix(1000, 0)
+	masterOffset, replicaOffset := int64(100), int64(100)
+	h := newHealthChecker(master, []*readNode{replica}, time.Second, 2)
+	h.now = func() time.Time { return now }
+	h.probe = func(ctx context.Context, node *readNode) probeResult {
+		if node == master {
+			return probeResult{offset: masterOffset}
+		}
+		return probeResult{masterLinkStatus: "up", offset: replicaOffset}
+	}
+
+	ctx := context.Background()
+	h.checkAll(ctx)
+	if bytes, staleness, ok := replica.Lag(); !ok || bytes != 0 || staleness != 0 {
+		t.Fatalf("Expected caught-up replica, got (%d, %s, %v)", bytes, staleness, ok)
+	}
+
+	// Master 持續寫入但 Replica 停在 100
+	now = now.Add(time.Second)
+	masterOffset = 500
+	h.checkAll(ctx)
+	if bytes, staleness, _ := replica.Lag(); bytes != 400 || staleness != time.Second {
+		t.Errorf("Expected lag (400, 1s), got (%d, %s)", bytes, staleness)
+	}
+	if health := replica.healthSnapshot(); health.Offset != 100 || health.LagBytes != 400 {
+		t.Errorf("Unexpected health snapshot: %+v", health)
+	}
+
+	// 無法取得 offset 時落差變為未知
+	replicaOffset = -1
+	h.checkAll(ctx)
+	if _, _, ok := replica.Lag(); ok {
+		t.Error("Expected unknown lag when replica offset is unavailable")
+	}
+}
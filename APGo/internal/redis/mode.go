@@ -0,0 +1,21 @@
+package redis
+
+import "github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+
+// ModeOf 依連線實作判斷 Redis 模式，conn 可以是裝飾器；無法判斷時返回 -1
+func ModeOf(conn redislib.IRedisConn) redislib.RedisMode {
+	switch redislib.Unwrap(conn).(type) {
+	case *RedisMasterSlave:
+		return redislib.RedisMasterSlaves
+	case *RedisSentinel:
+		return redislib.RedisSentinel
+	case *RedisCluster:
+		return redislib.RedisCluster
+	case *RedisRaft:
+		return redislib.RedisRaft
+	case *RedisInMemory:
+		return redislib.RedisInMemory
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,43 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+// wrappedConn 模擬裝飾器
+type wrappedConn struct {
+	redislib.IRedisConn
+}
+
+func (w wrappedConn) Unwrap() redislib.IRedisConn {
+	return w.IRedisConn
+}
+
+func TestModeOf(t *testing.T) {
+	conn, err := NewRedisInMemory("", nil, 0)
+	if err != nil {
+		t.Fatalf("NewRedisInMemory() error = %v", err)
+	}
+	defer conn.Close()
+
+	tests := []struct {
+		name string
+		conn redislib.IRedisConn
+		want redislib.RedisMode
+	}{
+		{"in-memory", conn, redislib.RedisInMemory},
+		{"decorated", wrappedConn{wrappedConn{conn}}, redislib.RedisInMemory},
+		{"raft", &RedisRaft{}, redislib.RedisRaft},
+		{"unknown", wrappedConn{}, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ModeOf(tt.conn); got != tt.want {
+				t.Errorf("ModeOf() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"sort"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// clusterPoolEndpoint Cluster 模式連線池統計的端點名稱（所有節點合計）
+const clusterPoolEndpoint = "cluster"
+
+// newPoolStats 轉換 go-redis 的連線池統計
+func newPoolStats(endpoint string, s *goredis.PoolStats) redislib.PoolStats {
+	return redislib.PoolStats{
+		Endpoint:   endpoint,
+		Hits:       uint64(s.Hits),
+		Misses:     uint64(s.Misses),
+		Timeouts:   uint64(s.Timeouts),
+		TotalConns: uint64(s.TotalConns),
+		IdleConns:  uint64(s.IdleConns),
+		StaleConns: uint64(s.StaleConns),
+	}
+}
+
+// PoolStats 取得 Master 與各 Replica 的連線池統計
+func (r *RedisMasterSlave) PoolStats() []redislib.PoolStats {
+	stats := []redislib.PoolStats{newPoolStats(r.masterEndpoint, r.master.PoolStats())}
+	for _, node := range r.replicaNodes {
+		stats = append(stats, newPoolStats(node.endpoint, node.client.PoolStats()))
+	}
+	return stats
+}
+
+// PoolStats 取得 Master（透過 Sentinel 的連線）與已建立連線的 Replica 的連線池統計
+func (r *RedisSentinel) PoolStats() []redislib.PoolStats {
+	stats := []redislib.PoolStats{newPoolStats(r.GetMasterEndpoint(), r.client.PoolStats())}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	replicas := make([]redislib.PoolStats, 0, len(r.replicaNodes))
+	for endpoint, node := range r.replicaNodes {
+		replicas = append(replicas, newPoolStats(endpoint, node.client.PoolStats()))
+	}
+	sort.Slice(replicas, func(i, j int) bool { return replicas[i].Endpoint < replicas[j].Endpoint })
+	return append(stats, replicas...)
+}
+
+// PoolStats 取得所有節點合計的連線池統計
+// 個別節點的統計需要取得 Cluster 狀態，為避免每次取得統計都發出網路請求只回報合計
+func (r *RedisCluster) PoolStats() []redislib.PoolStats {
+	return []redislib.PoolStats{newPoolStats(clusterPoolEndpoint, r.client.PoolStats())}
+}
+
+// PoolStats 取得已建立連線的各 Raft 節點（包含 Leader 重新導向時新增的節點）的連線池統計
+func (r *RedisRaft) PoolStats() []redislib.PoolStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := make([]redislib.PoolStats, 0, len(r.clients))
+	for node, client := range r.clients {
+		stats = append(stats, newPoolStats(node, client.PoolStats()))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Endpoint < stats[j].Endpoint })
+	return stats
+}
@@ -0,0 +1,23 @@
+package redis
+
+import (
+	"testing"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func TestRedisRaft_PoolStats(t *testing.T) {
+	r := &RedisRaft{clients: map[string]*goredis.Client{
+		"node-b:6379": goredis.NewClient(&goredis.Options{Addr: "node-b:6379"}),
+		"node-a:6379": goredis.NewClient(&goredis.Options{Addr: "node-a:6379"}),
+	}}
+	defer r.Close()
+
+	stats := r.PoolStats()
+	if len(stats) != 2 || stats[0].Endpoint != "node-a:6379" || stats[1].Endpoint != "node-b:6379" {
+		t.Fatalf("Expected pool stats for both nodes sorted by endpoint, got %+v", stats)
+	}
+	if stats[0].TotalConns != 0 {
+		t.Errorf("Expected no connections before any command, got %+v", stats[0])
+	}
+}
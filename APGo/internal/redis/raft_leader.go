@@ -0,0 +1,178 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	// raftMaxAttempts 單一操作最多嘗試的次數（包含重定向與重新尋找 Leader）
+	raftMaxAttempts = 5
+	// raftElectionBackoff 叢集正在選舉 Leader 時，重試前等待的時間
+	raftElectionBackoff = 200 * time.Millisecond
+)
+
+// raftErrorKind Raft 節點錯誤的處理方式
+type raftErrorKind int
+
+const (
+	raftErrNone        raftErrorKind = iota // 成功或不需要重試的錯誤
+	raftErrRedirect                         // 節點不是 Leader 並指出了 Leader 位址
+	raftErrNoLeader                         // 叢集目前沒有 Leader（選舉中）
+	raftErrUnavailable                      // 節點無法連線
+)
+
+// classifyRaftError 判斷錯誤是否需要改試其他節點，重定向時一併返回 Leader 位址
+// 支援 RedisRaft 的 MOVED <slot> <addr>、LEADERIS <addr>、NOTLEADER [addr]、NOLEADER 與 CLUSTERDOWN
+func classifyRaftError(err error) (raftErrorKind, string) {
+	if err == nil || errors.Is(err, goredis.Nil) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return raftErrNone, ""
+	}
+
+	var redisErr goredis.Error
+	if errors.As(err, &redisErr) {
+		fields := strings.Fields(redisErr.Error())
+		if len(fields) == 0 {
+			return raftErrNone, ""
+		}
+		switch fields[0] {
+		case "MOVED":
+			if len(fields) >= 3 {
+				return raftErrRedirect, fields[2]
+			}
+		case "LEADERIS", "NOTLEADER":
+			if len(fields) >= 2 {
+				return raftErrRedirect, fields[1]
+			}
+			return raftErrNoLeader, ""
+		case "NOLEADER", "CLUSTERDOWN":
+			return raftErrNoLeader, ""
+		}
+		return raftErrNone, ""
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, goredis.ErrClosed) {
+		return raftErrUnavailable, ""
+	}
+	return raftErrNone, ""
+}
+
+// withLeader 在目前的 Leader 上執行操作
+// 遇到重定向時改用指出的 Leader，節點無法連線或沒有 Leader 時重新尋找 Leader 後重試
+func (r *RedisRaft) withLeader(ctx context.Context, fn func(client *goredis.Client, leader string) error) error {
+	var err error
+	for attempt := 0; attempt < raftMaxAttempts; attempt++ {
+		client, leader, clientErr := r.leaderClient()
+		if clientErr != nil {
+			return clientErr
+		}
+		err = fn(client, leader)
+
+		kind, addr := classifyRaftError(err)
+		switch kind {
+		case raftErrNone:
+			redislib.RecordServedBy(ctx, leader)
+			return err
+		case raftErrRedirect:
+			r.setLeader(addr)
+			continue
+		case raftErrNoLeader:
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(raftElectionBackoff):
+			}
+		}
+
+		if discoverErr := r.discoverLeader(ctx); discoverErr != nil {
+			fmt.Printf("Warning: failed to discover raft leader: %v\n", discoverErr)
+		}
+	}
+	return err
+}
+
+// discoverLeader 依序向各節點查詢 RAFT.INFO，以第一個回報的 Leader 為準
+func (r *RedisRaft) discoverLeader(ctx context.Context) error {
+	var errs []error
+	for _, node := range r.nodes {
+		client, err := r.clientFor(node)
+		if err != nil {
+			return err
+		}
+		info, err := r.raftInfo(ctx, client)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", node, err))
+			continue
+		}
+		if leader, ok := info.Leader(node); ok {
+			r.setLeader(leader)
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: no leader elected", node))
+	}
+	return errors.Join(errs...)
+}
+
+// leaderClient 取得目前 Leader 的連線
+func (r *RedisRaft) leaderClient() (*goredis.Client, string, error) {
+	r.mu.RLock()
+	leader := r.leader
+	r.mu.RUnlock()
+	client, err := r.clientFor(leader)
+	return client, leader, err
+}
+
+// setLeader 更新目前的 Leader
+func (r *RedisRaft) setLeader(addr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.leader = addr
+}
+
+// clientFor 取得節點的連線，Leader 位址不在設定中時會建立新的連線
+// Close 之後返回 ErrConnectionFailed，不再建立連線
+func (r *RedisRaft) clientFor(addr string) (*goredis.Client, error) {
+	r.mu.RLock()
+	client, ok := r.clients[addr]
+	closed := r.closed
+	r.mu.RUnlock()
+	if closed {
+		return nil, fmt.Errorf("%w: raft connection is closed", redislib.ErrConnectionFailed)
+	}
+	if ok {
+		return client, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, fmt.Errorf("%w: raft connection is closed", redislib.ErrConnectionFailed)
+	}
+	if client, ok := r.clients[addr]; ok {
+		return client, nil
+	}
+	client = goredis.NewClient(r.clientOpts.options(addr))
+	r.clients[addr] = client
+	return client, nil
+}
+
+// firstBatchError 取得批次結果中的第一個錯誤
+func firstBatchError(results []redislib.BatchResult) error {
+	for _, result := range results {
+		if result.Err != nil {
+			return result.Err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// raftTestError 模擬 Redis 回傳的錯誤
+type raftTestError string
+
+func (e raftTestError) Error() string { return string(e) }
+func (e raftTestError) RedisError()   {}
+
+func TestClassifyRaftError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantKind raftErrorKind
+		wantAddr string
+	}{
+		{"nil", nil, raftErrNone, ""},
+		{"key not found", goredis.Nil, raftErrNone, ""},
+		{"moved", raftTestError("MOVED 3999 10.0.0.2:5002"), raftErrRedirect, "10.0.0.2:5002"},
+		{"leaderis", raftTestError("LEADERIS 10.0.0.3:5003"), raftErrRedirect, "10.0.0.3:5003"},
+		{"notleader with address", raftTestError("NOTLEADER 10.0.0.3:5003"), raftErrRedirect, "10.0.0.3:5003"},
+		{"notleader without address", raftTestError("NOTLEADER"), raftErrNoLeader, ""},
+		{"noleader", raftTestError("NOLEADER No Raft leader"), raftErrNoLeader, ""},
+		{"clusterdown", raftTestError("CLUSTERDOWN No raft leader"), raftErrNoLeader, ""},
+		{"wrapped redirect", fmt.Errorf("write failed: %w", raftTestError("MOVED 1 10.0.0.2:5002")), raftErrRedirect, "10.0.0.2:5002"},
+		{"other redis error", raftTestError("WRONGTYPE Operation against a key"), raftErrNone, ""},
+		{"connection refused", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, raftErrUnavailable, ""},
+		{"eof", io.EOF, raftErrUnavailable, ""},
+		{"context canceled", context.Canceled, raftErrNone, ""},
+		{"validation error", errors.New("invalid ttl"), raftErrNone, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, addr := classifyRaftError(tt.err)
+			if kind != tt.wantKind || addr != tt.wantAddr {
+				t.Errorf("classifyRaftError(%v) = (%d, %q), want (%d, %q)", tt.err, kind, addr, tt.wantKind, tt.wantAddr)
+			}
+		})
+	}
+}
+
+func TestRedisRaft_WithLeaderFollowsRedirect(t *testing.T) {
+	rr := &RedisRaft{
+		nodes:   []string{"10.0.0.1:5001", "10.0.0.2:5002"},
+		clients: make(map[string]*goredis.Client),
+		leader:  "10.0.0.1:5001",
+	}
+	defer rr.Close()
+
+	var tried []string
+	err := rr.withLeader(context.Background(), func(client *goredis.Client, leader string) error {
+		tried = append(tried, leader)
+		if client.Options().Addr != leader {
+			t.Errorf("client address %s does not match leader %s", client.Options().Addr, leader)
+		}
+		if leader != "10.0.0.2:5002" {
+			return raftTestError("MOVED 3999 10.0.0.2:5002")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("withLeader() error = %v", err)
+	}
+	if len(tried) != 2 || tried[1] != "10.0.0.2:5002" {
+		t.Errorf("Expected retry on redirected leader, tried %v", tried)
+	}
+	if got := rr.GetMasterEndpoint(); got != "10.0.0.2:5002" {
+		t.Errorf("Expected leader 10.0.0.2:5002, got %s", got)
+	}
+}
+
+func TestRedisRaft_ClientForAfterClose(t *testing.T) {
+	rr := &RedisRaft{
+		nodes:   []string{"10.0.0.1:5001"},
+		clients: make(map[string]*goredis.Client),
+		leader:  "10.0.0.9:5009",
+	}
+	if err := rr.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Close 之後不再建立連線（包含不在設定中的 Leader），直接返回錯誤而不是重試
+	called := false
+	err := rr.withLeader(context.Background(), func(client *goredis.Client, leader string) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, redislib.ErrConnectionFailed) || called {
+		t.Errorf("withLeader() after Close error = %v (called %v), want ErrConnectionFailed", err, called)
+	}
+	// 讀取錯誤保留原因，呼叫端可以依原因分類
+	if _, err := rr.ReadAsync(context.Background(), "key"); !errors.Is(err, redislib.ErrReadFailed) || !errors.Is(err, redislib.ErrConnectionFailed) {
+		t.Errorf("ReadAsync() after Close error = %v, want ErrReadFailed wrapping ErrConnectionFailed", err)
+	}
+	if _, err := rr.GetRaftNode(context.Background(), "10.0.0.1:5001"); !errors.Is(err, redislib.ErrConnectionFailed) {
+		t.Errorf("GetRaftNode() after Close error = %v, want ErrConnectionFailed", err)
+	}
+	if len(rr.clients) != 0 {
+		t.Errorf("Expected no clients after Close, got %d", len(rr.clients))
+	}
+}
+
+func TestRedisRaft_BatchAfterClose(t *testing.T) {
+	rr := &RedisRaft{
+		nodes:   []string{"10.0.0.1:5001"},
+		clients: make(map[string]*goredis.Client),
+		leader:  "10.0.0.1:5001",
+	}
+	if err := rr.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// 批次未曾執行時必須返回錯誤，不能以空結果表示成功
+	results, err := rr.BatchReadAsync(context.Background(), []string{"a", "b"})
+	if !errors.Is(err, redislib.ErrReadFailed) || !errors.Is(err, redislib.ErrConnectionFailed) || results != nil {
+		t.Errorf("BatchReadAsync() after Close = %v, %v, want ErrReadFailed wrapping ErrConnectionFailed", results, err)
+	}
+	results, err = rr.BatchWriteAsync(context.Background(), []redislib.KeyValue{{Key: "a", Value: "1"}})
+	if !errors.Is(err, redislib.ErrWriteFailed) || !errors.Is(err, redislib.ErrConnectionFailed) || results != nil {
+		t.Errorf("BatchWriteAsync() after Close = %v, %v, want ErrWriteFailed wrapping ErrConnectionFailed", results, err)
+	}
+}
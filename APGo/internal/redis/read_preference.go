@@ -0,0 +1,214 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// latencyEWMAWeight 延遲移動平均中新樣本的權重
+const latencyEWMAWeight = 0.2
+
+// readNode 可供讀取的節點，記錄權重、量測到的延遲與健康狀態
+type readNode struct {
+	endpoint string
+	client   *goredis.Client
+	weight   int
+	latency  atomic.Int64 // 延遲的指數移動平均（奈秒），0 表示尚未量測
+	healthy  atomic.Bool  // false 表示已被健康檢查移出讀取輪替
+	// 複寫落差（位元組與時間），-1 表示尚未量測
+	lagBytes  atomic.Int64
+	staleness atomic.Int64
+	offset    atomic.Int64 // 最近一次得知的複寫 offset，-1 表示未知
+
+	mu       sync.Mutex // 保護以下健康檢查欄位
+	health   ReplicaHealth
+	failures int // 連續失敗次數
+}
+
+// newReadNode 建立讀取節點（預設為健康狀態，複寫落差尚未量測）
+func newReadNode(endpoint string, client *goredis.Client, weight int) *readNode {
+	node := &readNode{endpoint: endpoint, client: client, weight: weight}
+	node.healthy.Store(true)
+	node.setLag(-1, -1)
+	node.offset.Store(-1)
+	return node
+}
+
+// newPrimaryReadNode 建立 Master 讀取節點（Master 不會有複寫落差）
+func newPrimaryReadNode(endpoint string, client *goredis.Client) *readNode {
+	node := newReadNode(endpoint, client, 0)
+	node.setLag(0, 0)
+	return node
+}
+
+// healthSnapshot 取得目前的健康狀態快照
+func (n *readNode) healthSnapshot() ReplicaHealth {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	health := n.health
+	health.Endpoint = n.endpoint
+	health.Healthy = n.healthy.Load()
+	health.LagBytes, health.Staleness, _ = n.Lag()
+	return health
+}
+
+// observeLatency 記錄一次量測到的延遲
+func (n *readNode) observeLatency(d time.Duration) {
+	for {
+		old := n.latency.Load()
+		next := int64(d)
+		if old != 0 {
+			next = int64(float64(old)*(1-latencyEWMAWeight) + float64(d)*latencyEWMAWeight)
+		}
+		if n.latency.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// Latency 取得目前的延遲移動平均
+func (n *readNode) Latency() time.Duration {
+	return time.Duration(n.latency.Load())
+}
+
+// readSelector 依讀取偏好排列候選節點
+type readSelector struct {
+	counter atomic.Uint64 // round_robin 使用的計數器
+}
+
+// order 返回嘗試讀取的節點順序，第一個讀取失敗時依序嘗試下一個
+// 被健康檢查移出輪替的 Replica 不會出現在結果中
+func (s *readSelector) order(pref redislib.ReadPreference, master *readNode, replicas []*readNode) []*readNode {
+	replicas = healthyNodes(replicas)
+
+	switch pref {
+	case redislib.ReadPrimary:
+		return []*readNode{master}
+
+	case redislib.ReadPrimaryPreferred:
+		return append([]*readNode{master}, replicas...)
+
+	case redislib.ReadReplica:
+		return append([]*readNode(nil), replicas...)
+
+	case redislib.ReadNearest:
+		nodes := append([]*readNode{master}, replicas...)
+		// 尚未量測的節點排在最後，延遲相同時保持原始順序
+		sort.SliceStable(nodes, func(i, j int) bool {
+			li, lj := nodes[i].Latency(), nodes[j].Latency()
+			if li == 0 || lj == 0 {
+				return lj == 0 && li != 0
+			}
+			return li < lj
+		})
+		return nodes
+
+	case redislib.ReadRoundRobin:
+		if len(replicas) == 0 {
+			return []*readNode{master}
+		}
+		start := int(s.counter.Add(1)-1) % len(replicas)
+		nodes := make([]*readNode, 0, len(replicas)+1)
+		nodes = append(nodes, replicas[start:]...)
+		nodes = append(nodes, replicas[:start]...)
+		return append(nodes, master)
+
+	case redislib.ReadWeighted:
+		return append(weightedShuffle(replicas), master)
+
+	default: // redislib.ReadReplicaPreferred
+		return append(append([]*readNode(nil), replicas...), master)
+	}
+}
+
+// healthyNodes 過濾出仍在讀取輪替中的節點
+func healthyNodes(nodes []*readNode) []*readNode {
+	healthy := make([]*readNode, 0, len(nodes))
+	for _, node := range nodes {
+		if node.healthy.Load() {
+			healthy = append(healthy, node)
+		}
+	}
+	return healthy
+}
+
+// weightedShuffle 依權重隨機排列節點（權重越高越可能排在前面）
+// 權重小於等於 0 的節點不會被選到
+func weightedShuffle(nodes []*readNode) []*readNode {
+	remaining := make([]*readNode, 0, len(nodes))
+	total := 0
+	for _, node := range nodes {
+		if node.weight > 0 {
+			remaining = append(remaining, node)
+			total += node.weight
+		}
+	}
+
+	result := make([]*readNode, 0, len(remaining))
+	for len(remaining) > 0 {
+		pick := rand.Intn(total)
+		for i, node := range remaining {
+			if pick < node.weight {
+				result = append(result, node)
+				total -= node.weight
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+			pick -= node.weight
+		}
+	}
+	return result
+}
+
+// readFromNodes 依序嘗試從節點讀取，連線錯誤時改試下一個節點
+// Key 不存在視為確定結果，不再嘗試其他節點
+func readFromNodes(ctx context.Context, nodes []*readNode, key string) (redislib.ReadResult, error) {
+	if len(nodes) == 0 {
+		return redislib.ReadResult{}, fmt.Errorf("%w: no node available for read", redislib.ErrReadFailed)
+	}
+
+	var lastErr error
+	for _, node := range nodes {
+		start := time.Now()
+		val, err := node.client.Get(ctx, key).Result()
+		if err == goredis.Nil {
+			node.observeLatency(time.Since(start))
+			redislib.RecordServedBy(ctx, node.endpoint)
+			return node.readResult(""), redislib.ErrKeyNotFound
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %v", node.endpoint, err)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		node.observeLatency(time.Since(start))
+		redislib.RecordServedBy(ctx, node.endpoint)
+		return node.readResult(val), nil
+	}
+	return redislib.ReadResult{}, fmt.Errorf("%w: %v", redislib.ErrReadFailed, lastErr)
+}
+
+// readResult 建立由此節點提供資料的讀取結果
+func (n *readNode) readResult(value string) redislib.ReadResult {
+	bytes, staleness, ok := n.Lag()
+	if !ok {
+		bytes, staleness = -1, -1
+	}
+	return redislib.ReadResult{
+		Value:     value,
+		Node:      n.endpoint,
+		LagBytes:  bytes,
+		Staleness: staleness,
+	}
+}
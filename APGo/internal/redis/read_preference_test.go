@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+func endpoints(nodes []*readNode) []string {
+	result := make([]string, len(nodes))
+	for i, node := range nodes {
+		result[i] = node.endpoint
+	}
+	return result
+}
+
+func TestReadSelectorOrder(t *testing.T) {
+	master := newReadNode("m", nil, 0)
+	r1 := newReadNode("r1", nil, 1)
+	r2 := newReadNode("r2", nil, 1)
+	replicas := []*readNode{r1, r2}
+
+	master.observeLatency(5 * time.Millisecond)
+	r1.observeLatency(9 * time.Millisecond)
+	r2.observeLatency(1 * time.Millisecond)
+
+	tests := []struct {
+		pref redislib.ReadPreference
+		want []string
+	}{
+		{redislib.ReadPrimary, []string{"m"}},
+		{redislib.ReadPrimaryPreferred, []string{"m", "r1", "r2"}},
+		{redislib.ReadReplica, []string{"r1", "r2"}},
+		{redislib.ReadReplicaPreferred, []string{"r1", "r2", "m"}},
+		{redislib.ReadNearest, []string{"r2", "m", "r1"}},
+	}
+
+	var s readSelector
+	for _, tt := range tests {
+		t.Run(string(tt.pref), func(t *testing.T) {
+			got := endpoints(s.order(tt.pref, master, replicas))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestReadSelectorRoundRobin(t *testing.T) {
+	master := newReadNode("m", nil, 0)
+	replicas := []*readNode{newReadNode("r1", nil, 1), newReadNode("r2", nil, 1), newReadNode("r3", nil, 1)}
+
+	var s readSelector
+	var first []string
+	for i := 0; i < 4; i++ {
+		order := endpoints(s.order(redislib.ReadRoundRobin, master, replicas))
+		first = append(first, order[0])
+		if order[len(order)-1] != "m" {
+			t.Errorf("Expected master as last fallback, got %v", order)
+		}
+	}
+
+	want := []string{"r1", "r2", "r3", "r1"}
+	for i := range want {
+		if first[i] != want[i] {
+			t.Fatalf("Expected rotation %v, got %v", want, first)
+		}
+	}
+}
+
+func TestReadSelectorWeighted(t *testing.T) {
+	master := newReadNode("m", nil, 0)
+	heavy := newReadNode("heavy", nil, 9)
+	light := newReadNode("light", nil, 1)
+	disabled := newReadNode("disabled", nil, 0)
+
+	var s readSelector
+	counts := make(map[string]int)
+	for i := 0; i < 2000; i++ {
+		order := endpoints(s.order(redislib.ReadWeighted, master, []*readNode{heavy, light, disabled}))
+		if len(order) != 3 {
+			t.Fatalf("Expected weight 0 replica to be skipped, got %v", order)
+		}
+		counts[order[0]]++
+	}
+
+	// 權重 9:1，heavy 被選為第一順位的比例應遠高於 light
+	if counts["heavy"] < 1500 || counts["light"] == 0 {
+		t.Errorf("Unexpected weighted distribution: %v", counts)
+	}
+}
+
+func TestObserveLatency(t *testing.T) {
+	var n readNode
+	n.observeLatency(10 * time.Millisecond)
+	if n.Latency() != 10*time.Millisecond {
+		t.Errorf("Expected first sample to be used as is, got %s", n.Latency())
+	}
+
+	n.observeLatency(20 * time.Millisecond)
+	if n.Latency() != 12*time.Millisecond {
+		t.Errorf("Expected EWMA 12ms, got %s", n.Latency())
+	}
+}
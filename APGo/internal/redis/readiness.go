@@ -0,0 +1,269 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// nodeProbe 就緒檢查要探測的節點
+type nodeProbe struct {
+	address string
+	role    redislib.NodeRole
+	check   func(ctx context.Context) error
+}
+
+// pingProbe 以 PING 探測節點
+func pingProbe(address string, role redislib.NodeRole, client *goredis.Client) nodeProbe {
+	return nodeProbe{address: address, role: role, check: func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}}
+}
+
+// masterProbe 以 ROLE 探測 Master，確認節點仍然是可寫入的 Master（例如沒有在 Failover 後被降為 Replica）
+func masterProbe(address string, client *goredis.Client) nodeProbe {
+	return nodeProbe{address: address, role: redislib.RoleMaster, check: func(ctx context.Context) error {
+		return checkMasterRole(ctx, client)
+	}}
+}
+
+// checkMasterRole 確認節點的 ROLE 為 master
+func checkMasterRole(ctx context.Context, client *goredis.Client) error {
+	reply, err := client.Do(ctx, "ROLE").Slice()
+	if err != nil {
+		return err
+	}
+	if len(reply) == 0 {
+		return fmt.Errorf("%w: empty ROLE reply", redislib.ErrInvalidReply)
+	}
+	if role := fmt.Sprint(reply[0]); role != "master" {
+		return fmt.Errorf("node reports role %s, not master", role)
+	}
+	return nil
+}
+
+// probeNodes 同時探測所有節點並記錄延遲，結果順序與 probes 相同
+func probeNodes(ctx context.Context, probes []nodeProbe) []redislib.NodeCheck {
+	checks := make([]redislib.NodeCheck, len(probes))
+	var wg sync.WaitGroup
+	for i, probe := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := probe.check(ctx)
+			checks[i] = redislib.NodeCheck{
+				Address:   probe.address,
+				Role:      probe.role,
+				Healthy:   err == nil,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				checks[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+	return checks
+}
+
+// Readiness 探測 Master（ROLE）與所有 Replica（PING），Master 無法寫入時未就緒
+// Replica 失敗只會降低讀取能力，不影響就緒狀態
+func (r *RedisMasterSlave) Readiness(ctx context.Context) redislib.Readiness {
+	probes := []nodeProbe{masterProbe(r.masterEndpoint, r.master)}
+	for _, node := range r.replicaNodes {
+		probes = append(probes, pingProbe(node.endpoint, redislib.RoleReplica, node.client))
+	}
+
+	readiness := redislib.NewReadiness(redislib.RedisMasterSlaves.String(), probeNodes(ctx, probes))
+	if master := readiness.Nodes[0]; !master.Healthy {
+		readiness.LoseWrites("master %s is not writable: %s", master.Address, master.Error)
+	}
+	return readiness
+}
+
+// Readiness 探測所有 Sentinel（SENTINEL CKQUORUM）、Master（ROLE）與 Sentinel 回報的 Replica（PING）
+// 沒有任何 Sentinel 回報具備 quorum 時視為失去多數派（無法 Failover），Master 無法寫入時未就緒
+func (r *RedisSentinel) Readiness(ctx context.Context) redislib.Readiness {
+	master, replicas, _ := r.endpoints.snapshot()
+	if master == "" {
+		master = r.GetMasterEndpoint()
+	}
+
+	probes := []nodeProbe{masterProbe(master, r.client)}
+	// 與 Sentinel 相同以短暫的連線探測 Replica，不為未用於讀取的 Replica 建立連線池
+	for _, replica := range replicas {
+		probes = append(probes, nodeProbe{address: replica, role: redislib.RoleReplica, check: func(ctx context.Context) error {
+			return r.pingReplica(ctx, replica)
+		}})
+	}
+	for _, addr := range r.sentinels {
+		probes = append(probes, nodeProbe{address: addr, role: redislib.RoleSentinel, check: func(ctx context.Context) error {
+			sentinel := goredis.NewSentinelClient(r.sentinelClientOptions(addr))
+			defer sentinel.Close()
+			return sentinel.CkQuorum(ctx, r.masterName).Err()
+		}})
+	}
+
+	readiness := redislib.NewReadiness(redislib.RedisSentinel.String(), probeNodes(ctx, probes))
+	if node := readiness.Nodes[0]; !node.Healthy {
+		readiness.LoseWrites("master %s is not writable: %s", node.Address, node.Error)
+	}
+	quorum := 0
+	for _, node := range readiness.Nodes {
+		if node.Role == redislib.RoleSentinel && node.Healthy {
+			quorum++
+		}
+	}
+	if quorum == 0 {
+		readiness.LoseQuorum("no sentinel reports quorum for master %s", r.masterName)
+	}
+	return readiness
+}
+
+// Readiness 探測 Cluster 的所有 Master 與 Replica（PING），並以 CLUSTER INFO 的 cluster_state 判斷多數派
+// cluster_state 不是 ok 或任何 Master 無法連線時未就緒（部分 slot 無法寫入）
+func (r *RedisCluster) Readiness(ctx context.Context) redislib.Readiness {
+	var mu sync.Mutex
+	var probes []nodeProbe
+	collect := func(role redislib.NodeRole) func(ctx context.Context, client *goredis.Client) error {
+		return func(ctx context.Context, client *goredis.Client) error {
+			mu.Lock()
+			defer mu.Unlock()
+			probes = append(probes, pingProbe(client.Options().Addr, role, client))
+			return nil
+		}
+	}
+	// 取得節點列表時會重新載入 Cluster 狀態，失敗表示無法連到任何節點
+	if err := r.client.ForEachMaster(ctx, collect(redislib.RoleMaster)); err != nil {
+		readiness := redislib.NewReadiness(redislib.RedisCluster.String(), nil)
+		readiness.LoseWrites("failed to load cluster state: %v", err)
+		readiness.LoseQuorum("failed to load cluster state: %v", err)
+		return readiness
+	}
+	_ = r.client.ForEachSlave(ctx, collect(redislib.RoleReplica))
+	sort.Slice(probes, func(i, j int) bool {
+		if probes[i].role != probes[j].role {
+			return probes[i].role == redislib.RoleMaster
+		}
+		return probes[i].address < probes[j].address
+	})
+
+	readiness := redislib.NewReadiness(redislib.RedisCluster.String(), probeNodes(ctx, probes))
+	for _, node := range readiness.Nodes {
+		if node.Role == redislib.RoleMaster && !node.Healthy {
+			readiness.LoseWrites("master %s is unreachable: %s", node.Address, node.Error)
+		}
+	}
+	info, err := r.GetClusterInfo(ctx)
+	switch {
+	case err != nil:
+		readiness.LoseQuorum("%v", err)
+	case info.State != "ok":
+		readiness.LoseQuorum("cluster_state is %s", info.State)
+	}
+	return readiness
+}
+
+// Readiness 向每個設定的節點查詢 RAFT.INFO，節點狀態為 up 才視為健康
+// 健康節點未達多數或沒有可連線的 Leader 時未就緒
+func (r *RedisRaft) Readiness(ctx context.Context) redislib.Readiness {
+	var mu sync.Mutex
+	roles := make(map[string]redislib.NodeRole, len(r.nodes))
+	leaders := make(map[string]bool)
+	probes := make([]nodeProbe, 0, len(r.nodes))
+	for _, addr := range r.nodes {
+		probes = append(probes, nodeProbe{address: addr, role: redislib.RoleUnknown, check: func(ctx context.Context) error {
+			client, err := r.clientFor(addr)
+			if err != nil {
+				return err
+			}
+			info, err := r.raftInfo(ctx, client)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			roles[addr] = raftRole(info.Role)
+			if leader, ok := info.Leader(addr); ok {
+				leaders[leader] = true
+			}
+			if info.State != "up" {
+				return fmt.Errorf("raft state is %s", info.State)
+			}
+			return nil
+		}})
+	}
+
+	readiness := redislib.NewReadiness(redislib.RedisRaft.String(), probeNodes(ctx, probes))
+	leader := ""
+	for i, node := range readiness.Nodes {
+		if role, ok := roles[node.Address]; ok {
+			readiness.Nodes[i].Role = role
+		}
+		if node.Healthy && readiness.Nodes[i].Role == redislib.RoleLeader {
+			leader = node.Address
+		}
+	}
+
+	if healthy, need := readiness.HealthyCount(), redislib.Majority(len(r.nodes)); healthy < need {
+		readiness.LoseQuorum("only %d of %d raft nodes are up, %d required", healthy, len(r.nodes), need)
+	}
+	if leader == "" {
+		if len(leaders) > 0 {
+			readiness.LoseWrites("raft leader %s is unreachable", firstKey(leaders))
+		} else {
+			readiness.LoseWrites("no raft leader elected")
+		}
+	}
+	return readiness
+}
+
+// raftRole 將 RAFT.INFO 的 role 轉為拓樸角色
+func raftRole(role string) redislib.NodeRole {
+	switch role {
+	case "leader":
+		return redislib.RoleLeader
+	case "follower":
+		return redislib.RoleFollower
+	case "candidate":
+		return redislib.RoleCandidate
+	default:
+		return redislib.RoleUnknown
+	}
+}
+
+// firstKey 依字母順序取得第一個鍵
+func firstKey(m map[string]bool) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys[0]
+}
+
+// Readiness 模擬的節點永遠健康，連線關閉或請求取消時未就緒
+func (r *RedisInMemory) Readiness(ctx context.Context) redislib.Readiness {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nodes := []redislib.NodeCheck{{Address: r.master.endpoint, Role: redislib.RoleMaster, Healthy: true}}
+	for _, replica := range r.replicas {
+		nodes = append(nodes, redislib.NodeCheck{Address: replica.endpoint, Role: redislib.RoleReplica, Healthy: true})
+	}
+	readiness := redislib.NewReadiness(redislib.RedisInMemory.String(), nodes)
+	if err := r.checkOpen(ctx); err != nil {
+		for i := range readiness.Nodes {
+			readiness.Nodes[i].Healthy = false
+			readiness.Nodes[i].Error = err.Error()
+		}
+		readiness.LoseWrites("%v", err)
+	}
+	return readiness
+}
@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// unreachableAddr 沒有服務監聽的位址，連線會立即被拒絕
+const unreachableAddr = "127.0.0.1:1"
+
+func TestRedisInMemory_Readiness(t *testing.T) {
+	conn, err := NewRedisInMemory("memory:master", []string{"memory:replica-1", "memory:replica-2"}, 0)
+	if err != nil {
+		t.Fatalf("NewRedisInMemory() error = %v", err)
+	}
+
+	readiness := conn.Readiness(context.Background())
+	if !readiness.Ready || !readiness.Writable || !readiness.Quorum {
+		t.Fatalf("Expected ready, got %+v", readiness)
+	}
+	if len(readiness.Nodes) != 3 || readiness.Nodes[0].Role != redislib.RoleMaster {
+		t.Fatalf("Expected master and 2 replicas, got %+v", readiness.Nodes)
+	}
+
+	conn.Close()
+	readiness = conn.Readiness(context.Background())
+	if readiness.Ready || readiness.Writable {
+		t.Fatalf("Expected not ready after close, got %+v", readiness)
+	}
+	if readiness.HealthyCount() != 0 || len(readiness.Reasons) == 0 {
+		t.Errorf("Expected all nodes unhealthy with a reason, got %+v", readiness)
+	}
+}
+
+func TestRedisMasterSlave_ReadinessMasterDown(t *testing.T) {
+	master := goredis.NewClient(&goredis.Options{Addr: unreachableAddr, MaxRetries: -1})
+	defer master.Close()
+	r := &RedisMasterSlave{master: master, masterEndpoint: unreachableAddr}
+
+	readiness := r.Readiness(context.Background())
+	if readiness.Ready || readiness.Writable {
+		t.Fatalf("Expected master down to lose writes, got %+v", readiness)
+	}
+	if !readiness.Quorum {
+		t.Error("Master/Slave has no quorum and must not lose it")
+	}
+	if node := readiness.Nodes[0]; node.Healthy || node.Error == "" || node.Role != redislib.RoleMaster {
+		t.Errorf("Expected unhealthy master with error, got %+v", node)
+	}
+}
+
+func TestRedisSentinel_ReadinessReplicaProbe(t *testing.T) {
+	rs := &RedisSentinel{
+		client:       goredis.NewClient(&goredis.Options{Addr: unreachableAddr, MaxRetries: -1}),
+		masterName:   "mymaster",
+		sentinels:    []string{"127.0.0.1:3"},
+		endpoints:    newSentinelEndpoints("mymaster"),
+		clientOpts:   ClientOptions{MaxRetries: -1},
+		replicaNodes: make(map[string]*readNode),
+	}
+	defer rs.Close()
+	rs.endpoints.reset(unreachableAddr, []string{"127.0.0.1:2"}, nil)
+
+	readiness := rs.Readiness(context.Background())
+	if readiness.Ready || readiness.Writable || readiness.Quorum {
+		t.Fatalf("Expected unreachable master and sentinel to lose writes and quorum, got %+v", readiness)
+	}
+	if node := readiness.Nodes[1]; node.Role != redislib.RoleReplica || node.Healthy || node.Error == "" {
+		t.Errorf("Expected unhealthy replica with error, got %+v", node)
+	}
+	// replica_reads 未啟用時探測不會留下 Replica 的連線池
+	if len(rs.replicaNodes) != 0 {
+		t.Errorf("Expected no pooled replica clients after Readiness, got %d", len(rs.replicaNodes))
+	}
+}
+
+func TestRedisRaft_ReadinessNoMajority(t *testing.T) {
+	nodes := []string{unreachableAddr, "127.0.0.1:2", "127.0.0.1:3"}
+	r := &RedisRaft{nodes: nodes, clients: make(map[string]*goredis.Client), clientOpts: ClientOptions{MaxRetries: -1}}
+	defer r.Close()
+
+	readiness := r.Readiness(context.Background())
+	if readiness.Ready || readiness.Writable || readiness.Quorum {
+		t.Fatalf("Expected raft without reachable nodes to lose writes and quorum, got %+v", readiness)
+	}
+	if len(readiness.Nodes) != len(nodes) {
+		t.Errorf("Expected %d nodes, got %d", len(nodes), len(readiness.Nodes))
+	}
+}
+
+func TestRaftRole(t *testing.T) {
+	tests := []struct {
+		role string
+		want redislib.NodeRole
+	}{
+		{"leader", redislib.RoleLeader},
+		{"follower", redislib.RoleFollower},
+		{"candidate", redislib.RoleCandidate},
+		{"", redislib.RoleUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := raftRole(tt.role); got != tt.want {
+			t.Errorf("raftRole(%q) = %s, want %s", tt.role, got, tt.want)
+		}
+	}
+}
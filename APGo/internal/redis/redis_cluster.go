@@ -3,6 +3,9 @@ package redis
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
 	goredis "github.com/redis/go-redis/v9"
@@ -14,16 +17,25 @@ type RedisCluster struct {
 	nodes  []string
 }
 
+// ClusterOptions Cluster 模式的進階選項
+type ClusterOptions struct {
+	// Client 各節點連線的連線池、逾時、重試與驗證設定
+	Client ClientOptions
+}
+
 // NewRedisCluster 建立新的 Cluster 模式 Redis 連線
 func NewRedisCluster(nodes []string) (*RedisCluster, error) {
+	return NewRedisClusterWithOptions(nodes, ClusterOptions{})
+}
+
+// NewRedisClusterWithOptions 使用進階選項建立 Cluster 模式 Redis 連線
+func NewRedisClusterWithOptions(nodes []string, opts ClusterOptions) (*RedisCluster, error) {
 	if len(nodes) == 0 {
 		return nil, fmt.Errorf("at least one cluster node is required")
 	}
 
 	// 使用 Cluster 客戶端
-	client := goredis.NewClusterClient(&goredis.ClusterOptions{
-		Addrs: nodes,
-	})
+	client := goredis.NewClusterClient(opts.Client.clusterOptions(nodes))
 
 	// 測試連線
 	ctx := context.Background()
@@ -51,13 +63,195 @@ func (r *RedisCluster) ReadAsync(ctx context.Context, key string) (string, error
 	return val, nil
 }
 
+// ReadWithOptionsAsync 讀取資料並回報負責該 Key 的 Master 節點
+func (r *RedisCluster) ReadWithOptionsAsync(ctx context.Context, key string, opts redislib.ReadOptions) (redislib.ReadResult, error) {
+	val, err := r.ReadAsync(ctx, key)
+	// Cluster 的讀取都由負責該 Slot 的 Master 處理，沒有複寫落差
+	return redislib.ReadResult{Value: val, Node: r.NodeForKey(ctx, key)}, err
+}
+
+// NodeForKey 取得負責該 Key 的 Master 端點（依快取的 slot 對應），無法取得時返回代表端點
+func (r *RedisCluster) NodeForKey(ctx context.Context, key string) string {
+	master, err := r.client.MasterForKey(ctx, key)
+	if err != nil {
+		return r.GetMasterEndpoint()
+	}
+	return master.Options().Addr
+}
+
 // WriteAsync 寫入資料到 Cluster
 func (r *RedisCluster) WriteAsync(ctx context.Context, key string, value string) (bool, error) {
-	err := r.client.Set(ctx, key, value, 0).Err()
+	return r.WriteWithTTLAsync(ctx, key, value, 0)
+}
+
+// WriteWithTTLAsync 寫入資料到 Cluster 並設定過期時間
+func (r *RedisCluster) WriteWithTTLAsync(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	return setWithTTL(ctx, r.client, key, value, ttl)
+}
+
+// ConsistencyTokenAsync 讀取由負責該 Slot 的 Master 處理，一定讀得到自己的寫入，不需要一致性 Token
+func (r *RedisCluster) ConsistencyTokenAsync(ctx context.Context) (redislib.ConsistencyToken, error) {
+	return 0, nil
+}
+
+// GetTTLAsync 取得 Key 的剩餘存活時間
+func (r *RedisCluster) GetTTLAsync(ctx context.Context, key string) (time.Duration, error) {
+	return getTTL(ctx, r.client, key)
+}
+
+// ExpireAsync 變更 Key 的過期時間
+func (r *RedisCluster) ExpireAsync(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return expire(ctx, r.client, key, ttl)
+}
+
+// PersistAsync 移除 Key 的過期時間
+func (r *RedisCluster) PersistAsync(ctx context.Context, key string) (bool, error) {
+	return persist(ctx, r.client, key)
+}
+
+// DeleteAsync 刪除單一 Key
+func (r *RedisCluster) DeleteAsync(ctx context.Context, key string) (bool, error) {
+	n, err := deleteKeys(ctx, r.client, key)
+	return n > 0, err
+}
+
+// DeleteManyAsync 刪除多個 Key
+// DEL 的所有 Key 必須位於同一個 hash slot，否則會回傳 CROSSSLOT 錯誤，
+// 因此先依 hash slot 分組，再以 Pipeline 送到各自的節點
+func (r *RedisCluster) DeleteManyAsync(ctx context.Context, keys []string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	groups := groupKeysBySlot(keys)
+	cmds := make([]*goredis.IntCmd, 0, len(groups))
+	_, err := r.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for _, group := range groups {
+			cmds = append(cmds, pipe.Del(ctx, group...))
+		}
+		return nil
+	})
+
+	// 即使部分 slot 失敗，仍回傳已成功刪除的數量
+	var deleted int64
+	for _, cmd := range cmds {
+		deleted += cmd.Val()
+	}
 	if err != nil {
-		return false, fmt.Errorf("%w: %v", redislib.ErrWriteFailed, err)
+		return deleted, fmt.Errorf("%w: %v", redislib.ErrWriteFailed, err)
 	}
-	return true, nil
+	return deleted, nil
+}
+
+// ExistsAsync 檢查 Key 是否存在
+func (r *RedisCluster) ExistsAsync(ctx context.Context, key string) (bool, error) {
+	return exists(ctx, r.client, key)
+}
+
+// BatchReadAsync 批次讀取多個 Key
+// 先依 hash slot 分組（每組一個 MGET），再依負責的 Master 節點平行送出
+func (r *RedisCluster) BatchReadAsync(ctx context.Context, keys []string) ([]redislib.BatchResult, error) {
+	results := make([]redislib.BatchResult, len(keys))
+	index := indexKeys(keys)
+
+	r.runSlotGroupsByNode(ctx, keys, results, index, func(ctx context.Context, node string, groups [][]string) {
+		cmds := make([]*goredis.SliceCmd, len(groups))
+		_, _ = r.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+			for i, group := range groups {
+				cmds[i] = pipe.MGet(ctx, group...)
+			}
+			return nil
+		})
+
+		for i, group := range groups {
+			vals, err := cmds[i].Result()
+			for j, key := range group {
+				for _, idx := range index[key] {
+					result := redislib.BatchResult{Key: key, Node: node}
+					switch {
+					case err != nil:
+						result.Err = fmt.Errorf("%w: %v", redislib.ErrReadFailed, err)
+					case vals[j] != nil:
+						result.Value = fmt.Sprint(vals[j])
+						result.Found = true
+					}
+					results[idx] = result
+				}
+			}
+		}
+	})
+
+	return results, nil
+}
+
+// BatchWriteAsync 批次寫入多筆資料
+// 先依 hash slot 分組，再依負責的 Master 節點平行以 Pipeline 寫入
+// 同一節點的資料在一個 Pipeline 中送出，各 Key 的結果獨立回報
+func (r *RedisCluster) BatchWriteAsync(ctx context.Context, entries []redislib.KeyValue) ([]redislib.BatchResult, error) {
+	results := make([]redislib.BatchResult, len(entries))
+	keys := make([]string, len(entries))
+	for i, entry := range entries {
+		keys[i] = entry.Key
+	}
+	index := indexKeys(keys)
+
+	r.runSlotGroupsByNode(ctx, keys, results, index, func(ctx context.Context, node string, groups [][]string) {
+		// 同一個 Key 重複出現時依輸入順序寫入，最後一筆生效
+		var batch []redislib.KeyValue
+		var batchIdx []int
+		for _, group := range groups {
+			for _, key := range group {
+				for _, idx := range index[key] {
+					batch = append(batch, entries[idx])
+					batchIdx = append(batchIdx, idx)
+				}
+			}
+		}
+
+		for i, result := range pipelineSet(ctx, r.client, node, batch) {
+			results[batchIdx[i]] = result
+		}
+	})
+
+	return results, nil
+}
+
+// runSlotGroupsByNode 依 hash slot 分組後，再依 Master 節點歸類並平行執行 fn
+// 無法取得節點的 Key 會直接在 results 中記錄錯誤
+func (r *RedisCluster) runSlotGroupsByNode(
+	ctx context.Context,
+	keys []string,
+	results []redislib.BatchResult,
+	index map[string][]int,
+	fn func(ctx context.Context, node string, groups [][]string),
+) {
+	byNode := make(map[string][][]string)
+	for _, group := range groupKeysBySlot(uniqueKeys(keys)) {
+		master, err := r.client.MasterForKey(ctx, group[0])
+		if err != nil {
+			for _, key := range group {
+				for _, idx := range index[key] {
+					results[idx] = redislib.BatchResult{
+						Key: key,
+						Err: fmt.Errorf("%w: %v", redislib.ErrConnectionFailed, err),
+					}
+				}
+			}
+			continue
+		}
+		node := master.Options().Addr
+		byNode[node] = append(byNode[node], group)
+	}
+
+	var wg sync.WaitGroup
+	for node, groups := range byNode {
+		wg.Add(1)
+		go func(node string, groups [][]string) {
+			defer wg.Done()
+			fn(ctx, node, groups)
+		}(node, groups)
+	}
+	wg.Wait()
 }
 
 // GetRandomCache 讀取資料（Cluster 會自動路由到正確節點）
@@ -65,6 +259,49 @@ func (r *RedisCluster) GetRandomCache(ctx context.Context, key string) (string,
 	return r.ReadAsync(ctx, key)
 }
 
+// Topology 以 CLUSTER INFO 與 CLUSTER NODES 建立拓樸，Replica 的複寫來源為其 Master 的位址
+func (r *RedisCluster) Topology(ctx context.Context) (redislib.Topology, error) {
+	info, err := r.GetClusterInfo(ctx)
+	if err != nil {
+		return redislib.Topology{}, err
+	}
+	nodes, err := r.GetClusterNodes(ctx)
+	if err != nil {
+		return redislib.Topology{}, err
+	}
+
+	addrByID := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		addrByID[node.ID] = node.Addr
+	}
+
+	topology := redislib.Topology{
+		Mode:    redislib.RedisCluster.String(),
+		Nodes:   make([]redislib.TopologyNode, 0, len(nodes)),
+		Cluster: &info,
+	}
+	for _, node := range nodes {
+		role := redislib.RoleMaster
+		if node.Role == "replica" {
+			role = redislib.RoleReplica
+		}
+		topologyNode := redislib.TopologyNode{
+			Address:   node.Addr,
+			ID:        node.ID,
+			Role:      role,
+			Healthy:   !node.Failing && node.LinkState == "connected",
+			Parent:    addrByID[node.MasterID],
+			Slots:     node.Slots,
+			LinkState: node.LinkState,
+		}
+		if node.Failing {
+			topologyNode.Error = fmt.Sprintf("flagged as %s", strings.Join(node.Flags, ","))
+		}
+		topology.Nodes = append(topology.Nodes, topologyNode)
+	}
+	return topology, nil
+}
+
 // GetMasterEndpoint 取得 Master 端點（Cluster 模式返回節點列表）
 func (r *RedisCluster) GetMasterEndpoint() string {
 	if len(r.nodes) == 0 {
@@ -84,34 +321,114 @@ func (r *RedisCluster) Close() error {
 	return r.client.Close()
 }
 
-// FillCluster 填充測試資料到 Cluster（用於測試 hash slot 分配）
-func (r *RedisCluster) FillCluster(ctx context.Context, count int) error {
-	for i := 0; i < count; i++ {
-		key := fmt.Sprintf("cluster:test:key:%d", i)
-		value := fmt.Sprintf("value-%d", i)
+// groupKeysBySlot 依 hash slot 將 Key 分組，保留各組內的原始順序
+func groupKeysBySlot(keys []string) map[int][]string {
+	groups := make(map[int][]string)
+	for _, key := range keys {
+		slot := redislib.KeySlot(key)
+		groups[slot] = append(groups[slot], key)
+	}
+	return groups
+}
+
+// uniqueKeys 去除重複的 Key，保留第一次出現的順序
+func uniqueKeys(keys []string) []string {
+	seen := make(map[string]bool, len(keys))
+	unique := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, key)
+		}
+	}
+	return unique
+}
+
+// indexKeys 記錄每個 Key 在輸入中出現的位置
+func indexKeys(keys []string) map[string][]int {
+	index := make(map[string][]int, len(keys))
+	for i, key := range keys {
+		index[key] = append(index[key], i)
+	}
+	return index
+}
+
+// FillCluster 填充測試資料到 Cluster（用於測試 hash slot 分配），並回報各 Master 分到的 Key 數量與寫入失敗的 Key
+// （opts.IncludeKeys 時列出每個 Key 的 slot 與負責的 Master）
+// Key 依負責的 Master 分成 chunk，以最多 opts.Concurrency 個 Pipeline 平行寫入；
+// progress 不為 nil 時定期回報進度。ctx 取消時停止寫入並返回目前為止的報告，沒有送出的 Key 計入 Skipped
+func (r *RedisCluster) FillCluster(ctx context.Context, opts redislib.FillOptions, progress func(redislib.FillProgress)) (redislib.FillReport, error) {
+	opts = opts.WithDefaults()
+	if err := opts.Validate(); err != nil {
+		return redislib.FillReport{}, err
+	}
 
-		if err := r.client.Set(ctx, key, value, 0).Err(); err != nil {
-			return fmt.Errorf("failed to fill cluster at key %s: %w", key, err)
+	nodes, err := r.GetClusterNodes(ctx)
+	if err != nil {
+		return redislib.FillReport{}, err
+	}
+	keys := make([]string, opts.Count)
+	for i := range keys {
+		keys[i] = opts.Key(i)
+	}
+	report, placements := redislib.NewFillReport(opts, keys, nodes)
+
+	run := newFillRun(len(keys), progress)
+	// 落在未分配 slot 的 Key 無法寫入，直接視為失敗
+	for i, key := range placements {
+		if key.Node == "" {
+			placements[i].Error = fmt.Sprintf("%v: slot %d is not assigned to any master", redislib.ErrWriteFailed, key.Slot)
+		}
+	}
+	run.add(0, report.Unassigned)
+
+	skipped := run.run(ctx, planFillChunks(placements, opts.BatchSize), opts.Concurrency, func(ctx context.Context, chunk fillChunk) int {
+		entries := make([]redislib.KeyValue, len(chunk.indexes))
+		for j, i := range chunk.indexes {
+			entries[j] = redislib.KeyValue{Key: keys[i], Value: opts.Value(i)}
 		}
+
+		failed := 0
+		for j, result := range pipelineSet(ctx, r.client, chunk.node, entries) {
+			if result.Err != nil {
+				placements[chunk.indexes[j]].Error = result.Err.Error()
+				failed++
+			}
+		}
+		return failed
+	})
+	for _, chunk := range skipped {
+		for _, i := range chunk.indexes {
+			placements[i].Skipped = true
+		}
+	}
+
+	report.Complete(placements, run.finish())
+	if err := ctx.Err(); err != nil {
+		return report, fmt.Errorf("%w: %w", redislib.ErrWriteFailed, err)
 	}
-	return nil
+	return report, nil
 }
 
 // GetClusterInfo 取得 Cluster 資訊
-func (r *RedisCluster) GetClusterInfo(ctx context.Context) (string, error) {
+func (r *RedisCluster) GetClusterInfo(ctx context.Context) (redislib.ClusterInfo, error) {
+	ctx, span := startInfoSpan(ctx, "CLUSTER INFO", redislib.RedisCluster.String(), "")
 	// 從第一個節點取得 cluster info
 	result, err := r.client.ClusterInfo(ctx).Result()
+	endInfoSpan(span, err)
 	if err != nil {
-		return "", fmt.Errorf("failed to get cluster info: %w", err)
+		return redislib.ClusterInfo{}, fmt.Errorf("failed to get cluster info: %w", err)
 	}
-	return result, nil
+	return redislib.ParseClusterInfo(result)
 }
 
 // GetClusterNodes 取得 Cluster 節點資訊
-func (r *RedisCluster) GetClusterNodes(ctx context.Context) (string, error) {
+func (r *RedisCluster) GetClusterNodes(ctx context.Context) ([]redislib.ClusterNode, error) {
+	ctx, span := startInfoSpan(ctx, "CLUSTER NODES", redislib.RedisCluster.String(), "")
 	result, err := r.client.ClusterNodes(ctx).Result()
+	endInfoSpan(span, err)
 	if err != nil {
-		return "", fmt.Errorf("failed to get cluster nodes: %w", err)
+		return nil, fmt.Errorf("failed to get cluster nodes: %w", err)
 	}
-	return result, nil
+	return redislib.ParseClusterNodes(result)
 }
@@ -41,7 +41,10 @@ func TestRedisCluster(t *testing.T) {
 	if err != nil {
 		t.Fatalf("GetClusterInfo failed: %v", err)
 	}
-	t.Logf("Cluster Info: %s", clusterInfo)
+	if clusterInfo.State != "ok" {
+		t.Errorf("Expected cluster state ok, got %s", clusterInfo.State)
+	}
+	t.Logf("Cluster Info: %+v", clusterInfo)
 
 	// 測試寫入
 	key := "test:cluster:key"
@@ -73,11 +76,39 @@ func TestRedisCluster(t *testing.T) {
 		t.Errorf("Expected %s, got %s", value, result)
 	}
 
+	// 測試跨 slot 的批次寫入與讀取
+	entries := []redislib.KeyValue{
+		{Key: "test:cluster:batch:a", Value: "a"},
+		{Key: "test:cluster:batch:b", Value: "b"},
+		{Key: "{test:cluster}:batch:c", Value: "c"},
+	}
+	writeResults, err := rc.BatchWriteAsync(ctx, entries)
+	if err != nil {
+		t.Fatalf("BatchWriteAsync failed: %v", err)
+	}
+	if failed := redislib.CountFailed(writeResults); failed != 0 {
+		t.Fatalf("BatchWriteAsync had %d failures", failed)
+	}
+
+	readResults, err := rc.BatchReadAsync(ctx, []string{"test:cluster:batch:a", "test:cluster:batch:b", "{test:cluster}:batch:c"})
+	if err != nil {
+		t.Fatalf("BatchReadAsync failed: %v", err)
+	}
+	for i, result := range readResults {
+		if !result.Found || result.Value != entries[i].Value {
+			t.Errorf("Batch key %s: expected %s, got %+v", entries[i].Key, entries[i].Value, result)
+		}
+	}
+
 	// 測試 FillCluster
-	if err := rc.FillCluster(ctx, 10); err != nil {
+	report, err := rc.FillCluster(ctx, redislib.FillOptions{Count: 10}, nil)
+	if err != nil {
 		t.Fatalf("FillCluster failed: %v", err)
 	}
-	t.Log("FillCluster succeeded")
+	if report.Count != 10 || report.Unassigned != 0 || report.Failed != 0 {
+		t.Errorf("Unexpected fill report: %+v", report)
+	}
+	t.Logf("FillCluster succeeded, skew %.2f across %d masters", report.Skew, len(report.Nodes))
 
 	// 驗證填充的資料
 	for i := 0; i < 10; i++ {
@@ -108,6 +139,31 @@ func TestRedisCluster(t *testing.T) {
 	t.Logf("Slave endpoint: %s", slaveEndpoint)
 }
 
+func TestGroupKeysBySlot(t *testing.T) {
+	keys := []string{"{user:1}:a", "foo", "{user:1}:b", "bar", "{user:1}:c"}
+	groups := groupKeysBySlot(keys)
+
+	// 相同 hash tag 的 Key 會被分在同一組，並保留原始順序
+	tagged := groups[redislib.KeySlot("user:1")]
+	want := []string{"{user:1}:a", "{user:1}:b", "{user:1}:c"}
+	if fmt.Sprint(tagged) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, tagged)
+	}
+
+	total := 0
+	for slot, group := range groups {
+		for _, key := range group {
+			if redislib.KeySlot(key) != slot {
+				t.Errorf("Key %s grouped into slot %d, want %d", key, slot, redislib.KeySlot(key))
+			}
+		}
+		total += len(group)
+	}
+	if total != len(keys) {
+		t.Errorf("Expected %d keys in groups, got %d", len(keys), total)
+	}
+}
+
 func TestNewRedisCluster_InvalidParams(t *testing.T) {
 	tests := []struct {
 		name    string
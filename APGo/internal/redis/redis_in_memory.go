@@ -0,0 +1,509 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+// RedisInMemory 實作內嵌記憶體模式的 Redis 連線
+// 不需要任何網路，模擬一個 Master 與多個 Replica，
+// 並可設定複寫延遲來重現讀寫分離下讀到舊資料的情境
+type RedisInMemory struct {
+	mu             sync.Mutex
+	master         *memoryNode
+	replicas       []*memoryNode
+	slave          *memoryNode
+	log            []memoryOp // 尚未被所有 Replica 套用的複寫日誌
+	logBase        int64      // log[0] 對應的複寫 offset
+	lag            time.Duration
+	masterEndpoint string
+	slaveEndpoint  string
+	closed         bool
+	now            func() time.Time
+	selector       readSelector
+	readNodes      map[*readNode]*memoryNode // 讀取偏好選出的節點對應到模擬節點
+	masterRead     *readNode
+	replicaReads   []*readNode
+}
+
+// memoryEntry 記憶體中的一筆資料
+type memoryEntry struct {
+	value    string
+	expireAt time.Time // 零值表示永不過期
+}
+
+// memoryNode 模擬的 Redis 節點
+type memoryNode struct {
+	endpoint string
+	data     map[string]memoryEntry
+	offset   int64 // 已套用的複寫 offset
+}
+
+// memoryOp 複寫日誌中的一筆操作
+type memoryOp struct {
+	writtenAt time.Time // 寫入 Master 的時間
+	applyAt   time.Time // Replica 最早可以套用此操作的時間
+	apply     func(data map[string]memoryEntry)
+}
+
+// NewRedisInMemory 建立新的內嵌記憶體模式 Redis 連線
+// master 與 replicas 只是端點名稱，lag 為 Replica 套用寫入前的延遲
+func NewRedisInMemory(master string, replicas []string, lag time.Duration) (*RedisInMemory, error) {
+	if master == "" {
+		master = "memory:master"
+	}
+	if lag < 0 {
+		return nil, fmt.Errorf("replication lag must not be negative, got %s", lag)
+	}
+
+	rim := &RedisInMemory{
+		master:         newMemoryNode(master),
+		replicas:       make([]*memoryNode, 0, len(replicas)),
+		lag:            lag,
+		masterEndpoint: master,
+		now:            time.Now,
+		readNodes:      make(map[*readNode]*memoryNode, len(replicas)+1),
+	}
+	rim.masterRead = newPrimaryReadNode(master, nil)
+	rim.readNodes[rim.masterRead] = rim.master
+
+	for _, replicaAddr := range replicas {
+		replica := newMemoryNode(replicaAddr)
+		rim.replicas = append(rim.replicas, replica)
+
+		read := newReadNode(replicaAddr, nil, 1)
+		rim.replicaReads = append(rim.replicaReads, read)
+		rim.readNodes[read] = replica
+
+		// 使用第一個 Replica 作為預設 Slave
+		if rim.slave == nil {
+			rim.slave = replica
+			rim.slaveEndpoint = replicaAddr
+		}
+	}
+
+	// 如果沒有 Replica，使用 Master 作為備用
+	if rim.slave == nil {
+		rim.slave = rim.master
+		rim.slaveEndpoint = master
+	}
+
+	return rim, nil
+}
+
+// newMemoryNode 建立空的模擬節點
+func newMemoryNode(endpoint string) *memoryNode {
+	return &memoryNode{
+		endpoint: endpoint,
+		data:     make(map[string]memoryEntry),
+	}
+}
+
+// ReadAsync 從預設 Replica 讀取資料（可能因複寫延遲讀到舊資料）
+func (r *RedisInMemory) ReadAsync(ctx context.Context, key string) (string, error) {
+	result, err := r.ReadWithOptionsAsync(ctx, key, redislib.ReadOptions{})
+	return result.Value, err
+}
+
+// ReadWithOptionsAsync 依讀取偏好與複寫落差上限選擇模擬節點讀取資料（預設 replica_preferred）
+func (r *RedisInMemory) ReadWithOptionsAsync(ctx context.Context, key string, opts redislib.ReadOptions) (redislib.ReadResult, error) {
+	pref, err := redislib.ParseReadPreference(string(opts.Preference))
+	if err != nil {
+		return redislib.ReadResult{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkOpen(ctx); err != nil {
+		return redislib.ReadResult{}, fmt.Errorf("%w: %v", redislib.ErrReadFailed, err)
+	}
+
+	// 模擬節點的複寫落差可以精確計算，讀取前先更新
+	for _, read := range r.replicaReads {
+		r.updateLag(read, r.readNodes[read])
+	}
+
+	// 模擬節點不會連線失敗，直接使用排序後的第一個節點
+	nodes, err := filterStale(r.selector.order(pref, r.masterRead, r.replicaReads), r.masterRead, opts)
+	if err != nil {
+		return redislib.ReadResult{}, err
+	}
+	nodes = filterCaughtUp(ctx, nodes, r.masterRead, opts.After)
+	if len(nodes) == 0 {
+		return redislib.ReadResult{}, fmt.Errorf("%w: no node available for read", redislib.ErrReadFailed)
+	}
+	val, err := r.get(r.readNodes[nodes[0]], key)
+	redislib.RecordServedBy(ctx, nodes[0].endpoint)
+	return nodes[0].readResult(val), err
+}
+
+// updateLag 套用已到期的操作後，以尚未套用的日誌計算 Replica 的複寫落差（呼叫前須持有鎖）
+// 模擬節點的 offset 以操作筆數計算，時間落差為最早一筆未套用操作的寫入時間至今
+func (r *RedisInMemory) updateLag(read *readNode, node *memoryNode) {
+	r.sync(node)
+	read.offset.Store(node.offset)
+
+	pending := r.master.offset - node.offset
+	if pending <= 0 {
+		read.setLag(0, 0)
+		return
+	}
+	oldest := r.log[node.offset-r.logBase]
+	read.setLag(pending, r.now().Sub(oldest.writtenAt))
+}
+
+// WriteAsync 寫入資料到 Master
+func (r *RedisInMemory) WriteAsync(ctx context.Context, key string, value string) (bool, error) {
+	return r.WriteWithTTLAsync(ctx, key, value, 0)
+}
+
+// WriteWithTTLAsync 寫入資料到 Master 並設定過期時間
+func (r *RedisInMemory) WriteWithTTLAsync(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkOpen(ctx); err != nil {
+		return false, fmt.Errorf("%w: %v", redislib.ErrWriteFailed, err)
+	}
+
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expireAt = r.now().Add(ttl)
+	}
+	r.replicate(func(data map[string]memoryEntry) {
+		data[key] = entry
+	})
+	return true, nil
+}
+
+// ConsistencyTokenAsync 取得 Master 目前的複寫 offset 作為一致性 Token
+func (r *RedisInMemory) ConsistencyTokenAsync(ctx context.Context) (redislib.ConsistencyToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkOpen(ctx); err != nil {
+		return 0, fmt.Errorf("%w: %v", redislib.ErrReadFailed, err)
+	}
+	return redislib.ConsistencyToken(r.master.offset), nil
+}
+
+// GetTTLAsync 取得 Key 的剩餘存活時間
+func (r *RedisInMemory) GetTTLAsync(ctx context.Context, key string) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkOpen(ctx); err != nil {
+		return 0, fmt.Errorf("%w: %v", redislib.ErrReadFailed, err)
+	}
+
+	entry, ok := r.lookup(r.master, key)
+	if !ok {
+		return 0, redislib.ErrKeyNotFound
+	}
+	if entry.expireAt.IsZero() {
+		return redislib.NoTTL, nil
+	}
+	return entry.expireAt.Sub(r.now()), nil
+}
+
+// ExpireAsync 變更 Key 的過期時間
+func (r *RedisInMemory) ExpireAsync(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		return false, fmt.Errorf("%w: ttl must be positive, got %s", redislib.ErrInvalidTTL, ttl)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkOpen(ctx); err != nil {
+		return false, fmt.Errorf("%w: %v", redislib.ErrWriteFailed, err)
+	}
+	if _, ok := r.lookup(r.master, key); !ok {
+		return false, redislib.ErrKeyNotFound
+	}
+
+	expireAt := r.now().Add(ttl)
+	r.replicate(func(data map[string]memoryEntry) {
+		if entry, ok := data[key]; ok {
+			entry.expireAt = expireAt
+			data[key] = entry
+		}
+	})
+	return true, nil
+}
+
+// PersistAsync 移除 Key 的過期時間
+func (r *RedisInMemory) PersistAsync(ctx context.Context, key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkOpen(ctx); err != nil {
+		return false, fmt.Errorf("%w: %v", redislib.ErrWriteFailed, err)
+	}
+	entry, ok := r.lookup(r.master, key)
+	if !ok {
+		return false, redislib.ErrKeyNotFound
+	}
+	if entry.expireAt.IsZero() {
+		return false, nil
+	}
+
+	r.replicate(func(data map[string]memoryEntry) {
+		if entry, ok := data[key]; ok {
+			entry.expireAt = time.Time{}
+			data[key] = entry
+		}
+	})
+	return true, nil
+}
+
+// DeleteAsync 從 Master 刪除單一 Key
+func (r *RedisInMemory) DeleteAsync(ctx context.Context, key string) (bool, error) {
+	n, err := r.DeleteManyAsync(ctx, []string{key})
+	return n > 0, err
+}
+
+// DeleteManyAsync 從 Master 刪除多個 Key
+func (r *RedisInMemory) DeleteManyAsync(ctx context.Context, keys []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkOpen(ctx); err != nil {
+		return 0, fmt.Errorf("%w: %v", redislib.ErrWriteFailed, err)
+	}
+
+	existing := make([]string, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if _, ok := r.lookup(r.master, key); ok && !seen[key] {
+			existing = append(existing, key)
+			seen[key] = true
+		}
+	}
+	if len(existing) == 0 {
+		return 0, nil
+	}
+
+	r.replicate(func(data map[string]memoryEntry) {
+		for _, key := range existing {
+			delete(data, key)
+		}
+	})
+	return int64(len(existing)), nil
+}
+
+// ExistsAsync 從預設 Replica 檢查 Key 是否存在
+func (r *RedisInMemory) ExistsAsync(ctx context.Context, key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkOpen(ctx); err != nil {
+		return false, fmt.Errorf("%w: %v", redislib.ErrReadFailed, err)
+	}
+	_, ok := r.lookup(r.slave, key)
+	return ok, nil
+}
+
+// BatchReadAsync 從預設 Replica 批次讀取
+func (r *RedisInMemory) BatchReadAsync(ctx context.Context, keys []string) ([]redislib.BatchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkOpen(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %v", redislib.ErrReadFailed, err)
+	}
+
+	results := make([]redislib.BatchResult, len(keys))
+	for i, key := range keys {
+		results[i] = redislib.BatchResult{Key: key, Node: r.slave.endpoint}
+		if entry, ok := r.lookup(r.slave, key); ok {
+			results[i].Value = entry.value
+			results[i].Found = true
+		}
+	}
+	return results, nil
+}
+
+// BatchWriteAsync 批次寫入到 Master
+func (r *RedisInMemory) BatchWriteAsync(ctx context.Context, entries []redislib.KeyValue) ([]redislib.BatchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkOpen(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %v", redislib.ErrWriteFailed, err)
+	}
+
+	now := r.now()
+	results := make([]redislib.BatchResult, len(entries))
+	for i, kv := range entries {
+		entry := memoryEntry{value: kv.Value}
+		if kv.TTL > 0 {
+			entry.expireAt = now.Add(kv.TTL)
+		}
+		key := kv.Key
+		r.replicate(func(data map[string]memoryEntry) {
+			data[key] = entry
+		})
+		results[i] = redislib.BatchResult{Key: key, Node: r.master.endpoint}
+	}
+	return results, nil
+}
+
+// GetRandomCache 隨機從一個 Replica 讀取資料
+func (r *RedisInMemory) GetRandomCache(ctx context.Context, key string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkOpen(ctx); err != nil {
+		return "", fmt.Errorf("%w: %v", redislib.ErrReadFailed, err)
+	}
+	if len(r.replicas) == 0 {
+		redislib.RecordServedBy(ctx, r.master.endpoint)
+		return r.get(r.master, key)
+	}
+
+	for _, idx := range rand.Perm(len(r.replicas)) {
+		redislib.RecordServedBy(ctx, r.replicas[idx].endpoint)
+		if val, err := r.get(r.replicas[idx], key); err == nil {
+			return val, nil
+		}
+	}
+	return "", redislib.ErrKeyNotFound
+}
+
+// Topology 取得模擬的 Master 與 Replica（模擬節點永遠健康）
+func (r *RedisInMemory) Topology(ctx context.Context) (redislib.Topology, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkOpen(ctx); err != nil {
+		return redislib.Topology{}, fmt.Errorf("%w: %v", redislib.ErrReadFailed, err)
+	}
+
+	topology := redislib.Topology{Mode: redislib.RedisInMemory.String()}
+	topology.Nodes = append(topology.Nodes, redislib.TopologyNode{
+		Address: r.master.endpoint,
+		Role:    redislib.RoleMaster,
+		Healthy: true,
+	})
+	for _, replica := range r.replicas {
+		topology.Nodes = append(topology.Nodes, redislib.TopologyNode{
+			Address: replica.endpoint,
+			Role:    redislib.RoleReplica,
+			Healthy: true,
+			Parent:  r.master.endpoint,
+		})
+	}
+	return topology, nil
+}
+
+// GetMasterEndpoint 取得 Master 端點
+func (r *RedisInMemory) GetMasterEndpoint() string {
+	return r.masterEndpoint
+}
+
+// GetSlaveEndpoint 取得 Slave 端點
+func (r *RedisInMemory) GetSlaveEndpoint() string {
+	return r.slaveEndpoint
+}
+
+// Close 關閉連線並釋放所有資料
+func (r *RedisInMemory) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	r.log = nil
+	r.master.data = nil
+	for _, replica := range r.replicas {
+		replica.data = nil
+	}
+	return nil
+}
+
+// checkOpen 確認連線未關閉且 context 仍有效（呼叫前須持有鎖）
+func (r *RedisInMemory) checkOpen(ctx context.Context) error {
+	if r.closed {
+		return redislib.ErrConnectionFailed
+	}
+	return ctx.Err()
+}
+
+// replicate 將操作套用到 Master，並記錄到複寫日誌等待 Replica 套用（呼叫前須持有鎖）
+func (r *RedisInMemory) replicate(apply func(data map[string]memoryEntry)) {
+	apply(r.master.data)
+	r.master.offset++
+
+	if len(r.replicas) == 0 {
+		r.logBase = r.master.offset
+		return
+	}
+	now := r.now()
+	r.log = append(r.log, memoryOp{
+		writtenAt: now,
+		applyAt:   now.Add(r.lag),
+		apply:     apply,
+	})
+}
+
+// sync 讓 Replica 套用已超過複寫延遲的操作（呼叫前須持有鎖）
+func (r *RedisInMemory) sync(node *memoryNode) {
+	if node == r.master {
+		return
+	}
+
+	now := r.now()
+	for node.offset < r.logBase+int64(len(r.log)) {
+		op := r.log[node.offset-r.logBase]
+		if op.applyAt.After(now) {
+			break
+		}
+		op.apply(node.data)
+		node.offset++
+	}
+
+	r.compact()
+}
+
+// compact 移除所有 Replica 都已套用的日誌（呼叫前須持有鎖）
+func (r *RedisInMemory) compact() {
+	minOffset := r.master.offset
+	for _, replica := range r.replicas {
+		if replica.offset < minOffset {
+			minOffset = replica.offset
+		}
+	}
+	if applied := minOffset - r.logBase; applied > 0 {
+		r.log = r.log[applied:]
+		r.logBase = minOffset
+	}
+}
+
+// lookup 從指定節點查找未過期的資料（呼叫前須持有鎖）
+func (r *RedisInMemory) lookup(node *memoryNode, key string) (memoryEntry, bool) {
+	r.sync(node)
+
+	entry, ok := node.data[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if !entry.expireAt.IsZero() && !r.now().Before(entry.expireAt) {
+		return memoryEntry{}, false
+	}
+	return entry, true
+}
+
+// get 從指定節點讀取值（呼叫前須持有鎖）
+func (r *RedisInMemory) get(node *memoryNode, key string) (string, error) {
+	entry, ok := r.lookup(node, key)
+	if !ok {
+		return "", redislib.ErrKeyNotFound
+	}
+	return entry.value, nil
+}
@@ -0,0 +1,359 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+// 驗證 RedisInMemory 實作了 IRedisConn 介面
+func TestRedisInMemoryImplementsInterface(t *testing.T) {
+	var _ redislib.IRedisConn = (*RedisInMemory)(nil)
+}
+
+// fakeClock 可手動推進的時鐘
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time          { return c.t }
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestInMemory(t *testing.T, replicas []string, lag time.Duration) (*RedisInMemory, *fakeClock) {
+	t.Helper()
+	rim, err := NewRedisInMemory("memory:master", replicas, lag)
+	if err != nil {
+		t.Fatalf("Failed to create RedisInMemory: %v", err)
+	}
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	rim.now = clock.Now
+	return rim, clock
+}
+
+func TestRedisInMemory(t *testing.T) {
+	rim, _ := newTestInMemory(t, []string{"memory:replica-1", "memory:replica-2"}, 0)
+	defer rim.Close()
+
+	ctx := context.Background()
+
+	// 測試寫入
+	key := "test:in-memory:key"
+	value := "test-value"
+
+	success, err := rim.WriteAsync(ctx, key, value)
+	if err != nil {
+		t.Fatalf("WriteAsync failed: %v", err)
+	}
+	if !success {
+		t.Fatal("WriteAsync returned false")
+	}
+
+	// 測試讀取
+	result, err := rim.ReadAsync(ctx, key)
+	if err != nil {
+		t.Fatalf("ReadAsync failed: %v", err)
+	}
+	if result != value {
+		t.Errorf("Expected %s, got %s", value, result)
+	}
+
+	// 測試 GetRandomCache
+	result, err = rim.GetRandomCache(ctx, key)
+	if err != nil {
+		t.Fatalf("GetRandomCache failed: %v", err)
+	}
+	if result != value {
+		t.Errorf("Expected %s, got %s", value, result)
+	}
+
+	// 測試不存在的 Key
+	if _, err := rim.ReadAsync(ctx, "missing"); !errors.Is(err, redislib.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	// 測試端點資訊
+	if got := rim.GetMasterEndpoint(); got != "memory:master" {
+		t.Errorf("Expected master endpoint memory:master, got %s", got)
+	}
+	if got := rim.GetSlaveEndpoint(); got != "memory:replica-1" {
+		t.Errorf("Expected slave endpoint memory:replica-1, got %s", got)
+	}
+}
+
+func TestRedisInMemory_ReplicationLag(t *testing.T) {
+	rim, clock := newTestInMemory(t, []string{"memory:replica-1"}, 500*time.Millisecond)
+	defer rim.Close()
+
+	ctx := context.Background()
+	if _, err := rim.WriteAsync(ctx, "lagged", "v1"); err != nil {
+		t.Fatalf("WriteAsync failed: %v", err)
+	}
+
+	// 複寫延遲內，Replica 尚未收到資料
+	if _, err := rim.ReadAsync(ctx, "lagged"); !errors.Is(err, redislib.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound before lag elapsed, got %v", err)
+	}
+
+	clock.Advance(500 * time.Millisecond)
+
+	val, err := rim.ReadAsync(ctx, "lagged")
+	if err != nil {
+		t.Fatalf("ReadAsync after lag failed: %v", err)
+	}
+	if val != "v1" {
+		t.Errorf("Expected v1, got %s", val)
+	}
+	if len(rim.log) != 0 {
+		t.Errorf("Expected replication log to be compacted, got %d entries", len(rim.log))
+	}
+}
+
+func TestRedisInMemory_MaxStaleness(t *testing.T) {
+	rim, clock := newTestInMemory(t, []string{"memory:replica-1"}, time.Second)
+	defer rim.Close()
+
+	ctx := context.Background()
+	if _, err := rim.WriteAsync(ctx, "k", "v1"); err != nil {
+		t.Fatalf("WriteAsync failed: %v", err)
+	}
+	clock.Advance(300 * time.Millisecond)
+
+	// 未限制落差時讀取 Replica，會讀到舊資料
+	result, err := rim.ReadWithOptionsAsync(ctx, "k", redislib.ReadOptions{})
+	if !errors.Is(err, redislib.ErrKeyNotFound) {
+		t.Fatalf("Expected stale replica read, got %v", err)
+	}
+	if result.Node != "memory:replica-1" || result.LagBytes != 1 || result.Staleness != 300*time.Millisecond {
+		t.Errorf("Unexpected replica result: %+v", result)
+	}
+
+	// 落差超過上限時改由 Master 提供
+	result, err = rim.ReadWithOptionsAsync(ctx, "k", redislib.ReadOptions{MaxStaleness: 100 * time.Millisecond})
+	if err != nil || result.Value != "v1" || result.Node != "memory:master" || result.LagBytes != 0 {
+		t.Errorf("Expected fresh master read, got %+v (%v)", result, err)
+	}
+
+	// 落差在上限內則繼續使用 Replica
+	result, _ = rim.ReadWithOptionsAsync(ctx, "k", redislib.ReadOptions{MaxStaleness: time.Second, MaxLagBytes: 5})
+	if result.Node != "memory:replica-1" {
+		t.Errorf("Expected replica within bound, got %s", result.Node)
+	}
+
+	clock.Advance(time.Second)
+	result, err = rim.ReadWithOptionsAsync(ctx, "k", redislib.ReadOptions{MaxLagBytes: 1})
+	if err != nil || result.Node != "memory:replica-1" || result.LagBytes != 0 || result.Staleness != 0 {
+		t.Errorf("Expected caught-up replica read, got %+v (%v)", result, err)
+	}
+}
+
+func TestRedisInMemory_TTL(t *testing.T) {
+	rim, clock := newTestInMemory(t, []string{"memory:replica-1"}, 0)
+	defer rim.Close()
+
+	ctx := context.Background()
+	if _, err := rim.WriteWithTTLAsync(ctx, "session", "v", 10*time.Second); err != nil {
+		t.Fatalf("WriteWithTTLAsync failed: %v", err)
+	}
+
+	ttl, err := rim.GetTTLAsync(ctx, "session")
+	if err != nil {
+		t.Fatalf("GetTTLAsync failed: %v", err)
+	}
+	if ttl != 10*time.Second {
+		t.Errorf("Expected ttl 10s, got %s", ttl)
+	}
+
+	// 移除過期時間
+	if ok, err := rim.PersistAsync(ctx, "session"); err != nil || !ok {
+		t.Fatalf("PersistAsync = %v, %v", ok, err)
+	}
+	if ttl, _ := rim.GetTTLAsync(ctx, "session"); ttl != redislib.NoTTL {
+		t.Errorf("Expected NoTTL after persist, got %s", ttl)
+	}
+	// 本來就沒有過期時間時不算錯誤，Key 不存在時與 ExpireAsync 相同返回 ErrKeyNotFound
+	if ok, err := rim.PersistAsync(ctx, "session"); err != nil || ok {
+		t.Errorf("PersistAsync without ttl = %v, %v, want false, nil", ok, err)
+	}
+	if _, err := rim.PersistAsync(ctx, "missing"); !errors.Is(err, redislib.ErrKeyNotFound) {
+		t.Errorf("PersistAsync on missing key error = %v, want ErrKeyNotFound", err)
+	}
+
+	// 重新設定過期時間並讓它過期
+	if _, err := rim.ExpireAsync(ctx, "session", time.Second); err != nil {
+		t.Fatalf("ExpireAsync failed: %v", err)
+	}
+	clock.Advance(time.Second)
+
+	if _, err := rim.ReadAsync(ctx, "session"); !errors.Is(err, redislib.ErrKeyNotFound) {
+		t.Errorf("Expected expired key on replica, got %v", err)
+	}
+	if _, err := rim.GetTTLAsync(ctx, "session"); !errors.Is(err, redislib.ErrKeyNotFound) {
+		t.Errorf("Expected expired key on master, got %v", err)
+	}
+	if _, err := rim.ExpireAsync(ctx, "session", time.Second); !errors.Is(err, redislib.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound when expiring missing key, got %v", err)
+	}
+	if _, err := rim.ExpireAsync(ctx, "session", 0); !errors.Is(err, redislib.ErrInvalidTTL) {
+		t.Errorf("Expected ErrInvalidTTL, got %v", err)
+	}
+}
+
+func TestRedisInMemory_DeleteAndExists(t *testing.T) {
+	rim, clock := newTestInMemory(t, []string{"memory:replica-1"}, time.Second)
+	defer rim.Close()
+
+	ctx := context.Background()
+	for _, key := range []string{"a", "b", "c"} {
+		if _, err := rim.WriteAsync(ctx, key, "v"); err != nil {
+			t.Fatalf("WriteAsync failed: %v", err)
+		}
+	}
+	clock.Advance(time.Second)
+
+	if found, err := rim.ExistsAsync(ctx, "a"); err != nil || !found {
+		t.Fatalf("ExistsAsync(a) = %v, %v", found, err)
+	}
+
+	if ok, err := rim.DeleteAsync(ctx, "a"); err != nil || !ok {
+		t.Fatalf("DeleteAsync(a) = %v, %v", ok, err)
+	}
+	if ok, _ := rim.DeleteAsync(ctx, "a"); ok {
+		t.Error("DeleteAsync on missing key should return false")
+	}
+
+	n, err := rim.DeleteManyAsync(ctx, []string{"b", "c", "c", "missing"})
+	if err != nil {
+		t.Fatalf("DeleteManyAsync failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 keys deleted, got %d", n)
+	}
+
+	// 刪除也會經過複寫延遲
+	if found, _ := rim.ExistsAsync(ctx, "b"); !found {
+		t.Error("Expected replica to still see b before lag elapsed")
+	}
+	clock.Advance(time.Second)
+	if found, _ := rim.ExistsAsync(ctx, "b"); found {
+		t.Error("Expected b to be deleted on replica after lag")
+	}
+}
+
+func TestRedisInMemory_Batch(t *testing.T) {
+	rim, _ := newTestInMemory(t, []string{"memory:replica-1"}, 0)
+	defer rim.Close()
+
+	ctx := context.Background()
+	results, err := rim.BatchWriteAsync(ctx, []redislib.KeyValue{
+		{Key: "a", Value: "1"},
+		{Key: "b", Value: "2", TTL: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("BatchWriteAsync failed: %v", err)
+	}
+	if failed := redislib.CountFailed(results); failed != 0 {
+		t.Errorf("Expected no failures, got %d", failed)
+	}
+
+	results, err = rim.BatchReadAsync(ctx, []string{"a", "missing", "b"})
+	if err != nil {
+		t.Fatalf("BatchReadAsync failed: %v", err)
+	}
+	want := []redislib.BatchResult{
+		{Key: "a", Value: "1", Found: true, Node: "memory:replica-1"},
+		{Key: "missing", Node: "memory:replica-1"},
+		{Key: "b", Value: "2", Found: true, Node: "memory:replica-1"},
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("Result %d: expected %+v, got %+v", i, want[i], results[i])
+		}
+	}
+}
+
+func TestRedisInMemory_NoReplicas(t *testing.T) {
+	rim, _ := newTestInMemory(t, nil, time.Second)
+	defer rim.Close()
+
+	ctx := context.Background()
+	if _, err := rim.WriteAsync(ctx, "key", "value"); err != nil {
+		t.Fatalf("WriteAsync failed: %v", err)
+	}
+
+	// 沒有 Replica 時直接從 Master 讀取，不受複寫延遲影響
+	val, err := rim.ReadAsync(ctx, "key")
+	if err != nil || val != "value" {
+		t.Errorf("ReadAsync = %q, %v", val, err)
+	}
+	if got := rim.GetSlaveEndpoint(); got != "memory:master" {
+		t.Errorf("Expected slave endpoint to fall back to master, got %s", got)
+	}
+}
+
+func TestRedisInMemory_Topology(t *testing.T) {
+	rim, _ := newTestInMemory(t, []string{"memory:replica-1", "memory:replica-2"}, 0)
+	defer rim.Close()
+
+	topology, err := rim.Topology(context.Background())
+	if err != nil {
+		t.Fatalf("Topology failed: %v", err)
+	}
+	if topology.Mode != redislib.RedisInMemory.String() {
+		t.Errorf("Expected mode %s, got %s", redislib.RedisInMemory, topology.Mode)
+	}
+	if len(topology.Nodes) != 3 || topology.HealthyCount() != 3 {
+		t.Fatalf("Expected 3 healthy nodes, got %+v", topology.Nodes)
+	}
+	primaries := topology.Primaries()
+	if len(primaries) != 1 || primaries[0].Address != "memory:master" {
+		t.Errorf("Expected memory:master as the only primary, got %+v", primaries)
+	}
+	for _, node := range topology.Nodes[1:] {
+		if node.Role != redislib.RoleReplica || node.Parent != "memory:master" {
+			t.Errorf("Unexpected replica node %+v", node)
+		}
+	}
+}
+
+func TestRedisInMemory_Closed(t *testing.T) {
+	rim, _ := newTestInMemory(t, nil, 0)
+	rim.Close()
+
+	if _, err := rim.WriteAsync(context.Background(), "key", "value"); !errors.Is(err, redislib.ErrWriteFailed) {
+		t.Errorf("Expected ErrWriteFailed after Close, got %v", err)
+	}
+}
+
+func TestNewRedisInMemory_InvalidParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		master  string
+		lag     time.Duration
+		wantErr bool
+	}{
+		{
+			name:    "default master",
+			master:  "",
+			lag:     0,
+			wantErr: false,
+		},
+		{
+			name:    "negative lag",
+			master:  "memory:master",
+			lag:     -time.Second,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRedisInMemory(tt.master, nil, tt.lag)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewRedisInMemory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
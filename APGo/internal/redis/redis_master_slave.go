@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"time"
 
 	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
 	goredis "github.com/redis/go-redis/v9"
@@ -12,25 +13,58 @@ import (
 // RedisMasterSlave 實作主從模式的 Redis 連線
 type RedisMasterSlave struct {
 	master         *goredis.Client
-	slave          *goredis.Client
-	slaves         []*goredis.Client
 	masterEndpoint string
-	slaveEndpoint  string
+	readPreference redislib.ReadPreference
+	selector       readSelector
+	masterNode     *readNode
+	replicaNodes   []*readNode // 所有設定的 Replica，是否參與讀取由健康狀態決定
+	healthChecker  *healthChecker
+	maxStaleness   time.Duration
+	maxLagBytes    int64
+}
+
+// MasterSlaveOptions 主從模式的進階選項
+type MasterSlaveOptions struct {
+	// ReadPreference 讀取偏好，空字串表示 replica_preferred
+	ReadPreference redislib.ReadPreference
+	// Weights weighted 讀取偏好使用的 Replica 權重（端點 → 權重），未列出的 Replica 權重為 1
+	Weights map[string]int
+	// HealthCheckInterval Replica 健康檢查間隔，0 表示使用預設值 5s，負數表示停用
+	HealthCheckInterval time.Duration
+	// UnhealthyThreshold 連續失敗幾次後將 Replica 移出讀取輪替，0 表示使用預設值 2
+	UnhealthyThreshold int
+	// MaxStaleness 預設可接受的最大複寫時間落差，0 表示不限制（可由單次讀取覆寫）
+	MaxStaleness time.Duration
+	// MaxLagBytes 預設可接受的最大複寫 offset 落差，0 表示不限制（可由單次讀取覆寫）
+	MaxLagBytes int64
+	// Client Master 與 Replica 連線共用的連線池、逾時、重試與驗證設定
+	Client ClientOptions
 }
 
 // NewRedisMasterSlave 建立新的主從模式 Redis 連線
 func NewRedisMasterSlave(master string, slaves []string) (*RedisMasterSlave, error) {
+	return NewRedisMasterSlaveWithOptions(master, slaves, MasterSlaveOptions{})
+}
+
+// NewRedisMasterSlaveWithOptions 使用進階選項建立主從模式 Redis 連線
+func NewRedisMasterSlaveWithOptions(master string, slaves []string, opts MasterSlaveOptions) (*RedisMasterSlave, error) {
 	if master == "" {
 		return nil, fmt.Errorf("master endpoint is required")
 	}
+	readPreference, err := redislib.ParseReadPreference(string(opts.ReadPreference))
+	if err != nil {
+		return nil, err
+	}
+	if opts.MaxStaleness < 0 || opts.MaxLagBytes < 0 {
+		return nil, fmt.Errorf("max staleness and max lag bytes must not be negative")
+	}
 
 	// 連線到 Master
-	masterClient := goredis.NewClient(&goredis.Options{
-		Addr: master,
-	})
+	masterClient := goredis.NewClient(opts.Client.options(master))
 
-	// 測試 Master 連線
+	// 測試 Master 連線（同時作為延遲的初始量測）
 	ctx := context.Background()
+	start := time.Now()
 	if err := masterClient.Ping(ctx).Err(); err != nil {
 		return nil, fmt.Errorf("failed to connect to master %s: %w", master, err)
 	}
@@ -38,75 +72,172 @@ func NewRedisMasterSlave(master string, slaves []string) (*RedisMasterSlave, err
 	rms := &RedisMasterSlave{
 		master:         masterClient,
 		masterEndpoint: master,
-		slaves:         make([]*goredis.Client, 0, len(slaves)),
+		readPreference: readPreference,
+		masterNode:     newPrimaryReadNode(master, masterClient),
+		replicaNodes:   make([]*readNode, 0, len(slaves)),
+		maxStaleness:   opts.MaxStaleness,
+		maxLagBytes:    opts.MaxLagBytes,
 	}
+	rms.masterNode.observeLatency(time.Since(start))
 
 	// 連線到所有 Slaves
 	for _, slaveAddr := range slaves {
-		slave := goredis.NewClient(&goredis.Options{
-			Addr: slaveAddr,
-		})
+		slave := goredis.NewClient(opts.Client.options(slaveAddr))
+
+		weight, ok := opts.Weights[slaveAddr]
+		if !ok {
+			weight = 1
+		}
+		node := newReadNode(slaveAddr, slave, weight)
+		rms.replicaNodes = append(rms.replicaNodes, node)
 
 		// 測試 Slave 連線
+		start := time.Now()
 		if err := slave.Ping(ctx).Err(); err != nil {
-			// 如果 Slave 連線失敗，記錄錯誤但繼續
+			// 如果 Slave 連線失敗，先移出讀取輪替，交由健康檢查在恢復後加回
 			fmt.Printf("Warning: failed to connect to slave %s: %v\n", slaveAddr, err)
+			node.healthy.Store(false)
 			continue
 		}
-
-		rms.slaves = append(rms.slaves, slave)
-
-		// 使用第一個成功連線的 Slave 作為預設 Slave
-		if rms.slave == nil {
-			rms.slave = slave
-			rms.slaveEndpoint = slaveAddr
-		}
+		node.observeLatency(time.Since(start))
 	}
 
-	// 如果沒有可用的 Slave，使用 Master 作為備用
-	if rms.slave == nil {
-		rms.slave = masterClient
-		rms.slaveEndpoint = master
+	// 啟動 Replica 健康檢查
+	if opts.HealthCheckInterval >= 0 && len(rms.replicaNodes) > 0 {
+		rms.healthChecker = newHealthChecker(rms.masterNode, rms.replicaNodes, opts.HealthCheckInterval, opts.UnhealthyThreshold)
+		rms.healthChecker.Start()
 	}
 
 	return rms, nil
 }
 
-// ReadAsync 從 Slave 讀取資料
+// defaultReadNode 取得預設讀取節點：第一個健康的 Replica，沒有時使用 Master
+func (r *RedisMasterSlave) defaultReadNode() *readNode {
+	return r.selector.order(redislib.ReadReplicaPreferred, r.masterNode, r.replicaNodes)[0]
+}
+
+// ReplicaHealth 取得所有 Replica 的健康狀態
+func (r *RedisMasterSlave) ReplicaHealth() []ReplicaHealth {
+	health := make([]ReplicaHealth, len(r.replicaNodes))
+	for i, node := range r.replicaNodes {
+		health[i] = node.healthSnapshot()
+	}
+	return health
+}
+
+// ReadAsync 依讀取偏好讀取資料（預設從 Slave 讀取）
 func (r *RedisMasterSlave) ReadAsync(ctx context.Context, key string) (string, error) {
-	val, err := r.slave.Get(ctx, key).Result()
-	if err == goredis.Nil {
-		return "", redislib.ErrKeyNotFound
+	result, err := r.ReadWithOptionsAsync(ctx, key, redislib.ReadOptions{})
+	return result.Value, err
+}
+
+// ReadWithOptionsAsync 依讀取偏好與複寫落差上限選擇節點讀取資料，節點連線失敗時改試下一個
+func (r *RedisMasterSlave) ReadWithOptionsAsync(ctx context.Context, key string, opts redislib.ReadOptions) (redislib.ReadResult, error) {
+	pref := r.readPreference
+	if opts.Preference != "" {
+		p, err := redislib.ParseReadPreference(string(opts.Preference))
+		if err != nil {
+			return redislib.ReadResult{}, err
+		}
+		pref = p
 	}
+
+	if opts.MaxStaleness == 0 {
+		opts.MaxStaleness = r.maxStaleness
+	}
+	if opts.MaxLagBytes == 0 {
+		opts.MaxLagBytes = r.maxLagBytes
+	}
+
+	// 落差超過上限或尚未追上一致性 Token 的 Replica 會被略過，沒有符合的 Replica 時改由 Master 提供
+	// （讀取偏好為 replica 時不改讀 Master，返回錯誤）
+	nodes := r.selector.order(pref, r.masterNode, r.replicaNodes)
+	nodes, err := filterStale(nodes, r.masterNode, opts)
 	if err != nil {
-		return "", fmt.Errorf("%w: %v", redislib.ErrReadFailed, err)
+		return redislib.ReadResult{}, err
 	}
-	return val, nil
+	nodes = filterCaughtUp(ctx, nodes, r.masterNode, opts.After)
+	return readFromNodes(ctx, nodes, key)
+}
+
+// ReadPreference 取得目前設定的讀取偏好
+func (r *RedisMasterSlave) ReadPreference() redislib.ReadPreference {
+	return r.readPreference
 }
 
 // WriteAsync 寫入資料到 Master
 func (r *RedisMasterSlave) WriteAsync(ctx context.Context, key string, value string) (bool, error) {
-	err := r.master.Set(ctx, key, value, 0).Err()
-	if err != nil {
-		return false, fmt.Errorf("%w: %v", redislib.ErrWriteFailed, err)
-	}
-	return true, nil
+	return r.WriteWithTTLAsync(ctx, key, value, 0)
 }
 
-// GetRandomCache 隨機從一個 Slave 讀取資料
+// WriteWithTTLAsync 寫入資料到 Master 並設定過期時間
+func (r *RedisMasterSlave) WriteWithTTLAsync(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	return setWithTTL(ctx, r.master, key, value, ttl)
+}
+
+// ConsistencyTokenAsync 取得 Master 目前的複寫 offset 作為一致性 Token
+func (r *RedisMasterSlave) ConsistencyTokenAsync(ctx context.Context) (redislib.ConsistencyToken, error) {
+	return consistencyToken(ctx, r.master)
+}
+
+// GetTTLAsync 取得 Key 的剩餘存活時間
+func (r *RedisMasterSlave) GetTTLAsync(ctx context.Context, key string) (time.Duration, error) {
+	return getTTL(ctx, r.master, key)
+}
+
+// ExpireAsync 變更 Key 的過期時間
+func (r *RedisMasterSlave) ExpireAsync(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return expire(ctx, r.master, key, ttl)
+}
+
+// PersistAsync 移除 Key 的過期時間
+func (r *RedisMasterSlave) PersistAsync(ctx context.Context, key string) (bool, error) {
+	return persist(ctx, r.master, key)
+}
+
+// DeleteAsync 刪除單一 Key
+func (r *RedisMasterSlave) DeleteAsync(ctx context.Context, key string) (bool, error) {
+	n, err := deleteKeys(ctx, r.master, key)
+	return n > 0, err
+}
+
+// DeleteManyAsync 刪除多個 Key
+func (r *RedisMasterSlave) DeleteManyAsync(ctx context.Context, keys []string) (int64, error) {
+	return deleteKeys(ctx, r.master, keys...)
+}
+
+// ExistsAsync 從 Slave 檢查 Key 是否存在
+func (r *RedisMasterSlave) ExistsAsync(ctx context.Context, key string) (bool, error) {
+	return exists(ctx, r.defaultReadNode().client, key)
+}
+
+// BatchReadAsync 從 Slave 以 Pipeline 批次讀取
+func (r *RedisMasterSlave) BatchReadAsync(ctx context.Context, keys []string) ([]redislib.BatchResult, error) {
+	node := r.defaultReadNode()
+	return pipelineGet(ctx, node.client, node.endpoint, keys), nil
+}
+
+// BatchWriteAsync 以 Pipeline 批次寫入到 Master
+func (r *RedisMasterSlave) BatchWriteAsync(ctx context.Context, entries []redislib.KeyValue) ([]redislib.BatchResult, error) {
+	return pipelineSet(ctx, r.master, r.masterEndpoint, entries), nil
+}
+
+// GetRandomCache 隨機從一個健康的 Slave 讀取資料
 func (r *RedisMasterSlave) GetRandomCache(ctx context.Context, key string) (string, error) {
-	if len(r.slaves) == 0 {
-		// 如果沒有 Slave，從 Master 讀取
+	replicas := healthyNodes(r.replicaNodes)
+	if len(replicas) == 0 {
+		// 如果沒有可用的 Slave，從 Master 讀取
 		return r.ReadAsync(ctx, key)
 	}
 
 	// 隨機打亂 slaves 順序
-	indices := rand.Perm(len(r.slaves))
+	indices := rand.Perm(len(replicas))
 
 	// 嘗試從隨機順序的 Slave 讀取
 	for _, idx := range indices {
-		slave := r.slaves[idx]
+		slave := replicas[idx].client
 		val, err := slave.Get(ctx, key).Result()
+		redislib.RecordServedBy(ctx, replicas[idx].endpoint)
 		if err == goredis.Nil {
 			continue // Key 不存在，嘗試下一個 Slave
 		}
@@ -121,28 +252,57 @@ func (r *RedisMasterSlave) GetRandomCache(ctx context.Context, key string) (stri
 	return "", redislib.ErrKeyNotFound
 }
 
+// Topology 取得 Master 與各 Replica 的拓樸，Replica 的健康狀態來自背景健康檢查
+func (r *RedisMasterSlave) Topology(ctx context.Context) (redislib.Topology, error) {
+	master := redislib.TopologyNode{Address: r.masterEndpoint, Role: redislib.RoleMaster, Healthy: true}
+	if err := r.master.Ping(ctx).Err(); err != nil {
+		master.Healthy = false
+		master.Error = err.Error()
+	}
+
+	topology := redislib.Topology{
+		Mode:  redislib.RedisMasterSlaves.String(),
+		Nodes: []redislib.TopologyNode{master},
+	}
+	for _, health := range r.ReplicaHealth() {
+		topology.Nodes = append(topology.Nodes, redislib.TopologyNode{
+			Address: health.Endpoint,
+			Role:    redislib.RoleReplica,
+			Healthy: health.Healthy,
+			Parent:  r.masterEndpoint,
+			Error:   health.LastError,
+		})
+	}
+	return topology, nil
+}
+
 // GetMasterEndpoint 取得 Master 端點
 func (r *RedisMasterSlave) GetMasterEndpoint() string {
 	return r.masterEndpoint
 }
 
-// GetSlaveEndpoint 取得 Slave 端點
+// GetSlaveEndpoint 取得 Slave 端點（第一個健康的 Slave，沒有時為 Master）
 func (r *RedisMasterSlave) GetSlaveEndpoint() string {
-	return r.slaveEndpoint
+	return r.defaultReadNode().endpoint
 }
 
-// Close 關閉所有連線
+// Close 停止健康檢查並關閉所有連線
 func (r *RedisMasterSlave) Close() error {
 	var lastErr error
 
+	// 先停止健康檢查，避免檢查中使用已關閉的連線
+	if r.healthChecker != nil {
+		r.healthChecker.Stop()
+	}
+
 	// 關閉 Master
 	if err := r.master.Close(); err != nil {
 		lastErr = err
 	}
 
 	// 關閉所有 Slaves
-	for _, slave := range r.slaves {
-		if err := slave.Close(); err != nil {
+	for _, node := range r.replicaNodes {
+		if err := node.client.Close(); err != nil {
 			lastErr = err
 		}
 	}
@@ -2,67 +2,222 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
 	goredis "github.com/redis/go-redis/v9"
 )
 
 // RedisRaft 實作 Raft 模式的 Redis 連線
+// 每個節點各保留一條連線，所有操作送往目前的 Leader，Leader 變動時自動重定向
 type RedisRaft struct {
-	client *goredis.Client
-	nodes  []string
+	nodes   []string
+	mu      sync.RWMutex // 保護 clients、leader 與 closed
+	clients map[string]*goredis.Client
+	leader  string
+	closed  bool // Close 之後不再建立節點連線
+
+	clientOpts ClientOptions
+}
+
+// RaftOptions Raft 模式的進階選項
+type RaftOptions struct {
+	// Client 各節點連線的連線池、逾時、重試與驗證設定
+	Client ClientOptions
 }
 
 // NewRedisRaft 建立新的 Raft 模式 Redis 連線
 func NewRedisRaft(nodes []string) (*RedisRaft, error) {
+	return NewRedisRaftWithOptions(nodes, RaftOptions{})
+}
+
+// NewRedisRaftWithOptions 使用進階選項建立 Raft 模式 Redis 連線
+func NewRedisRaftWithOptions(nodes []string, opts RaftOptions) (*RedisRaft, error) {
 	if len(nodes) == 0 {
 		return nil, fmt.Errorf("at least one raft node is required")
 	}
 
-	// 連線到第一個節點（通常是 Leader）
-	// RedisRaft 會自動處理 Leader 重定向
-	client := goredis.NewClient(&goredis.Options{
-		Addr: nodes[0],
-	})
-
-	// 測試連線
-	ctx := context.Background()
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to raft node: %w", err)
+	rr := &RedisRaft{
+		nodes:      nodes,
+		clients:    make(map[string]*goredis.Client, len(nodes)),
+		clientOpts: opts.Client,
+	}
+	for _, node := range nodes {
+		rr.clients[node] = goredis.NewClient(opts.Client.options(node))
 	}
 
-	rr := &RedisRaft{
-		client: client,
-		nodes:  nodes,
+	// 透過 RAFT.INFO 找出目前的 Leader
+	ctx := context.Background()
+	if err := rr.discoverLeader(ctx); err != nil {
+		// 找不到 Leader 時改用第一個可連線的節點，讓操作時再依重定向更新
+		fmt.Printf("Warning: failed to discover raft leader: %v\n", err)
+		if err := rr.useFirstReachable(ctx); err != nil {
+			rr.Close()
+			return nil, fmt.Errorf("failed to connect to raft node: %w", err)
+		}
 	}
 
 	return rr, nil
 }
 
+// useFirstReachable 以第一個回應 PING 的節點作為暫定的 Leader
+func (r *RedisRaft) useFirstReachable(ctx context.Context) error {
+	var errs []error
+	for _, node := range r.nodes {
+		client, err := r.clientFor(node)
+		if err != nil {
+			return err
+		}
+		if err := client.Ping(ctx).Err(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", node, err))
+			continue
+		}
+		r.setLeader(node)
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
 // ReadAsync 從 Raft 讀取資料（Strong Consistency）
 func (r *RedisRaft) ReadAsync(ctx context.Context, key string) (string, error) {
 	// RedisRaft 提供強一致性讀取
 	// 所有讀取都會經過 Raft 共識
-	val, err := r.client.Get(ctx, key).Result()
+	var val string
+	err := r.withLeader(ctx, func(client *goredis.Client, leader string) error {
+		var err error
+		val, err = client.Get(ctx, key).Result()
+		return err
+	})
 	if err == goredis.Nil {
 		return "", redislib.ErrKeyNotFound
 	}
 	if err != nil {
-		return "", fmt.Errorf("%w: %v", redislib.ErrReadFailed, err)
+		return "", fmt.Errorf("%w: %w", redislib.ErrReadFailed, err)
 	}
 	return val, nil
 }
 
+// ReadWithOptionsAsync 讀取資料並回報提供資料的節點（Raft 讀取一律經過 Leader）
+func (r *RedisRaft) ReadWithOptionsAsync(ctx context.Context, key string, opts redislib.ReadOptions) (redislib.ReadResult, error) {
+	val, err := r.ReadAsync(ctx, key)
+	// Raft 的讀取由 Leader 處理，沒有複寫落差
+	return redislib.ReadResult{Value: val, Node: r.GetMasterEndpoint()}, err
+}
+
 // WriteAsync 寫入資料到 Raft（Strong Consistency）
 func (r *RedisRaft) WriteAsync(ctx context.Context, key string, value string) (bool, error) {
 	// RedisRaft 的寫入會經過 Raft 共識
 	// 需要多數節點確認才會成功
-	err := r.client.Set(ctx, key, value, 0).Err()
-	if err != nil {
-		return false, fmt.Errorf("%w: %v", redislib.ErrWriteFailed, err)
+	return r.WriteWithTTLAsync(ctx, key, value, 0)
+}
+
+// WriteWithTTLAsync 寫入資料到 Raft 並設定過期時間
+func (r *RedisRaft) WriteWithTTLAsync(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := r.withLeader(ctx, func(client *goredis.Client, leader string) error {
+		var err error
+		ok, err = setWithTTL(ctx, client, key, value, ttl)
+		return err
+	})
+	return ok, err
+}
+
+// ConsistencyTokenAsync 讀取由 Leader 處理，一定讀得到自己的寫入，不需要一致性 Token
+func (r *RedisRaft) ConsistencyTokenAsync(ctx context.Context) (redislib.ConsistencyToken, error) {
+	return 0, nil
+}
+
+// GetTTLAsync 取得 Key 的剩餘存活時間
+func (r *RedisRaft) GetTTLAsync(ctx context.Context, key string) (time.Duration, error) {
+	var ttl time.Duration
+	err := r.withLeader(ctx, func(client *goredis.Client, leader string) error {
+		var err error
+		ttl, err = getTTL(ctx, client, key)
+		return err
+	})
+	return ttl, err
+}
+
+// ExpireAsync 變更 Key 的過期時間
+func (r *RedisRaft) ExpireAsync(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := r.withLeader(ctx, func(client *goredis.Client, leader string) error {
+		var err error
+		ok, err = expire(ctx, client, key, ttl)
+		return err
+	})
+	return ok, err
+}
+
+// PersistAsync 移除 Key 的過期時間
+func (r *RedisRaft) PersistAsync(ctx context.Context, key string) (bool, error) {
+	var ok bool
+	err := r.withLeader(ctx, func(client *goredis.Client, leader string) error {
+		var err error
+		ok, err = persist(ctx, client, key)
+		return err
+	})
+	return ok, err
+}
+
+// DeleteAsync 刪除單一 Key
+func (r *RedisRaft) DeleteAsync(ctx context.Context, key string) (bool, error) {
+	n, err := r.DeleteManyAsync(ctx, []string{key})
+	return n > 0, err
+}
+
+// DeleteManyAsync 刪除多個 Key
+func (r *RedisRaft) DeleteManyAsync(ctx context.Context, keys []string) (int64, error) {
+	var n int64
+	err := r.withLeader(ctx, func(client *goredis.Client, leader string) error {
+		var err error
+		n, err = deleteKeys(ctx, client, keys...)
+		return err
+	})
+	return n, err
+}
+
+// ExistsAsync 檢查 Key 是否存在（Strong Consistency）
+func (r *RedisRaft) ExistsAsync(ctx context.Context, key string) (bool, error) {
+	var ok bool
+	err := r.withLeader(ctx, func(client *goredis.Client, leader string) error {
+		var err error
+		ok, err = exists(ctx, client, key)
+		return err
+	})
+	return ok, err
+}
+
+// BatchReadAsync 以 Pipeline 批次讀取（Strong Consistency）
+func (r *RedisRaft) BatchReadAsync(ctx context.Context, keys []string) ([]redislib.BatchResult, error) {
+	var results []redislib.BatchResult
+	// 個別 Key 的錯誤記錄在結果中，只依第一個錯誤判斷是否需要重定向
+	err := r.withLeader(ctx, func(client *goredis.Client, leader string) error {
+		results = pipelineGet(ctx, client, leader, keys)
+		return firstBatchError(results)
+	})
+	// 沒有任何結果代表批次未曾執行（例如已 Close 或找不到 Leader）
+	if results == nil && err != nil {
+		return nil, fmt.Errorf("%w: %w", redislib.ErrReadFailed, err)
+	}
+	return results, nil
+}
+
+// BatchWriteAsync 以 Pipeline 批次寫入（每筆皆經過 Raft 共識）
+func (r *RedisRaft) BatchWriteAsync(ctx context.Context, entries []redislib.KeyValue) ([]redislib.BatchResult, error) {
+	var results []redislib.BatchResult
+	err := r.withLeader(ctx, func(client *goredis.Client, leader string) error {
+		results = pipelineSet(ctx, client, leader, entries)
+		return firstBatchError(results)
+	})
+	if results == nil && err != nil {
+		return nil, fmt.Errorf("%w: %w", redislib.ErrWriteFailed, err)
 	}
-	return true, nil
+	return results, nil
 }
 
 // GetRandomCache 讀取資料（Raft 保證強一致性）
@@ -70,13 +225,76 @@ func (r *RedisRaft) GetRandomCache(ctx context.Context, key string) (string, err
 	return r.ReadAsync(ctx, key)
 }
 
-// GetMasterEndpoint 取得 Leader 端點
+// Topology 向每個設定的節點查詢 RAFT.INFO，無法連線的節點角色為 unknown
+// Follower 的 Parent 為節點回報的 Leader，沒有節點知道 Leader 時留空
+func (r *RedisRaft) Topology(ctx context.Context) (redislib.Topology, error) {
+	topology := redislib.Topology{
+		Mode:  redislib.RedisRaft.String(),
+		Nodes: make([]redislib.TopologyNode, 0, len(r.nodes)),
+	}
+
+	infos := make(map[string]redislib.RaftInfo, len(r.nodes))
+	for _, addr := range r.nodes {
+		node := redislib.TopologyNode{Address: addr, Role: redislib.RoleUnknown}
+		info, err := r.GetRaftNode(ctx, addr)
+		if err != nil {
+			node.Error = err.Error()
+			topology.Nodes = append(topology.Nodes, node)
+			continue
+		}
+		infos[addr] = info
+
+		node.ID = strconv.FormatInt(info.NodeID, 10)
+		node.Healthy = info.State == "up"
+		node.Term = info.CurrentTerm
+		node.CommitIndex = info.CommitIndex
+		node.Role = raftRole(info.Role)
+		if !node.Healthy {
+			node.Error = fmt.Sprintf("raft state is %s", info.State)
+		}
+		topology.Nodes = append(topology.Nodes, node)
+	}
+
+	leader := probedLeader(r.nodes, infos)
+	for i := range topology.Nodes {
+		if topology.Nodes[i].Role == redislib.RoleFollower {
+			topology.Nodes[i].Parent = leader
+		}
+	}
+
+	// Leader 的 RAFT.INFO 包含整個叢集的狀態，查詢失敗時只省略此欄位
+	if info, err := r.GetRaftInfo(ctx); err == nil {
+		topology.Raft = &info
+	}
+	return topology, nil
+}
+
+// probedLeader 依各節點的 RAFT.INFO 判斷 Leader，優先採用自己回報為 Leader 的節點
+func probedLeader(nodes []string, infos map[string]redislib.RaftInfo) string {
+	for _, addr := range nodes {
+		if info, ok := infos[addr]; ok && info.Role == "leader" {
+			return addr
+		}
+	}
+	for _, addr := range nodes {
+		if info, ok := infos[addr]; ok {
+			if leader, ok := info.Leader(addr); ok {
+				return leader
+			}
+		}
+	}
+	return ""
+}
+
+// GetMasterEndpoint 取得目前 Leader 端點
 func (r *RedisRaft) GetMasterEndpoint() string {
 	// 在 Raft 中，Leader 就是 Master
-	if len(r.nodes) == 0 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.leader == "" {
 		return "raft:unknown"
 	}
-	return fmt.Sprintf("raft-leader:%s", r.nodes[0])
+	return r.leader
 }
 
 // GetSlaveEndpoint 取得 Follower 端點
@@ -85,26 +303,61 @@ func (r *RedisRaft) GetSlaveEndpoint() string {
 	return fmt.Sprintf("raft-followers:%d-nodes", len(r.nodes)-1)
 }
 
-// Close 關閉連線
+// Close 關閉所有節點的連線
 func (r *RedisRaft) Close() error {
-	return r.client.Close()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	var errs []error
+	for addr, client := range r.clients {
+		if err := client.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(r.clients, addr)
+	}
+	return errors.Join(errs...)
 }
 
-// GetRaftInfo 取得 Raft 集群資訊
-func (r *RedisRaft) GetRaftInfo(ctx context.Context) (string, error) {
-	// 使用 RAFT.INFO 命令取得集群狀態
-	result, err := r.client.Do(ctx, "RAFT.INFO").Result()
+// GetRaftInfo 取得 Leader 的 Raft 集群資訊
+func (r *RedisRaft) GetRaftInfo(ctx context.Context) (redislib.RaftInfo, error) {
+	var info redislib.RaftInfo
+	err := r.withLeader(ctx, func(client *goredis.Client, leader string) error {
+		var err error
+		info, err = r.raftInfo(ctx, client)
+		return err
+	})
+	if err != nil {
+		return redislib.RaftInfo{}, fmt.Errorf("failed to get raft info: %w", err)
+	}
+	return info, nil
+}
+
+// GetRaftNode 取得指定節點自己回報的 Raft 資訊（term、commit index 等可能與 Leader 不同）
+func (r *RedisRaft) GetRaftNode(ctx context.Context, addr string) (redislib.RaftInfo, error) {
+	client, err := r.clientFor(addr)
 	if err != nil {
-		return "", fmt.Errorf("failed to get raft info: %w", err)
+		return redislib.RaftInfo{}, err
 	}
-	return fmt.Sprintf("%v", result), nil
+	info, err := r.raftInfo(ctx, client)
+	if err != nil {
+		return redislib.RaftInfo{}, fmt.Errorf("failed to get raft node %s: %w", addr, err)
+	}
+	return info, nil
+}
+
+// GetRaftNodes 取得設定的節點列表
+func (r *RedisRaft) GetRaftNodes() []string {
+	return append([]string(nil), r.nodes...)
 }
 
-// GetRaftNode 取得節點資訊
-func (r *RedisRaft) GetRaftNode(ctx context.Context) (string, error) {
-	result, err := r.client.Do(ctx, "RAFT.NODE").Result()
+// raftInfo 對單一節點執行 RAFT.INFO 並解析結果
+func (r *RedisRaft) raftInfo(ctx context.Context, client *goredis.Client) (redislib.RaftInfo, error) {
+	ctx, span := startInfoSpan(ctx, "RAFT.INFO", redislib.RedisRaft.String(), client.Options().Addr)
+	text, err := client.Do(ctx, "RAFT.INFO").Text()
+	endInfoSpan(span, err)
 	if err != nil {
-		return "", fmt.Errorf("failed to get raft node: %w", err)
+		return redislib.RaftInfo{}, err
 	}
-	return fmt.Sprintf("%v", result), nil
+	return redislib.ParseRaftInfo(text)
 }
@@ -37,7 +37,7 @@ func TestRedisRaft(t *testing.T) {
 	if err != nil {
 		t.Fatalf("GetRaftInfo failed: %v", err)
 	}
-	t.Logf("Raft Info: %s", raftInfo)
+	t.Logf("Raft Info: %+v", raftInfo)
 
 	// 測試寫入（需要 Raft 共識）
 	key := "test:raft:key"
@@ -83,11 +83,14 @@ func TestRedisRaft(t *testing.T) {
 	t.Logf("Slave (Followers) endpoint: %s", slaveEndpoint)
 
 	// 測試節點資訊
-	nodeInfo, err := rr.GetRaftNode(ctx)
+	nodeInfo, err := rr.GetRaftNode(ctx, nodes[1])
 	if err != nil {
 		t.Fatalf("GetRaftNode failed: %v", err)
 	}
-	t.Logf("Raft Node Info: %s", nodeInfo)
+	if nodeInfo.CurrentTerm != raftInfo.CurrentTerm {
+		t.Logf("Node term %d differs from leader term %d", nodeInfo.CurrentTerm, raftInfo.CurrentTerm)
+	}
+	t.Logf("Raft Node Info: %+v", nodeInfo)
 }
 
 func TestNewRedisRaft_InvalidParams(t *testing.T) {
@@ -117,3 +120,31 @@ func TestNewRedisRaft_InvalidParams(t *testing.T) {
 		})
 	}
 }
+
+func TestProbedLeader(t *testing.T) {
+	nodes := []string{"10.0.0.1:5001", "10.0.0.2:5002", "10.0.0.3:5003"}
+	follower := redislib.RaftInfo{NodeID: 1, Role: "follower", LeaderID: 3,
+		Nodes: []redislib.RaftNode{{ID: 3, Addr: "10.0.0.3:5003"}}}
+	electing := redislib.RaftInfo{NodeID: 2, Role: "candidate", LeaderID: -1}
+
+	tests := []struct {
+		name  string
+		infos map[string]redislib.RaftInfo
+		want  string
+	}{
+		{"no node reachable", map[string]redislib.RaftInfo{}, ""},
+		{"no leader elected", map[string]redislib.RaftInfo{"10.0.0.2:5002": electing}, ""},
+		{"leader reported by follower", map[string]redislib.RaftInfo{"10.0.0.1:5001": follower}, "10.0.0.3:5003"},
+		{"self-reported leader wins", map[string]redislib.RaftInfo{
+			"10.0.0.1:5001": follower,
+			"10.0.0.2:5002": {NodeID: 2, Role: "leader", LeaderID: 2},
+		}, "10.0.0.2:5002"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := probedLeader(nodes, tt.infos); got != tt.want {
+				t.Errorf("probedLeader() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
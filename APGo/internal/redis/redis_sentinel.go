@@ -2,35 +2,101 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
 	goredis "github.com/redis/go-redis/v9"
 )
 
 // RedisSentinel 實作 Sentinel 模式的 Redis 連線
+// 在背景訂閱所有 Sentinel 的事件，Failover 後端點資訊會自動更新
 type RedisSentinel struct {
-	client         *goredis.Client
-	masterName     string
-	sentinels      []string
-	masterEndpoint string
-	slaveEndpoint  string
+	client     *goredis.Client
+	masterName string
+	sentinels  []string
+	endpoints  *sentinelEndpoints
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+
+	replicaReads     bool
+	replicaSelection ReplicaSelection
+	maxStaleness     time.Duration
+	maxLagBytes      int64
+	clientOpts       ClientOptions
+	sentinelUsername string
+	sentinelPassword string
+	mu               sync.Mutex           // 保護 replicaNodes 與 closed
+	replicaNodes     map[string]*readNode // 依端點快取 Replica 連線
+	closed           bool                 // Close 之後不再建立 Replica 連線
+	healthChecker    *healthChecker       // 啟用 Replica 讀取時量測 Replica 的健康狀態與複寫落差
+}
+
+// ReplicaSelection Sentinel 模式從 Replica 讀取時的選擇方式
+type ReplicaSelection string
+
+const (
+	// ReplicaSelectRandom 隨機選擇 Replica（預設）
+	ReplicaSelectRandom ReplicaSelection = "random"
+	// ReplicaSelectLatency 選擇量測延遲最低的 Replica
+	ReplicaSelectLatency ReplicaSelection = "latency"
+)
+
+// SentinelOptions Sentinel 模式的進階選項
+type SentinelOptions struct {
+	// ReplicaReads 為 true 時讀取改由 Sentinel 回報的 Replica 提供，預設全部經過 Master
+	ReplicaReads bool
+	// ReplicaSelection Replica 選擇方式：random（預設）或 latency
+	ReplicaSelection ReplicaSelection
+	// HealthCheckInterval 啟用 Replica 讀取時的健康檢查與複寫落差量測間隔，0 表示使用預設值 5s，負數表示停用
+	HealthCheckInterval time.Duration
+	// UnhealthyThreshold 連續失敗幾次後將 Replica 移出讀取輪替，0 表示使用預設值 2
+	UnhealthyThreshold int
+	// MaxStaleness 預設可接受的最大複寫時間落差，0 表示不限制（可由單次讀取覆寫）
+	MaxStaleness time.Duration
+	// MaxLagBytes 預設可接受的最大複寫 offset 落差，0 表示不限制（可由單次讀取覆寫）
+	MaxLagBytes int64
+	// Client Master 與 Replica 連線的連線池、逾時、重試與驗證設定（TLS 設定也套用到 Sentinel）
+	Client ClientOptions
+	// SentinelUsername / SentinelPassword Sentinel 本身的驗證，與 Master 的帳號密碼分開
+	SentinelUsername string
+	SentinelPassword string
 }
 
 // NewRedisSentinel 建立新的 Sentinel 模式 Redis 連線
 func NewRedisSentinel(masterName string, sentinels []string) (*RedisSentinel, error) {
+	return NewRedisSentinelWithOptions(masterName, sentinels, SentinelOptions{})
+}
+
+// NewRedisSentinelWithOptions 使用進階選項建立 Sentinel 模式 Redis 連線
+func NewRedisSentinelWithOptions(masterName string, sentinels []string, opts SentinelOptions) (*RedisSentinel, error) {
 	if masterName == "" {
 		return nil, fmt.Errorf("master name is required")
 	}
 	if len(sentinels) == 0 {
 		return nil, fmt.Errorf("at least one sentinel is required")
 	}
+	switch opts.ReplicaSelection {
+	case "":
+		opts.ReplicaSelection = ReplicaSelectRandom
+	case ReplicaSelectRandom, ReplicaSelectLatency:
+	default:
+		return nil, fmt.Errorf("%w: replica selection %q", redislib.ErrInvalidReadPreference, opts.ReplicaSelection)
+	}
+	if opts.MaxStaleness < 0 || opts.MaxLagBytes < 0 {
+		return nil, fmt.Errorf("max staleness and max lag bytes must not be negative")
+	}
 
 	// 使用 Sentinel 客戶端
-	client := goredis.NewFailoverClient(&goredis.FailoverOptions{
-		MasterName:    masterName,
-		SentinelAddrs: sentinels,
-	})
+	failoverOpts := opts.Client.failoverOptions(masterName, sentinels)
+	failoverOpts.SentinelUsername = opts.SentinelUsername
+	failoverOpts.SentinelPassword = opts.SentinelPassword
+	client := goredis.NewFailoverClient(failoverOpts)
 
 	// 測試連線
 	ctx := context.Background()
@@ -42,6 +108,16 @@ func NewRedisSentinel(masterName string, sentinels []string) (*RedisSentinel, er
 		client:     client,
 		masterName: masterName,
 		sentinels:  sentinels,
+		endpoints:  newSentinelEndpoints(masterName),
+
+		replicaReads:     opts.ReplicaReads,
+		replicaSelection: opts.ReplicaSelection,
+		maxStaleness:     opts.MaxStaleness,
+		maxLagBytes:      opts.MaxLagBytes,
+		clientOpts:       opts.Client,
+		sentinelUsername: opts.SentinelUsername,
+		sentinelPassword: opts.SentinelPassword,
+		replicaNodes:     make(map[string]*readNode),
 	}
 
 	// 取得當前 Master 和 Slave 端點
@@ -50,15 +126,49 @@ func NewRedisSentinel(masterName string, sentinels []string) (*RedisSentinel, er
 		fmt.Printf("Warning: failed to update endpoints: %v\n", err)
 	}
 
+	// 以 INFO replication 比對 Master 與 Replica 的 offset，讀取時才能依落差上限選擇 Replica
+	if opts.ReplicaReads && opts.HealthCheckInterval >= 0 {
+		rs.healthChecker = newHealthChecker(newPrimaryReadNode(rs.GetMasterEndpoint(), client), nil,
+			opts.HealthCheckInterval, opts.UnhealthyThreshold)
+		rs.healthChecker.nodes = rs.checkedReplicas
+		rs.healthChecker.Start()
+	}
+
+	// 訂閱每個 Sentinel 的事件，任一 Sentinel 失聯時仍能從其他 Sentinel 收到通知
+	watchCtx, cancel := context.WithCancel(context.Background())
+	rs.cancel = cancel
+	for _, addr := range sentinels {
+		rs.wg.Add(1)
+		go rs.watchSentinel(watchCtx, addr)
+	}
+
 	return rs, nil
 }
 
-// updateEndpoints 更新 Master 和 Slave 端點資訊
+// updateEndpoints 依序向各個 Sentinel 查詢 Master 和 Slave 端點，直到其中一個成功
 func (r *RedisSentinel) updateEndpoints(ctx context.Context) error {
-	// 透過 Sentinel 查詢當前 Master
-	sentinelClient := goredis.NewSentinelClient(&goredis.Options{
-		Addr: r.sentinels[0],
-	})
+	var errs []error
+	for _, addr := range r.sentinels {
+		err := r.queryEndpoints(ctx, addr)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+	}
+	return errors.Join(errs...)
+}
+
+// sentinelClientOptions 建立連到 Sentinel 本身的設定，使用 Sentinel 的帳號密碼而非 Master 的
+func (r *RedisSentinel) sentinelClientOptions(addr string) *goredis.Options {
+	opts := r.clientOpts.options(addr)
+	opts.Username = r.sentinelUsername
+	opts.Password = r.sentinelPassword
+	return opts
+}
+
+// queryEndpoints 向單一 Sentinel 查詢 Master 和 Slave 端點
+func (r *RedisSentinel) queryEndpoints(ctx context.Context, addr string) error {
+	sentinelClient := goredis.NewSentinelClient(r.sentinelClientOptions(addr))
 	defer sentinelClient.Close()
 
 	// 取得 Master 位址
@@ -66,73 +176,299 @@ func (r *RedisSentinel) updateEndpoints(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to get master address: %w", err)
 	}
-	if len(masterAddr) >= 2 {
-		r.masterEndpoint = fmt.Sprintf("%s:%s", masterAddr[0], masterAddr[1])
+	if len(masterAddr) < 2 {
+		return fmt.Errorf("unexpected master address reply: %v", masterAddr)
 	}
+	master := net.JoinHostPort(masterAddr[0], masterAddr[1])
 
-	// 取得 Slave（Replica）位址
+	// 取得 Slave（Replica）位址，Sentinel 標記為下線或斷線的 Replica 會記錄為下線
 	replicas, err := sentinelClient.Replicas(ctx, r.masterName).Result()
-	if err == nil && len(replicas) > 0 {
-		// 使用第一個 Replica
-		replica := replicas[0]
-		if ip, ok := replica["ip"]; ok {
-			if port, ok := replica["port"]; ok {
-				r.slaveEndpoint = fmt.Sprintf("%s:%s", ip, port)
-			}
-		}
+	if err != nil {
+		return fmt.Errorf("failed to get replicas: %w", err)
 	}
-
-	// 如果沒有 Slave，使用 Master
-	if r.slaveEndpoint == "" {
-		r.slaveEndpoint = r.masterEndpoint
+	endpoints := make([]string, 0, len(replicas))
+	var down []string
+	for _, replica := range replicas {
+		ip, hasIP := replica["ip"]
+		port, hasPort := replica["port"]
+		if !hasIP || !hasPort {
+			continue
+		}
+		endpoint := net.JoinHostPort(ip, port)
+		endpoints = append(endpoints, endpoint)
+		if flags := replica["flags"]; strings.Contains(flags, "s_down") ||
+			strings.Contains(flags, "o_down") || strings.Contains(flags, "disconnected") {
+			down = append(down, endpoint)
+		}
 	}
 
+	r.endpoints.reset(master, endpoints, down)
 	return nil
 }
 
-// ReadAsync 從 Redis 讀取資料
+// ReadAsync 從 Redis 讀取資料（啟用 Replica 讀取時由 Replica 提供）
 func (r *RedisSentinel) ReadAsync(ctx context.Context, key string) (string, error) {
-	val, err := r.client.Get(ctx, key).Result()
-	if err == goredis.Nil {
-		return "", redislib.ErrKeyNotFound
+	result, err := r.ReadWithOptionsAsync(ctx, key, redislib.ReadOptions{})
+	return result.Value, err
+}
+
+// ReadWithOptionsAsync 讀取資料並回報提供資料的節點
+// 未啟用 Replica 讀取或讀取偏好為 primary 時經過 Master，
+// 否則依設定的選擇方式嘗試 Replica，全部失敗時改讀 Master
+func (r *RedisSentinel) ReadWithOptionsAsync(ctx context.Context, key string, opts redislib.ReadOptions) (redislib.ReadResult, error) {
+	pref, err := redislib.ParseReadPreference(string(opts.Preference))
+	if err != nil {
+		return redislib.ReadResult{}, err
 	}
+
+	master := newPrimaryReadNode(r.GetMasterEndpoint(), r.client)
+	if !r.replicaReads || pref == redislib.ReadPrimary {
+		return readFromNodes(ctx, []*readNode{master}, key)
+	}
+
+	if opts.MaxStaleness == 0 {
+		opts.MaxStaleness = r.maxStaleness
+	}
+	if opts.MaxLagBytes == 0 {
+		opts.MaxLagBytes = r.maxLagBytes
+	}
+
+	replicas, err := r.orderReplicas(r.replicaSelection)
 	if err != nil {
-		return "", fmt.Errorf("%w: %v", redislib.ErrReadFailed, err)
+		return redislib.ReadResult{}, err
 	}
-	return val, nil
+	// 複寫落差由健康檢查量測，停用健康檢查時設定落差上限的讀取會略過 Replica
+	nodes := append(replicas, master)
+	nodes, err = filterStale(nodes, master, opts)
+	if err != nil {
+		return redislib.ReadResult{}, err
+	}
+	nodes = filterCaughtUp(ctx, nodes, master, opts.After)
+	return readFromNodes(ctx, nodes, key)
 }
 
-// WriteAsync 寫入資料到 Redis
-func (r *RedisSentinel) WriteAsync(ctx context.Context, key string, value string) (bool, error) {
-	err := r.client.Set(ctx, key, value, 0).Err()
+// orderReplicas 依選擇方式排列目前未下線且未被健康檢查移出輪替的 Replica
+func (r *RedisSentinel) orderReplicas(selection ReplicaSelection) ([]*readNode, error) {
+	replicas, err := r.replicaReadNodes()
+	if err != nil {
+		return nil, err
+	}
+	replicas = healthyNodes(replicas)
+	if selection == ReplicaSelectLatency {
+		// 尚未量測的 Replica 排在最前面，讓每個 Replica 都至少被量測一次
+		sort.SliceStable(replicas, func(i, j int) bool {
+			li, lj := replicas[i].Latency(), replicas[j].Latency()
+			if li == 0 || lj == 0 {
+				return li == 0 && lj != 0
+			}
+			return li < lj
+		})
+		return replicas, nil
+	}
+	return weightedShuffle(replicas), nil
+}
+
+// replicaReadNodes 取得目前未下線 Replica 的讀取節點，第一次使用時建立連線
+func (r *RedisSentinel) replicaReadNodes() ([]*readNode, error) {
+	endpoints := r.endpoints.availableReplicas()
+	nodes := make([]*readNode, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		node, err := r.replicaNode(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// checkedReplicas 健康檢查每一輪要檢查的 Replica，連線已關閉時不檢查
+func (r *RedisSentinel) checkedReplicas() []*readNode {
+	nodes, err := r.replicaReadNodes()
 	if err != nil {
-		return false, fmt.Errorf("%w: %v", redislib.ErrWriteFailed, err)
+		return nil
+	}
+	return nodes
+}
+
+// replicaNode 取得 Replica 的讀取節點，第一次使用時建立連線；連線已關閉時返回錯誤
+func (r *RedisSentinel) replicaNode(endpoint string) (*readNode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, fmt.Errorf("%w: sentinel connection %s is closed", redislib.ErrConnectionFailed, r.masterName)
+	}
+	node, ok := r.replicaNodes[endpoint]
+	if !ok {
+		node = newReadNode(endpoint, goredis.NewClient(r.clientOpts.options(endpoint)), 1)
+		r.replicaNodes[endpoint] = node
 	}
-	return true, nil
+	return node, nil
+}
+
+// WriteAsync 寫入資料到 Redis
+func (r *RedisSentinel) WriteAsync(ctx context.Context, key string, value string) (bool, error) {
+	return r.WriteWithTTLAsync(ctx, key, value, 0)
+}
+
+// WriteWithTTLAsync 寫入資料到 Redis 並設定過期時間
+func (r *RedisSentinel) WriteWithTTLAsync(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	return setWithTTL(ctx, r.client, key, value, ttl)
+}
+
+// ConsistencyTokenAsync 取得 Master 目前的複寫 offset 作為一致性 Token
+// Failover 後新 Master 會延續原本的 offset，Token 仍然有效
+func (r *RedisSentinel) ConsistencyTokenAsync(ctx context.Context) (redislib.ConsistencyToken, error) {
+	return consistencyToken(ctx, r.client)
+}
+
+// GetTTLAsync 取得 Key 的剩餘存活時間
+func (r *RedisSentinel) GetTTLAsync(ctx context.Context, key string) (time.Duration, error) {
+	return getTTL(ctx, r.client, key)
+}
+
+// ExpireAsync 變更 Key 的過期時間
+func (r *RedisSentinel) ExpireAsync(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return expire(ctx, r.client, key, ttl)
+}
+
+// PersistAsync 移除 Key 的過期時間
+func (r *RedisSentinel) PersistAsync(ctx context.Context, key string) (bool, error) {
+	return persist(ctx, r.client, key)
+}
+
+// DeleteAsync 刪除單一 Key
+func (r *RedisSentinel) DeleteAsync(ctx context.Context, key string) (bool, error) {
+	n, err := deleteKeys(ctx, r.client, key)
+	return n > 0, err
+}
+
+// DeleteManyAsync 刪除多個 Key
+func (r *RedisSentinel) DeleteManyAsync(ctx context.Context, keys []string) (int64, error) {
+	return deleteKeys(ctx, r.client, keys...)
+}
+
+// ExistsAsync 檢查 Key 是否存在
+func (r *RedisSentinel) ExistsAsync(ctx context.Context, key string) (bool, error) {
+	return exists(ctx, r.client, key)
+}
+
+// BatchReadAsync 以 Pipeline 批次讀取
+func (r *RedisSentinel) BatchReadAsync(ctx context.Context, keys []string) ([]redislib.BatchResult, error) {
+	return pipelineGet(ctx, r.client, r.GetMasterEndpoint(), keys), nil
 }
 
-// GetRandomCache 讀取資料（Sentinel 會自動路由）
+// BatchWriteAsync 以 Pipeline 批次寫入
+func (r *RedisSentinel) BatchWriteAsync(ctx context.Context, entries []redislib.KeyValue) ([]redislib.BatchResult, error) {
+	return pipelineSet(ctx, r.client, r.GetMasterEndpoint(), entries), nil
+}
+
+// GetRandomCache 隨機從一個 Replica 讀取資料（未啟用 Replica 讀取時經過 Master）
 func (r *RedisSentinel) GetRandomCache(ctx context.Context, key string) (string, error) {
-	return r.ReadAsync(ctx, key)
+	nodes := []*readNode{newPrimaryReadNode(r.GetMasterEndpoint(), r.client)}
+	if r.replicaReads {
+		replicas, err := r.orderReplicas(ReplicaSelectRandom)
+		if err != nil {
+			return "", err
+		}
+		nodes = append(replicas, nodes...)
+	}
+	result, err := readFromNodes(ctx, nodes, key)
+	return result.Value, err
+}
+
+// Topology 取得 Sentinel 回報的 Master 與 Replica，並同時 PING 每個節點
+// 無法連線或被判定為主觀下線的節點視為不健康
+func (r *RedisSentinel) Topology(ctx context.Context) (redislib.Topology, error) {
+	master, replicas, down := r.endpoints.snapshot()
+	if master == "" {
+		master = r.GetMasterEndpoint()
+	}
+
+	topology := redislib.Topology{Mode: redislib.RedisSentinel.String()}
+	topology.Nodes = append(topology.Nodes, sentinelTopologyNode(master, redislib.RoleMaster, "", down))
+	for _, replica := range replicas {
+		topology.Nodes = append(topology.Nodes, sentinelTopologyNode(replica, redislib.RoleReplica, master, down))
+	}
+
+	var wg sync.WaitGroup
+	for i := range topology.Nodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node := &topology.Nodes[i]
+			var err error
+			if node.Role == redislib.RoleMaster {
+				err = r.client.Ping(ctx).Err()
+			} else {
+				err = r.pingReplica(ctx, node.Address)
+			}
+			if err != nil {
+				node.Healthy = false
+				node.Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+	return topology, nil
+}
+
+// pingReplica 以短暫的連線 PING Replica，不建立讀取用的連線池（未啟用 replica_reads 時不會有）
+func (r *RedisSentinel) pingReplica(ctx context.Context, addr string) error {
+	client := goredis.NewClient(r.clientOpts.options(addr))
+	defer client.Close()
+	return client.Ping(ctx).Err()
+}
+
+// sentinelTopologyNode 建立 Sentinel 模式的拓樸節點
+func sentinelTopologyNode(addr string, role redislib.NodeRole, parent string, down map[string]bool) redislib.TopologyNode {
+	node := redislib.TopologyNode{Address: addr, Role: role, Healthy: !down[addr], Parent: parent}
+	if down[addr] {
+		node.Error = "marked subjectively down by sentinel"
+	}
+	return node
 }
 
 // GetMasterEndpoint 取得 Master 端點
 func (r *RedisSentinel) GetMasterEndpoint() string {
-	if r.masterEndpoint == "" {
-		return fmt.Sprintf("sentinel:%s", r.masterName)
+	if master := r.endpoints.masterEndpoint(); master != "" {
+		return master
 	}
-	return r.masterEndpoint
+	return fmt.Sprintf("sentinel:%s", r.masterName)
 }
 
-// GetSlaveEndpoint 取得 Slave 端點
+// GetSlaveEndpoint 取得第一個未下線的 Slave 端點（沒有時使用 Master）
 func (r *RedisSentinel) GetSlaveEndpoint() string {
-	if r.slaveEndpoint == "" {
-		return r.GetMasterEndpoint()
+	if slave := r.endpoints.slaveEndpoint(); slave != "" {
+		return slave
 	}
-	return r.slaveEndpoint
+	return r.GetMasterEndpoint()
+}
+
+// FailoverHistory 取得 Sentinel 回報的 Failover 與節點上下線事件（由舊到新）
+func (r *RedisSentinel) FailoverHistory() []FailoverEvent {
+	return r.endpoints.failoverHistory()
 }
 
-// Close 關閉連線
+// Close 停止事件訂閱並關閉所有連線
 func (r *RedisSentinel) Close() error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+	// 先停止健康檢查，避免檢查中使用已關閉的連線
+	if r.healthChecker != nil {
+		r.healthChecker.Stop()
+	}
+
+	r.mu.Lock()
+	r.closed = true
+	for endpoint, node := range r.replicaNodes {
+		node.client.Close()
+		delete(r.replicaNodes, endpoint)
+	}
+	r.mu.Unlock()
+
 	return r.client.Close()
 }
@@ -2,8 +2,14 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	goredis "github.com/redis/go-redis/v9"
 )
 
 func TestRedisSentinel(t *testing.T) {
@@ -102,3 +108,214 @@ func TestNewRedisSentinel_InvalidParams(t *testing.T) {
 		})
 	}
 }
+
+func TestNewRedisSentinel_InvalidReplicaSelection(t *testing.T) {
+	_, err := NewRedisSentinelWithOptions("mymaster", []string{"localhost:26379"}, SentinelOptions{
+		ReplicaReads:     true,
+		ReplicaSelection: "fastest",
+	})
+	if !errors.Is(err, redislib.ErrInvalidReadPreference) {
+		t.Errorf("NewRedisSentinelWithOptions() error = %v, want ErrInvalidReadPreference", err)
+	}
+}
+
+func TestRedisSentinel_ReplicaReadsAfterClose(t *testing.T) {
+	rs := &RedisSentinel{
+		client:       goredis.NewClient(&goredis.Options{Addr: unreachableAddr}),
+		masterName:   "mymaster",
+		endpoints:    newSentinelEndpoints("mymaster"),
+		replicaReads: true,
+		replicaNodes: make(map[string]*readNode),
+	}
+	rs.endpoints.reset(unreachableAddr, []string{"r1:6379"}, nil)
+	if err := rs.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if _, err := rs.ReadWithOptionsAsync(context.Background(), "key", redislib.ReadOptions{}); !errors.Is(err, redislib.ErrConnectionFailed) {
+		t.Errorf("ReadWithOptionsAsync() after Close error = %v, want ErrConnectionFailed", err)
+	}
+	if len(rs.replicaNodes) != 0 {
+		t.Errorf("Expected no replica connections after Close, got %d", len(rs.replicaNodes))
+	}
+}
+
+func TestRedisSentinel_TopologyPingsNodes(t *testing.T) {
+	rs := &RedisSentinel{
+		client:       goredis.NewClient(&goredis.Options{Addr: unreachableAddr, MaxRetries: -1}),
+		masterName:   "mymaster",
+		endpoints:    newSentinelEndpoints("mymaster"),
+		clientOpts:   ClientOptions{MaxRetries: -1},
+		replicaNodes: make(map[string]*readNode),
+	}
+	defer rs.Close()
+	rs.endpoints.reset(unreachableAddr, []string{"127.0.0.1:2"}, nil)
+
+	// Sentinel 沒有回報下線，但節點無法連線時仍視為不健康
+	topology, err := rs.Topology(context.Background())
+	if err != nil {
+		t.Fatalf("Topology() error = %v", err)
+	}
+	if len(topology.Nodes) != 2 {
+		t.Fatalf("Expected master and replica, got %+v", topology.Nodes)
+	}
+	for _, node := range topology.Nodes {
+		if node.Healthy || node.Error == "" {
+			t.Errorf("Expected unreachable node %s to be unhealthy with an error, got %+v", node.Address, node)
+		}
+	}
+	if len(rs.replicaNodes) != 0 {
+		t.Errorf("Expected Topology not to create replica read connections, got %d", len(rs.replicaNodes))
+	}
+}
+
+func TestRedisSentinel_OrderReplicas(t *testing.T) {
+	rs := &RedisSentinel{
+		endpoints:    newSentinelEndpoints("mymaster"),
+		replicaNodes: make(map[string]*readNode),
+	}
+	rs.endpoints.reset("m:6379", []string{"r1:6379", "r2:6379", "r3:6379", "r4:6379"}, []string{"r4:6379"})
+
+	// 預先建立讀取節點，避免測試建立實際連線
+	for _, endpoint := range []string{"r1:6379", "r2:6379", "r3:6379", "r4:6379"} {
+		rs.replicaNodes[endpoint] = newReadNode(endpoint, nil, 1)
+	}
+	rs.replicaNodes["r1:6379"].observeLatency(30 * time.Millisecond)
+	rs.replicaNodes["r3:6379"].observeLatency(10 * time.Millisecond)
+
+	order := func(selection ReplicaSelection) []string {
+		t.Helper()
+		nodes, err := rs.orderReplicas(selection)
+		if err != nil {
+			t.Fatalf("orderReplicas(%s) error = %v", selection, err)
+		}
+		return endpoints(nodes)
+	}
+
+	got := order(ReplicaSelectLatency)
+	want := []string{"r2:6379", "r3:6379", "r1:6379"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("latency order = %v, want %v", got, want)
+	}
+
+	// 隨機選擇只包含未下線的 Replica
+	random := order(ReplicaSelectRandom)
+	sort.Strings(random)
+	if want := []string{"r1:6379", "r2:6379", "r3:6379"}; !reflect.DeepEqual(random, want) {
+		t.Errorf("random order contains %v, want %v", random, want)
+	}
+}
+
+func TestRedisSentinel_HealthCheckerMeasuresLag(t *testing.T) {
+	rs := &RedisSentinel{
+		endpoints:    newSentinelEndpoints("mymaster"),
+		replicaNodes: make(map[string]*readNode),
+	}
+	rs.endpoints.reset("m:6379", []string{"r1:6379", "r2:6379", "r3:6379"}, []string{"r3:6379"})
+	for _, endpoint := range []string{"r1:6379", "r2:6379", "r3:6379"} {
+		rs.replicaNodes[endpoint] = newReadNode(endpoint, nil, 1)
+	}
+
+	master := newPrimaryReadNode("m:6379", nil)
+	offsets := map[string]int64{"r1:6379": 500, "r2:6379": 100}
+	h := newHealthChecker(master, nil, time.Second, 1)
+	h.nodes = rs.checkedReplicas
+	h.probe = func(ctx context.Context, node *readNode) probeResult {
+		if node == master {
+			return probeResult{offset: 500}
+		}
+		return probeResult{masterLinkStatus: "up", offset: offsets[node.endpoint]}
+	}
+	h.checkAll(context.Background())
+
+	if bytes, _, ok := rs.replicaNodes["r1:6379"].Lag(); !ok || bytes != 0 {
+		t.Errorf("Expected r1 caught up, got (%d, %v)", bytes, ok)
+	}
+	if bytes, _, ok := rs.replicaNodes["r2:6379"].Lag(); !ok || bytes != 400 {
+		t.Errorf("Expected r2 lag 400, got (%d, %v)", bytes, ok)
+	}
+	// 被判定為下線的 Replica 不檢查
+	if _, _, ok := rs.replicaNodes["r3:6379"].Lag(); ok {
+		t.Error("Expected r3 to stay unmeasured")
+	}
+
+	replicas, err := rs.orderReplicas(ReplicaSelectRandom)
+	if err != nil {
+		t.Fatalf("orderReplicas() error = %v", err)
+	}
+	fresh, err := filterStale(append(replicas, master), master, redislib.ReadOptions{MaxLagBytes: 100})
+	if err != nil {
+		t.Fatalf("filterStale() error = %v", err)
+	}
+	if got, want := endpoints(fresh), []string{"r1:6379", "m:6379"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filterStale() = %v, want %v", got, want)
+	}
+}
+
+// addrHook 讓 GET 直接回傳節點自己的位址，不需要實際連線
+type addrHook struct{ addr string }
+
+func (h addrHook) DialHook(next goredis.DialHook) goredis.DialHook { return next }
+
+func (h addrHook) ProcessHook(next goredis.ProcessHook) goredis.ProcessHook {
+	return func(ctx context.Context, cmd goredis.Cmder) error {
+		if get, ok := cmd.(*goredis.StringCmd); ok && cmd.Name() == "get" {
+			get.SetVal(h.addr)
+			return nil
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h addrHook) ProcessPipelineHook(next goredis.ProcessPipelineHook) goredis.ProcessPipelineHook {
+	return next
+}
+
+// newAddrClient 建立以 addrHook 回應 GET 的連線
+func newAddrClient(addr string) *goredis.Client {
+	client := goredis.NewClient(&goredis.Options{Addr: addr})
+	client.AddHook(addrHook{addr: addr})
+	return client
+}
+
+func TestRedisSentinel_ReadWithOptionsStaleness(t *testing.T) {
+	rs := &RedisSentinel{
+		client:           newAddrClient("m:6379"),
+		endpoints:        newSentinelEndpoints("mymaster"),
+		replicaReads:     true,
+		replicaSelection: ReplicaSelectLatency,
+		maxLagBytes:      100,
+		replicaNodes:     make(map[string]*readNode),
+	}
+	defer rs.Close()
+	rs.endpoints.reset("m:6379", []string{"r1:6379", "r2:6379"}, nil)
+	for _, endpoint := range []string{"r1:6379", "r2:6379"} {
+		rs.replicaNodes[endpoint] = newReadNode(endpoint, newAddrClient(endpoint), 1)
+	}
+	// r1 延遲較低但落後 400 bytes，r2 延遲較高但只落後 50 bytes
+	rs.replicaNodes["r1:6379"].observeLatency(time.Millisecond)
+	rs.replicaNodes["r1:6379"].setLag(400, time.Second)
+	rs.replicaNodes["r2:6379"].observeLatency(10 * time.Millisecond)
+	rs.replicaNodes["r2:6379"].setLag(50, 100*time.Millisecond)
+
+	tests := []struct {
+		name     string
+		opts     redislib.ReadOptions
+		wantNode string
+	}{
+		{"config bound skips lagging replica", redislib.ReadOptions{}, "r2:6379"},
+		{"request overrides config bound", redislib.ReadOptions{MaxLagBytes: redislib.NoLagLimit}, "r1:6379"},
+		{"request zero lag falls back to master", redislib.ReadOptions{MaxLagBytes: redislib.ZeroLag}, "m:6379"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rs.ReadWithOptionsAsync(context.Background(), "key", tt.opts)
+			if err != nil {
+				t.Fatalf("ReadWithOptionsAsync() error = %v", err)
+			}
+			if result.Node != tt.wantNode {
+				t.Errorf("ReadWithOptionsAsync() node = %s, want %s", result.Node, tt.wantNode)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package redis
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+// offsetHistorySize 保留的 Master offset 取樣數
+const offsetHistorySize = 64
+
+// offsetSample Master 複寫 offset 的一次取樣
+type offsetSample struct {
+	at     time.Time
+	offset int64
+}
+
+// offsetHistory 記錄 Master 複寫 offset 的歷史，用來把 Replica 的 offset 落差換算成時間落差
+type offsetHistory struct {
+	mu      sync.Mutex
+	samples []offsetSample
+}
+
+// add 新增一筆 Master offset 取樣
+func (h *offsetHistory) add(at time.Time, offset int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.samples = append(h.samples, offsetSample{at: at, offset: offset})
+	if len(h.samples) > offsetHistorySize {
+		h.samples = h.samples[len(h.samples)-offsetHistorySize:]
+	}
+}
+
+// lag 計算 Replica 相對於最新 Master offset 的落差
+// 時間落差為「最後一次確認 Replica 已追上 Master」到 now 的時間；
+// 歷史中找不到該時間點時，以最舊的取樣時間估算
+func (h *offsetHistory) lag(replicaOffset int64, now time.Time) (int64, time.Duration, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.samples) == 0 || replicaOffset < 0 {
+		return 0, 0, false
+	}
+
+	latest := h.samples[len(h.samples)-1]
+	if replicaOffset >= latest.offset {
+		return 0, 0, true
+	}
+
+	caughtUpAt := h.samples[0].at
+	for i := len(h.samples) - 1; i >= 0; i-- {
+		if h.samples[i].offset <= replicaOffset {
+			caughtUpAt = h.samples[i].at
+			break
+		}
+	}
+	return latest.offset - replicaOffset, now.Sub(caughtUpAt), true
+}
+
+// setLag 更新節點的複寫落差
+func (n *readNode) setLag(bytes int64, staleness time.Duration) {
+	n.lagBytes.Store(bytes)
+	n.staleness.Store(int64(staleness))
+}
+
+// Lag 取得節點的複寫落差，ok 為 false 表示尚未量測
+func (n *readNode) Lag() (bytes int64, staleness time.Duration, ok bool) {
+	bytes = n.lagBytes.Load()
+	staleness = time.Duration(n.staleness.Load())
+	return bytes, staleness, bytes >= 0 && staleness >= 0
+}
+
+// lagBound 將 ReadOptions 的落差上限換算為實際上限，ok 為 false 表示不限制
+func lagBound(limit int64) (bound int64, ok bool) {
+	switch {
+	case limit == redislib.ZeroLag:
+		return 0, true
+	case limit > 0:
+		return limit, true
+	default: // 0（未設定）或 redislib.NoLagLimit
+		return 0, false
+	}
+}
+
+// withinStaleness 判斷節點的複寫落差是否在上限內（尚未量測視為超過上限）
+func (n *readNode) withinStaleness(opts redislib.ReadOptions) bool {
+	bytes, staleness, ok := n.Lag()
+	if !ok {
+		return false
+	}
+	if bound, ok := lagBound(opts.MaxLagBytes); ok && bytes > bound {
+		return false
+	}
+	if bound, ok := lagBound(int64(opts.MaxStaleness)); ok && int64(staleness) > bound {
+		return false
+	}
+	return true
+}
+
+// filterStale 移除複寫落差超過上限的 Replica
+// Master 不會落後，只在讀取偏好允許（排序中包含 Master）時保留；
+// 讀取偏好為 replica 且沒有 Replica 符合上限時返回錯誤，而不是改讀 Master
+func filterStale(nodes []*readNode, master *readNode, opts redislib.ReadOptions) ([]*readNode, error) {
+	_, boundedBytes := lagBound(opts.MaxLagBytes)
+	_, boundedStaleness := lagBound(int64(opts.MaxStaleness))
+	if !boundedBytes && !boundedStaleness {
+		return nodes, nil
+	}
+
+	fresh := make([]*readNode, 0, len(nodes))
+	for _, node := range nodes {
+		if node == master || node.withinStaleness(opts) {
+			fresh = append(fresh, node)
+		}
+	}
+	if len(fresh) == 0 {
+		return nil, fmt.Errorf("%w: no replica within the requested replication lag", redislib.ErrReadFailed)
+	}
+	return fresh, nil
+}
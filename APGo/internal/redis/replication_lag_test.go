@@ -0,0 +1,97 @@
+package redis
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+func TestOffsetHistory_Lag(t *testing.T) {
+	base := time.Unix(1000, 0)
+	var h offsetHistory
+
+	if _, _, ok := h.lag(10, base); ok {
+		t.Fatal("Expected unknown lag without samples")
+	}
+
+	h.add(base, 100)
+	h.add(base.Add(time.Second), 200)
+	h.add(base.Add(2*time.Second), 300)
+	now := base.Add(3 * time.Second)
+
+	tests := []struct {
+		name          string
+		replicaOffset int64
+		wantBytes     int64
+		wantStaleness time.Duration
+		wantOK        bool
+	}{
+		{"caught up", 300, 0, 0, true},
+		{"ahead of sample", 350, 0, 0, true},
+		{"one sample behind", 250, 50, 2 * time.Second, true},
+		{"exact older sample", 100, 200, 3 * time.Second, true},
+		{"older than history", 50, 250, 3 * time.Second, true},
+		{"unknown offset", -1, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bytes, staleness, ok := h.lag(tt.replicaOffset, now)
+			if ok != tt.wantOK || bytes != tt.wantBytes || staleness != tt.wantStaleness {
+				t.Errorf("lag(%d) = (%d, %s, %v), want (%d, %s, %v)",
+					tt.replicaOffset, bytes, staleness, ok, tt.wantBytes, tt.wantStaleness, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFilterStale(t *testing.T) {
+	master := newPrimaryReadNode("m", nil)
+	fresh := newReadNode("fresh", nil, 1)
+	fresh.setLag(10, 50*time.Millisecond)
+	stale := newReadNode("stale", nil, 1)
+	stale.setLag(5000, 3*time.Second)
+	unknown := newReadNode("unknown", nil, 1)
+
+	replicas := []*readNode{stale, unknown, fresh}
+
+	tests := []struct {
+		name    string
+		nodes   []*readNode
+		opts    redislib.ReadOptions
+		want    []string
+		wantErr bool
+	}{
+		{"no bound", append(replicas, master), redislib.ReadOptions{}, []string{"stale", "unknown", "fresh", "m"}, false},
+		{"max staleness", append(replicas, master), redislib.ReadOptions{MaxStaleness: time.Second}, []string{"fresh", "m"}, false},
+		{"max lag bytes", append(replicas, master), redislib.ReadOptions{MaxLagBytes: 100}, []string{"fresh", "m"}, false},
+		{"both bounds", append(replicas, master), redislib.ReadOptions{MaxStaleness: 10 * time.Second, MaxLagBytes: 100}, []string{"fresh", "m"}, false},
+		{"tight bound", append(replicas, master), redislib.ReadOptions{MaxStaleness: time.Millisecond}, []string{"m"}, false},
+		{"replica only keeps fresh replicas", replicas, redislib.ReadOptions{MaxStaleness: time.Second}, []string{"fresh"}, false},
+		{"replica only without fresh replica", replicas, redislib.ReadOptions{MaxStaleness: time.Millisecond}, nil, true},
+		{"zero lag", append(replicas, master), redislib.ReadOptions{MaxLagBytes: redislib.ZeroLag}, []string{"m"}, false},
+		{"no limit", append(replicas, master), redislib.ReadOptions{MaxStaleness: redislib.NoLagLimit, MaxLagBytes: redislib.NoLagLimit}, []string{"stale", "unknown", "fresh", "m"}, false},
+		{"master first kept in place", []*readNode{master, fresh}, redislib.ReadOptions{MaxLagBytes: 1}, []string{"m"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := filterStale(tt.nodes, master, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("filterStale() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, redislib.ErrReadFailed) {
+					t.Errorf("filterStale() error = %v, want ErrReadFailed", err)
+				}
+				return
+			}
+			if got := endpoints(nodes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterStale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
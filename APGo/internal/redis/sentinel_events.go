@@ -0,0 +1,259 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// failoverHistorySize 保留的 Sentinel 事件筆數
+const failoverHistorySize = 100
+
+// sentinelChannels 追蹤端點時訂閱的 Sentinel 事件頻道
+var sentinelChannels = []string{"+switch-master", "+sdown", "-sdown", "+slave"}
+
+// FailoverEvent Sentinel 回報並造成端點變動的事件
+type FailoverEvent struct {
+	Time      time.Time `json:"time"`
+	Sentinel  string    `json:"sentinel"`             // 送出事件的 Sentinel
+	Event     string    `json:"event"`                // 頻道名稱，例如 +switch-master
+	Role      string    `json:"role"`                 // master 或 slave
+	Endpoint  string    `json:"endpoint"`             // 受影響的節點
+	OldMaster string    `json:"old_master,omitempty"` // +switch-master 切換前的 Master
+}
+
+// sentinelEndpoints 依 Sentinel 事件維護的 Master / Replica 端點，可同時讀寫
+type sentinelEndpoints struct {
+	mu         sync.RWMutex
+	masterName string
+	master     string
+	replicas   []string
+	down       map[string]bool // 被 Sentinel 判定為主觀下線（+sdown）的節點
+	history    []FailoverEvent
+	now        func() time.Time
+}
+
+// newSentinelEndpoints 建立空的端點狀態
+func newSentinelEndpoints(masterName string) *sentinelEndpoints {
+	return &sentinelEndpoints{
+		masterName: masterName,
+		down:       make(map[string]bool),
+		now:        time.Now,
+	}
+}
+
+// reset 以 Sentinel 查詢結果覆蓋端點狀態（不記錄事件）
+func (s *sentinelEndpoints) reset(master string, replicas []string, down []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.master = master
+	s.replicas = replicas
+	s.down = make(map[string]bool, len(down))
+	for _, endpoint := range down {
+		s.down[endpoint] = true
+	}
+}
+
+// masterEndpoint 取得目前的 Master 端點，尚未得知時返回空字串
+func (s *sentinelEndpoints) masterEndpoint() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.master
+}
+
+// slaveEndpoint 取得第一個未下線的 Replica 端點，沒有時返回空字串
+func (s *sentinelEndpoints) slaveEndpoint() string {
+	if available := s.availableReplicas(); len(available) > 0 {
+		return available[0]
+	}
+	return ""
+}
+
+// snapshot 取得目前端點狀態的複本
+func (s *sentinelEndpoints) snapshot() (master string, replicas []string, down map[string]bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	down = make(map[string]bool, len(s.down))
+	for endpoint := range s.down {
+		down[endpoint] = true
+	}
+	return s.master, append([]string(nil), s.replicas...), down
+}
+
+// availableReplicas 取得所有未下線的 Replica 端點
+func (s *sentinelEndpoints) availableReplicas() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	available := make([]string, 0, len(s.replicas))
+	for _, replica := range s.replicas {
+		if !s.down[replica] {
+			available = append(available, replica)
+		}
+	}
+	return available
+}
+
+// failoverHistory 取得事件歷史（由舊到新）
+func (s *sentinelEndpoints) failoverHistory() []FailoverEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]FailoverEvent(nil), s.history...)
+}
+
+// handleEvent 套用一筆 Sentinel 事件，返回是否需要重新向 Sentinel 查詢完整端點
+// 多個 Sentinel 會送出相同事件，只有實際改變狀態的事件會被記錄
+func (s *sentinelEndpoints) handleEvent(sentinel, channel, payload string) bool {
+	fields := strings.Fields(payload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch channel {
+	case "+switch-master":
+		// <master name> <old ip> <old port> <new ip> <new port>
+		if len(fields) < 5 || fields[0] != s.masterName {
+			return false
+		}
+		oldMaster := net.JoinHostPort(fields[1], fields[2])
+		newMaster := net.JoinHostPort(fields[3], fields[4])
+		if newMaster == s.master {
+			return false
+		}
+		s.master = newMaster
+		s.replicas = removeEndpoint(s.replicas, newMaster)
+		s.replicas = addEndpoint(s.replicas, oldMaster)
+		s.record(FailoverEvent{Sentinel: sentinel, Event: channel, Role: "master", Endpoint: newMaster, OldMaster: oldMaster})
+		return true
+
+	case "+sdown", "-sdown", "+slave":
+		// <instance type> <name> <ip> <port> @ <master name> <master ip> <master port>
+		role, endpoint, ok := s.parseInstance(fields)
+		if !ok {
+			return false
+		}
+		switch channel {
+		case "+sdown":
+			if s.down[endpoint] {
+				return false
+			}
+			s.down[endpoint] = true
+		case "-sdown":
+			if !s.down[endpoint] {
+				return false
+			}
+			delete(s.down, endpoint)
+		case "+slave":
+			if role != "slave" || containsEndpoint(s.replicas, endpoint) {
+				return false
+			}
+			s.replicas = append(s.replicas, endpoint)
+		}
+		s.record(FailoverEvent{Sentinel: sentinel, Event: channel, Role: role, Endpoint: endpoint})
+	}
+	return false
+}
+
+// parseInstance 解析事件中的節點資訊，並確認屬於追蹤中的 Master（呼叫前須持有鎖）
+func (s *sentinelEndpoints) parseInstance(fields []string) (role, endpoint string, ok bool) {
+	if len(fields) < 4 {
+		return "", "", false
+	}
+	role, endpoint = fields[0], net.JoinHostPort(fields[2], fields[3])
+
+	masterName := fields[1]
+	if role != "master" {
+		if len(fields) < 6 || fields[4] != "@" {
+			return "", "", false
+		}
+		masterName = fields[5]
+	}
+	return role, endpoint, masterName == s.masterName
+}
+
+// record 新增事件並保留最近 failoverHistorySize 筆（呼叫前須持有鎖）
+func (s *sentinelEndpoints) record(event FailoverEvent) {
+	event.Time = s.now()
+	s.history = append(s.history, event)
+	if len(s.history) > failoverHistorySize {
+		s.history = s.history[len(s.history)-failoverHistorySize:]
+	}
+}
+
+// containsEndpoint 判斷端點是否在列表中
+func containsEndpoint(endpoints []string, endpoint string) bool {
+	for _, e := range endpoints {
+		if e == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+// addEndpoint 加入不重複的端點
+func addEndpoint(endpoints []string, endpoint string) []string {
+	if containsEndpoint(endpoints, endpoint) {
+		return endpoints
+	}
+	return append(endpoints, endpoint)
+}
+
+// removeEndpoint 移除端點
+func removeEndpoint(endpoints []string, endpoint string) []string {
+	result := make([]string, 0, len(endpoints))
+	for _, e := range endpoints {
+		if e != endpoint {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// watchSentinel 訂閱單一 Sentinel 的事件並更新端點，直到 ctx 結束
+func (r *RedisSentinel) watchSentinel(ctx context.Context, addr string) {
+	defer r.wg.Done()
+
+	client := goredis.NewSentinelClient(r.sentinelClientOptions(addr))
+	defer client.Close()
+
+	pubsub := client.Subscribe(ctx, sentinelChannels...)
+	defer pubsub.Close()
+
+	messages := pubsub.ChannelWithSubscriptions()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			switch m := msg.(type) {
+			case *goredis.Subscription:
+				// 訂閱完成（包含斷線重連）後重新查詢，補上期間錯過的事件
+				if m.Kind == "subscribe" && m.Channel == sentinelChannels[0] {
+					r.refreshEndpoints(ctx)
+				}
+			case *goredis.Message:
+				if r.endpoints.handleEvent(addr, m.Channel, m.Payload) {
+					fmt.Printf("Info: sentinel %s reported %s: %s\n", addr, m.Channel, m.Payload)
+					r.refreshEndpoints(ctx)
+				}
+			}
+		}
+	}
+}
+
+// refreshEndpoints 重新查詢端點，失敗時只輸出警告並保留事件推導出的狀態
+func (r *RedisSentinel) refreshEndpoints(ctx context.Context) {
+	if err := r.updateEndpoints(ctx); err != nil && ctx.Err() == nil {
+		fmt.Printf("Warning: failed to update endpoints: %v\n", err)
+	}
+}
@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSentinelEndpoints_HandleEvent(t *testing.T) {
+	s := newSentinelEndpoints("mymaster")
+	s.reset("10.0.0.1:6379", []string{"10.0.0.2:6379", "10.0.0.3:6379"}, nil)
+
+	tests := []struct {
+		name        string
+		channel     string
+		payload     string
+		wantRefresh bool
+		wantMaster  string
+		wantSlave   string
+		wantEvents  int
+	}{
+		{"other master ignored", "+switch-master", "othermaster 10.0.0.1 6379 10.0.0.9 6379", false, "10.0.0.1:6379", "10.0.0.2:6379", 0},
+		{"replica down", "+sdown", "slave 10.0.0.2:6379 10.0.0.2 6379 @ mymaster 10.0.0.1 6379", false, "10.0.0.1:6379", "10.0.0.3:6379", 1},
+		{"duplicate from another sentinel", "+sdown", "slave 10.0.0.2:6379 10.0.0.2 6379 @ mymaster 10.0.0.1 6379", false, "10.0.0.1:6379", "10.0.0.3:6379", 1},
+		{"master down", "+sdown", "master mymaster 10.0.0.1 6379", false, "10.0.0.1:6379", "10.0.0.3:6379", 2},
+		{"switch master", "+switch-master", "mymaster 10.0.0.1 6379 10.0.0.3 6379", true, "10.0.0.3:6379", "", 3},
+		{"switch master repeated", "+switch-master", "mymaster 10.0.0.1 6379 10.0.0.3 6379", false, "10.0.0.3:6379", "", 3},
+		{"replica back", "-sdown", "slave 10.0.0.2:6379 10.0.0.2 6379 @ mymaster 10.0.0.3 6379", false, "10.0.0.3:6379", "10.0.0.2:6379", 4},
+		{"new replica", "+slave", "slave 10.0.0.4:6379 10.0.0.4 6379 @ mymaster 10.0.0.3 6379", false, "10.0.0.3:6379", "10.0.0.2:6379", 5},
+		{"malformed", "+sdown", "slave", false, "10.0.0.3:6379", "10.0.0.2:6379", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refresh := s.handleEvent("sentinel-1", tt.channel, tt.payload)
+			if refresh != tt.wantRefresh {
+				t.Errorf("handleEvent() refresh = %v, want %v", refresh, tt.wantRefresh)
+			}
+			if got := s.masterEndpoint(); got != tt.wantMaster {
+				t.Errorf("master = %s, want %s", got, tt.wantMaster)
+			}
+			if got := s.slaveEndpoint(); got != tt.wantSlave {
+				t.Errorf("slave = %s, want %s", got, tt.wantSlave)
+			}
+			if got := len(s.failoverHistory()); got != tt.wantEvents {
+				t.Errorf("history length = %d, want %d", got, tt.wantEvents)
+			}
+		})
+	}
+
+	// 切換後舊 Master 成為 Replica（仍為下線狀態），新 Master 不再列為 Replica
+	if want := []string{"10.0.0.2:6379", "10.0.0.1:6379", "10.0.0.4:6379"}; !reflect.DeepEqual(s.replicas, want) {
+		t.Errorf("replicas = %v, want %v", s.replicas, want)
+	}
+
+	switched := s.failoverHistory()[2]
+	if switched.Event != "+switch-master" || switched.Endpoint != "10.0.0.3:6379" ||
+		switched.OldMaster != "10.0.0.1:6379" || switched.Sentinel != "sentinel-1" {
+		t.Errorf("Unexpected switch-master event: %+v", switched)
+	}
+}
+
+func TestSentinelEndpoints_HistoryLimit(t *testing.T) {
+	s := newSentinelEndpoints("mymaster")
+	for i := 0; i < failoverHistorySize+10; i++ {
+		s.handleEvent("s", "+sdown", "master mymaster 10.0.0.1 6379")
+		s.handleEvent("s", "-sdown", "master mymaster 10.0.0.1 6379")
+	}
+	if got := len(s.failoverHistory()); got != failoverHistorySize {
+		t.Errorf("Expected history capped at %d, got %d", failoverHistorySize, got)
+	}
+}
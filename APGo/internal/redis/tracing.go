@@ -0,0 +1,38 @@
+package redis
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName Cluster / Raft 資訊查詢使用的 tracer 名稱
+// 未設定 tracing 時 OpenTelemetry 的全域 TracerProvider 為 no-op，不會產生 span
+const tracerName = "github.com/AmandaChou/RedisLab/APGo/internal/redis"
+
+// startInfoSpan 為 CLUSTER INFO / CLUSTER NODES / RAFT.INFO 建立 client span
+// 屬性名稱與 tracing.TracedConn 相同
+func startInfoSpan(ctx context.Context, command, mode, endpoint string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation.name", command),
+		attribute.String("redis.mode", mode),
+	}
+	if endpoint != "" {
+		attrs = append(attrs, attribute.String("redis.endpoint", endpoint))
+	}
+	return otel.Tracer(tracerName).Start(ctx, command,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endInfoSpan 記錄錯誤後結束 span
+func endInfoSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
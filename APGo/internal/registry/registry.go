@@ -0,0 +1,63 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+// Registry 以名稱管理同時存在的多個 Redis 連線（例如同時連到 Cluster 與 Sentinel 比較行為）
+type Registry struct {
+	mu    sync.RWMutex
+	names []string
+	conns map[string]redislib.IRedisConn
+}
+
+// New 建立空的 Registry
+func New() *Registry {
+	return &Registry{conns: make(map[string]redislib.IRedisConn)}
+}
+
+// Register 以 name 註冊連線，名稱重複時返回錯誤；關閉 Registry 時會一併關閉連線
+func (r *Registry) Register(name string, conn redislib.IRedisConn) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.conns[name]; ok {
+		return fmt.Errorf("backend %q is already registered", name)
+	}
+	r.names = append(r.names, name)
+	r.conns[name] = conn
+	return nil
+}
+
+// Get 取得 name 的連線
+func (r *Registry) Get(name string) (redislib.IRedisConn, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	conn, ok := r.conns[name]
+	return conn, ok
+}
+
+// Names 依註冊順序列出所有名稱
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.names...)
+}
+
+// Close 依註冊的相反順序關閉所有連線，返回所有關閉失敗的錯誤
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for i := len(r.names) - 1; i >= 0; i-- {
+		name := r.names[i]
+		if err := r.conns[name].Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	r.names, r.conns = nil, make(map[string]redislib.IRedisConn)
+	return errors.Join(errs...)
+}
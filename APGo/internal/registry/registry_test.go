@@ -0,0 +1,67 @@
+package registry
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/AmandaChou/RedisLab/APGo/internal/redis"
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+// closeRecorder 記錄關閉順序的連線
+type closeRecorder struct {
+	redislib.IRedisConn
+	name   string
+	closed *[]string
+	err    error
+}
+
+func (c *closeRecorder) Close() error {
+	*c.closed = append(*c.closed, c.name)
+	return c.err
+}
+
+func TestRegistry(t *testing.T) {
+	conn, err := redis.NewRedisInMemory("", nil, 0)
+	if err != nil {
+		t.Fatalf("NewRedisInMemory() error = %v", err)
+	}
+	defer conn.Close()
+
+	var closed []string
+	errClose := errors.New("close failed")
+	r := New()
+	for _, name := range []string{"default", "cluster", "sentinel"} {
+		c := &closeRecorder{IRedisConn: conn, name: name, closed: &closed}
+		if name == "cluster" {
+			c.err = errClose
+		}
+		if err := r.Register(name, c); err != nil {
+			t.Fatalf("Register(%s) error = %v", name, err)
+		}
+	}
+
+	if err := r.Register("cluster", conn); err == nil {
+		t.Error("Expected error when registering a duplicate name")
+	}
+	if got := r.Names(); !reflect.DeepEqual(got, []string{"default", "cluster", "sentinel"}) {
+		t.Errorf("Names() = %v, want registration order", got)
+	}
+	if _, ok := r.Get("sentinel"); !ok {
+		t.Error("Expected sentinel to be registered")
+	}
+	if _, ok := r.Get("raft"); ok {
+		t.Error("Expected raft not to be registered")
+	}
+
+	if err := r.Close(); !errors.Is(err, errClose) {
+		t.Errorf("Close() error = %v, want %v", err, errClose)
+	}
+	if !reflect.DeepEqual(closed, []string{"sentinel", "cluster", "default"}) {
+		t.Errorf("Expected connections closed in reverse order, got %v", closed)
+	}
+	if len(r.Names()) != 0 {
+		t.Errorf("Expected empty registry after Close, got %v", r.Names())
+	}
+}
@@ -0,0 +1,134 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/internal/config"
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+// verifyTimeout 新連線就緒檢查的逾時
+const verifyTimeout = 5 * time.Second
+
+// Connector 依設定建立新連線（例如 ConnectRedis 再包上 tracing / metrics 裝飾器）
+type Connector func(cfg *config.Config) (redislib.IRedisConn, error)
+
+// Reloader 在設定變更時建立新連線並替換 SwappableConn 中的連線
+// 新設定無法連線或未就緒時拒絕變更，繼續使用原本的連線
+type Reloader struct {
+	mu      sync.Mutex
+	conn    *SwappableConn
+	connect Connector
+	current *config.Config
+}
+
+// NewReloader 建立 Reloader，cfg 為建立 conn 目前連線時使用的設定
+func NewReloader(cfg *config.Config, conn *SwappableConn, connect Connector) *Reloader {
+	return &Reloader{conn: conn, connect: connect, current: cfg}
+}
+
+// Config 取得目前連線使用的設定
+func (r *Reloader) Config() *config.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Apply 以 cfg 的 Redis 設定建立並驗證新連線，成功後替換目前的連線
+// Redis 設定沒有變化時不重新連線並返回 false；server、tracing 與 redis.backends 設定需要重新啟動才會生效
+func (r *Reloader) Apply(cfg *config.Config) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.apply(cfg)
+}
+
+// Mode 取得目前連線的 Redis 模式
+func (r *Reloader) Mode() string {
+	return r.Config().Redis.Mode
+}
+
+// SwitchMode 以目前載入的 Redis 設定（各模式的節點設定）切換到 mode，返回切換前的模式
+// mode 與目前相同時不重新連線；設定檔之後再變更時以設定檔的 redis.mode 為準
+func (r *Reloader) SwitchMode(mode string) (string, error) {
+	if _, err := redislib.ParseRedisMode(mode); err != nil {
+		return "", fmt.Errorf("%w: %s", err, mode)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous := r.current.Redis.Mode
+	updated := *r.current
+	updated.Redis.Mode = mode
+	_, err := r.apply(&updated)
+	return previous, err
+}
+
+// apply 實作 Apply（呼叫前須持有鎖）
+func (r *Reloader) apply(cfg *config.Config) (bool, error) {
+	if reflect.DeepEqual(connSettings(cfg), connSettings(r.current)) {
+		return false, nil
+	}
+
+	next, err := r.connect(cfg)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to %s: %w", cfg.Redis.Mode, err)
+	}
+	if err := verify(next); err != nil {
+		next.Close()
+		return false, fmt.Errorf("%s is not ready: %w", cfg.Redis.Mode, err)
+	}
+	if err := r.conn.Swap(next); err != nil {
+		return false, err
+	}
+
+	if !reflect.DeepEqual(cfg.Server, r.current.Server) || !reflect.DeepEqual(cfg.Tracing, r.current.Tracing) ||
+		!reflect.DeepEqual(cfg.Redis.Backends, r.current.Redis.Backends) {
+		fmt.Printf("Warning: server, tracing and redis.backends settings changed but require a restart to take effect\n")
+	}
+	// 只更新預設連線的設定，其他設定仍是啟動時實際使用的值
+	updated := *r.current
+	updated.Redis = connSettings(cfg)
+	updated.Redis.Backends = r.current.Redis.Backends
+	r.current = &updated
+	return true, nil
+}
+
+// connSettings 預設連線使用的 Redis 設定；具名後端（redis.backends）在啟動時建立，需要重新啟動才會生效
+func connSettings(cfg *config.Config) config.RedisConfig {
+	settings := cfg.Redis
+	settings.Backends = nil
+	return settings
+}
+
+// OnConfigChange 處理 config.WatchConfig 的變更通知，錯誤只記錄不中斷監看
+func (r *Reloader) OnConfigChange(cfg *config.Config, err error) {
+	if err != nil {
+		fmt.Printf("Warning: rejected config change, keeping current Redis connection: %v\n", err)
+		return
+	}
+	from := r.Config().Redis.Mode
+	changed, err := r.Apply(cfg)
+	switch {
+	case err != nil:
+		fmt.Printf("Warning: rejected config change, keeping current Redis connection: %v\n", err)
+	case changed:
+		fmt.Printf("Info: reloaded Redis connection (%s -> %s)\n", from, cfg.Redis.Mode)
+	}
+}
+
+// verify 確認新連線已就緒（可以寫入且具備多數派）
+func verify(conn redislib.IRedisConn) error {
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+
+	readiness := conn.Readiness(ctx)
+	if !readiness.Ready {
+		return fmt.Errorf("%w: %s", redislib.ErrConnectionFailed, strings.Join(readiness.Reasons, "; "))
+	}
+	return nil
+}
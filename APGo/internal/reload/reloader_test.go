@@ -0,0 +1,147 @@
+package reload
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AmandaChou/RedisLab/APGo/internal/config"
+	"github.com/AmandaChou/RedisLab/APGo/internal/redis"
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+// inMemoryConfig 使用指定 Master 名稱的 RedisInMemory 設定
+func inMemoryConfig(master string) *config.Config {
+	return &config.Config{Redis: config.RedisConfig{
+		Mode:     redislib.RedisInMemory.String(),
+		InMemory: config.InMemoryConfig{Master: master},
+	}}
+}
+
+// withBackend 加上一個具名後端
+func withBackend(cfg *config.Config) *config.Config {
+	cfg.Redis.Backends = []config.BackendConfig{{Name: "other", RedisConfig: config.RedisConfig{Mode: redislib.RedisInMemory.String()}}}
+	return cfg
+}
+
+func TestReloader_Apply(t *testing.T) {
+	errUnreachable := errors.New("dial tcp: connection refused")
+	connect := func(cfg *config.Config) (redislib.IRedisConn, error) {
+		if cfg.Redis.InMemory.Master == "memory:unreachable" {
+			return nil, errUnreachable
+		}
+		return cfg.ConnectRedis()
+	}
+
+	initial := inMemoryConfig("memory:a")
+	first, err := connect(initial)
+	if err != nil {
+		t.Fatalf("connect() error = %v", err)
+	}
+	conn := NewSwappableConn(first, 0)
+	defer conn.Close()
+	reloader := NewReloader(initial, conn, connect)
+
+	tests := []struct {
+		name        string
+		cfg         *config.Config
+		wantChanged bool
+		wantErr     error
+		wantMaster  string
+	}{
+		{"unchanged config is ignored", inMemoryConfig("memory:a"), false, nil, "memory:a"},
+		{"changed config swaps the connection", inMemoryConfig("memory:b"), true, nil, "memory:b"},
+		{"backends only change is ignored", withBackend(inMemoryConfig("memory:b")), false, nil, "memory:b"},
+		{"failed connection keeps the current one", inMemoryConfig("memory:unreachable"), false, errUnreachable, "memory:b"},
+		{"invalid mode keeps the current one", &config.Config{Redis: config.RedisConfig{Mode: "Invalid"}}, false, redislib.ErrInvalidRedisMode, "memory:b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, err := reloader.Apply(tt.cfg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Apply() error = %v, want %v", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("Apply() changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if got := conn.GetMasterEndpoint(); got != tt.wantMaster {
+				t.Errorf("Expected master %s, got %s", tt.wantMaster, got)
+			}
+			if got := reloader.Config().Redis.InMemory.Master; got != tt.wantMaster {
+				t.Errorf("Expected current config master %s, got %s", tt.wantMaster, got)
+			}
+		})
+	}
+}
+
+func TestReloader_RejectsNotReady(t *testing.T) {
+	var rejected *redis.RedisInMemory
+	connect := func(cfg *config.Config) (redislib.IRedisConn, error) {
+		conn, err := redis.NewRedisInMemory(cfg.Redis.InMemory.Master, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Redis.InMemory.Master == "memory:closed" {
+			conn.Close()
+			rejected = conn
+		}
+		return conn, nil
+	}
+
+	initial := inMemoryConfig("memory:a")
+	first, _ := connect(initial)
+	conn := NewSwappableConn(first, 0)
+	defer conn.Close()
+	reloader := NewReloader(initial, conn, connect)
+
+	if _, err := reloader.Apply(inMemoryConfig("memory:closed")); !errors.Is(err, redislib.ErrConnectionFailed) {
+		t.Fatalf("Expected not-ready connection to be rejected, got %v", err)
+	}
+	if rejected == nil || conn.Current() == redislib.IRedisConn(rejected) {
+		t.Error("Expected the current connection to be kept")
+	}
+}
+
+func TestReloader_SwitchMode(t *testing.T) {
+	// 已載入的設定同時包含兩種模式的節點設定
+	initial := &config.Config{Redis: config.RedisConfig{
+		Mode:     redislib.RedisInMemory.String(),
+		InMemory: config.InMemoryConfig{Master: "memory:a"},
+		Cluster:  config.ClusterConfig{Nodes: []string{"127.0.0.1:7000"}},
+	}}
+	var connected []string
+	connect := func(cfg *config.Config) (redislib.IRedisConn, error) {
+		connected = append(connected, cfg.Redis.Mode)
+		if cfg.Redis.Mode != redislib.RedisInMemory.String() {
+			return nil, redislib.ErrConnectionFailed
+		}
+		return cfg.ConnectRedis()
+	}
+	first, err := initial.ConnectRedis()
+	if err != nil {
+		t.Fatalf("ConnectRedis() error = %v", err)
+	}
+	conn := NewSwappableConn(first, 0)
+	defer conn.Close()
+	reloader := NewReloader(initial, conn, connect)
+
+	if _, err := reloader.SwitchMode("RedisMemcached"); !errors.Is(err, redislib.ErrInvalidRedisMode) {
+		t.Errorf("Expected ErrInvalidRedisMode, got %v", err)
+	}
+	previous, err := reloader.SwitchMode(redislib.RedisCluster.String())
+	if !errors.Is(err, redislib.ErrConnectionFailed) || previous != redislib.RedisInMemory.String() {
+		t.Errorf("SwitchMode() = (%s, %v), want (RedisInMemory, ErrConnectionFailed)", previous, err)
+	}
+	if reloader.Mode() != redislib.RedisInMemory.String() || conn.Current() != first {
+		t.Errorf("Expected failed switch to keep the current connection, mode %s", reloader.Mode())
+	}
+	if _, err := reloader.SwitchMode(redislib.RedisInMemory.String()); err != nil {
+		t.Errorf("Switching to the active mode should be a no-op, got %v", err)
+	}
+	if len(connected) != 1 || connected[0] != redislib.RedisCluster.String() {
+		t.Errorf("Expected one connection attempt for RedisCluster, got %v", connected)
+	}
+	if nodes := reloader.Config().Redis.Cluster.Nodes; len(nodes) != 1 {
+		t.Errorf("Expected loaded cluster nodes to be kept, got %v", nodes)
+	}
+}
@@ -0,0 +1,244 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+// connEntry 一個被 SwappableConn 使用過的連線與其進行中的操作數
+type connEntry struct {
+	conn     redislib.IRedisConn
+	inflight sync.WaitGroup
+}
+
+// SwappableConn 可以在執行期間以新連線原子性替換的 IRedisConn
+// 每個操作開始時取得當下的連線，替換後舊連線會等到進行中的操作完成（最多 drainTimeout）才關閉，
+// 使用者（例如 CacheController）不需要知道連線被替換過
+type SwappableConn struct {
+	mu           sync.RWMutex
+	current      *connEntry
+	drainTimeout time.Duration
+	draining     sync.WaitGroup
+	closed       bool
+}
+
+// NewSwappableConn 以 conn 作為初始連線，drainTimeout <= 0 表示一直等到舊連線的操作完成
+func NewSwappableConn(conn redislib.IRedisConn, drainTimeout time.Duration) *SwappableConn {
+	return &SwappableConn{current: &connEntry{conn: conn}, drainTimeout: drainTimeout}
+}
+
+// Current 取得目前的連線
+func (s *SwappableConn) Current() redislib.IRedisConn {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current.conn
+}
+
+// Unwrap 取得目前的連線，供 redislib.Unwrap 判斷模式專屬的功能
+// 透過 Unwrap 取得的連線不計入進行中的操作，替換後可能在使用中被關閉；需要使用連線時以 Borrow 借出
+func (s *SwappableConn) Unwrap() redislib.IRedisConn {
+	return s.Current()
+}
+
+// Borrow 借出目前的連線給 fn，fn 返回前此連線計入進行中的操作，替換後會等到 fn 結束才關閉
+func (s *SwappableConn) Borrow(ctx context.Context, operation string, fn func(ctx context.Context, conn redislib.IRedisConn) error) error {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return fn(ctx, e.conn)
+}
+
+// Swap 以 next 取代目前的連線，並在背景等待舊連線進行中的操作完成後關閉舊連線
+// SwappableConn 已關閉時會關閉 next 並返回錯誤
+func (s *SwappableConn) Swap(next redislib.IRedisConn) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		next.Close()
+		return fmt.Errorf("%w: connection is closed", redislib.ErrConnectionFailed)
+	}
+	old := s.current
+	s.current = &connEntry{conn: next}
+	// 在鎖內加入計數，讓 Close 一定會等到這次排空
+	s.draining.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.draining.Done()
+		s.drain(old)
+	}()
+	return nil
+}
+
+// drain 等待舊連線進行中的操作完成後關閉，超過 drainTimeout 時直接關閉
+func (s *SwappableConn) drain(old *connEntry) {
+	done := make(chan struct{})
+	go func() {
+		old.inflight.Wait()
+		close(done)
+	}()
+
+	if s.drainTimeout > 0 {
+		timer := time.NewTimer(s.drainTimeout)
+		defer timer.Stop()
+		select {
+		case <-done:
+		case <-timer.C:
+			fmt.Printf("Warning: closing replaced Redis connection %s with operations still in flight after %s\n",
+				old.conn.GetMasterEndpoint(), s.drainTimeout)
+		}
+	} else {
+		<-done
+	}
+
+	if err := old.conn.Close(); err != nil {
+		fmt.Printf("Warning: failed to close replaced Redis connection %s: %v\n", old.conn.GetMasterEndpoint(), err)
+	}
+}
+
+// acquire 取得目前的連線並記錄一個進行中的操作，操作結束時必須呼叫 inflight.Done
+// Add 在讀鎖內執行，Swap 取得寫鎖之後舊連線就不會再增加操作，排空時的 Wait 不會漏掉任何操作
+func (s *SwappableConn) acquire() *connEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry := s.current
+	entry.inflight.Add(1)
+	return entry
+}
+
+// ReadAsync 從 Redis 讀取資料
+func (s *SwappableConn) ReadAsync(ctx context.Context, key string) (string, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.ReadAsync(ctx, key)
+}
+
+// ReadWithOptionsAsync 依讀取選項讀取資料
+func (s *SwappableConn) ReadWithOptionsAsync(ctx context.Context, key string, opts redislib.ReadOptions) (redislib.ReadResult, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.ReadWithOptionsAsync(ctx, key, opts)
+}
+
+// WriteAsync 寫入資料到 Redis
+func (s *SwappableConn) WriteAsync(ctx context.Context, key string, value string) (bool, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.WriteAsync(ctx, key, value)
+}
+
+// WriteWithTTLAsync 寫入資料並設定過期時間
+func (s *SwappableConn) WriteWithTTLAsync(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.WriteWithTTLAsync(ctx, key, value, ttl)
+}
+
+// ConsistencyTokenAsync 取得目前 Master 的一致性 Token
+// 替換連線後舊 Token 可能無法在新連線上使用，ReadWithOptionsAsync 會依新連線的規則處理
+func (s *SwappableConn) ConsistencyTokenAsync(ctx context.Context) (redislib.ConsistencyToken, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.ConsistencyTokenAsync(ctx)
+}
+
+// GetTTLAsync 取得 Key 的剩餘存活時間
+func (s *SwappableConn) GetTTLAsync(ctx context.Context, key string) (time.Duration, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.GetTTLAsync(ctx, key)
+}
+
+// ExpireAsync 變更 Key 的過期時間
+func (s *SwappableConn) ExpireAsync(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.ExpireAsync(ctx, key, ttl)
+}
+
+// PersistAsync 移除 Key 的過期時間
+func (s *SwappableConn) PersistAsync(ctx context.Context, key string) (bool, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.PersistAsync(ctx, key)
+}
+
+// DeleteAsync 刪除單一 Key
+func (s *SwappableConn) DeleteAsync(ctx context.Context, key string) (bool, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.DeleteAsync(ctx, key)
+}
+
+// DeleteManyAsync 刪除多個 Key
+func (s *SwappableConn) DeleteManyAsync(ctx context.Context, keys []string) (int64, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.DeleteManyAsync(ctx, keys)
+}
+
+// ExistsAsync 檢查 Key 是否存在
+func (s *SwappableConn) ExistsAsync(ctx context.Context, key string) (bool, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.ExistsAsync(ctx, key)
+}
+
+// BatchReadAsync 批次讀取多個 Key
+func (s *SwappableConn) BatchReadAsync(ctx context.Context, keys []string) ([]redislib.BatchResult, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.BatchReadAsync(ctx, keys)
+}
+
+// BatchWriteAsync 批次寫入多筆資料
+func (s *SwappableConn) BatchWriteAsync(ctx context.Context, entries []redislib.KeyValue) ([]redislib.BatchResult, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.BatchWriteAsync(ctx, entries)
+}
+
+// GetRandomCache 隨機取得快取資料
+func (s *SwappableConn) GetRandomCache(ctx context.Context, key string) (string, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.GetRandomCache(ctx, key)
+}
+
+// Topology 取得目前連線的部署拓樸
+func (s *SwappableConn) Topology(ctx context.Context) (redislib.Topology, error) {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.Topology(ctx)
+}
+
+// Readiness 檢查目前連線的就緒狀態
+func (s *SwappableConn) Readiness(ctx context.Context) redislib.Readiness {
+	e := s.acquire()
+	defer e.inflight.Done()
+	return e.conn.Readiness(ctx)
+}
+
+// GetMasterEndpoint 取得目前連線的 Master 端點資訊
+func (s *SwappableConn) GetMasterEndpoint() string {
+	return s.Current().GetMasterEndpoint()
+}
+
+// GetSlaveEndpoint 取得目前連線的 Slave 端點資訊
+func (s *SwappableConn) GetSlaveEndpoint() string {
+	return s.Current().GetSlaveEndpoint()
+}
+
+// Close 停止接受新的替換，等待所有被替換的連線排空後關閉目前的連線
+func (s *SwappableConn) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	current := s.current.conn
+	s.mu.Unlock()
+
+	s.draining.Wait()
+	return current.Close()
+}
@@ -0,0 +1,119 @@
+package reload
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/internal/redis"
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+)
+
+// blockingConn ReadAsync 會等到 release 關閉才返回，並記錄是否已被關閉
+type blockingConn struct {
+	redislib.IRedisConn
+	started chan struct{}
+	release chan struct{}
+	closed  atomic.Bool
+}
+
+func newBlockingConn(t *testing.T, master string) *blockingConn {
+	t.Helper()
+	conn, err := redis.NewRedisInMemory(master, nil, 0)
+	if err != nil {
+		t.Fatalf("NewRedisInMemory() error = %v", err)
+	}
+	return &blockingConn{IRedisConn: conn, started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (c *blockingConn) ReadAsync(ctx context.Context, key string) (string, error) {
+	close(c.started)
+	<-c.release
+	return c.IRedisConn.ReadAsync(ctx, key)
+}
+
+func (c *blockingConn) Close() error {
+	c.closed.Store(true)
+	return c.IRedisConn.Close()
+}
+
+func TestSwappableConn_SwapDrainsInFlight(t *testing.T) {
+	old := newBlockingConn(t, "memory:old")
+	next := newBlockingConn(t, "memory:next")
+	conn := NewSwappableConn(old, 0)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.ReadAsync(context.Background(), "key")
+	}()
+	<-old.started
+
+	if err := conn.Swap(next); err != nil {
+		t.Fatalf("Swap() error = %v", err)
+	}
+	if got := conn.GetMasterEndpoint(); got != "memory:next" {
+		t.Errorf("Expected new operations to use memory:next, got %s", got)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if old.closed.Load() {
+		t.Fatal("Old connection closed while an operation was in flight")
+	}
+
+	close(old.release)
+	<-done
+	if err := conn.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !old.closed.Load() || !next.closed.Load() {
+		t.Errorf("Expected both connections closed, old=%v next=%v", old.closed.Load(), next.closed.Load())
+	}
+}
+
+func TestSwappableConn_DrainTimeout(t *testing.T) {
+	old := newBlockingConn(t, "memory:old")
+	defer close(old.release)
+	next := newBlockingConn(t, "memory:next")
+	conn := NewSwappableConn(old, 20*time.Millisecond)
+
+	go conn.ReadAsync(context.Background(), "key")
+	<-old.started
+
+	if err := conn.Swap(next); err != nil {
+		t.Fatalf("Swap() error = %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !old.closed.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("Old connection was not closed after the drain timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSwappableConn_SwapAfterClose(t *testing.T) {
+	conn := NewSwappableConn(newBlockingConn(t, "memory:old"), 0)
+	conn.Close()
+
+	next := newBlockingConn(t, "memory:next")
+	if err := conn.Swap(next); err == nil {
+		t.Fatal("Expected Swap() after Close() to fail")
+	}
+	if !next.closed.Load() {
+		t.Error("Expected rejected connection to be closed")
+	}
+}
+
+func TestSwappableConn_Unwrap(t *testing.T) {
+	inner, err := redis.NewRedisInMemory("", nil, 0)
+	if err != nil {
+		t.Fatalf("NewRedisInMemory() error = %v", err)
+	}
+	conn := NewSwappableConn(inner, 0)
+	defer conn.Close()
+
+	if _, ok := redislib.Unwrap(conn).(*redis.RedisInMemory); !ok {
+		t.Errorf("Expected Unwrap to reach the in-memory connection, got %T", redislib.Unwrap(conn))
+	}
+}
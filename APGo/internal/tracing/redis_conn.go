@@ -0,0 +1,144 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Redis span 的屬性
+const (
+	attrMode     = attribute.Key("redis.mode")     // Redis 部署模式
+	attrEndpoint = attribute.Key("redis.endpoint") // 處理請求的節點
+	attrSlot     = attribute.Key("redis.slot")     // Key 的 hash slot
+	attrHit      = attribute.Key("redis.hit")      // 讀取時 Key 是否存在
+)
+
+// TracedConn 為讀取、寫入、GetRandomCache 與拓樸查詢建立 span 的 IRedisConn 裝飾器
+// 其他操作直接交給被包裝的連線
+type TracedConn struct {
+	redislib.IRedisConn
+	mode   string
+	tracer trace.Tracer
+}
+
+// NewTracedConn 以 mode 屬性包裝 conn
+func NewTracedConn(conn redislib.IRedisConn, mode string) *TracedConn {
+	return &TracedConn{IRedisConn: conn, mode: mode, tracer: otel.Tracer(tracerName)}
+}
+
+// Unwrap 取得被包裝的連線
+func (c *TracedConn) Unwrap() redislib.IRedisConn {
+	return c.IRedisConn
+}
+
+// Borrow 借出被包裝的連線給 fn，以 redis.<operation> span 記錄 fn 的執行，fn 內的操作為其子 span
+func (c *TracedConn) Borrow(ctx context.Context, operation string, fn func(ctx context.Context, conn redislib.IRedisConn) error) error {
+	ctx, span := c.start(ctx, "redis."+operation)
+	err := fn(ctx, c.IRedisConn)
+	end(span, c.GetMasterEndpoint(), err)
+	return err
+}
+
+// start 建立 Redis 操作的 client span
+func (c *TracedConn) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.system", "redis"), attrMode.String(c.mode))
+	return c.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// startKey 建立單一 Key 操作的 span，附上 Key 的 hash slot
+func (c *TracedConn) startKey(ctx context.Context, name, key string) (context.Context, trace.Span) {
+	return c.start(ctx, name, attrSlot.Int(redislib.KeySlot(key)))
+}
+
+// end 記錄處理的節點與錯誤後結束 span，Key 不存在不視為錯誤
+func end(span trace.Span, endpoint string, err error) {
+	defer span.End()
+	span.SetAttributes(attrEndpoint.String(endpoint))
+	switch {
+	case err == nil:
+	case errors.Is(err, redislib.ErrKeyNotFound):
+		span.SetAttributes(attrHit.Bool(false))
+	default:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// ReadAsync 從 Redis 讀取資料
+func (c *TracedConn) ReadAsync(ctx context.Context, key string) (string, error) {
+	ctx, served := redislib.WithServedBy(ctx)
+	ctx, span := c.startKey(ctx, "redis.read", key)
+	value, err := c.IRedisConn.ReadAsync(ctx, key)
+	end(span, c.node(ctx, served, key, c.GetSlaveEndpoint), err)
+	return value, err
+}
+
+// ReadWithOptionsAsync 依讀取選項讀取資料，附上讀取偏好與實際提供資料的節點
+func (c *TracedConn) ReadWithOptionsAsync(ctx context.Context, key string, opts redislib.ReadOptions) (redislib.ReadResult, error) {
+	ctx, span := c.startKey(ctx, "redis.read", key)
+	if opts.Preference != "" {
+		span.SetAttributes(attribute.String("redis.read_preference", string(opts.Preference)))
+	}
+	ctx, served := redislib.WithServedBy(ctx)
+	result, err := c.IRedisConn.ReadWithOptionsAsync(ctx, key, opts)
+	if result.LagBytes > 0 {
+		span.SetAttributes(attribute.Int64("redis.lag_bytes", result.LagBytes))
+	}
+	node := result.Node
+	if node == "" {
+		node = c.node(ctx, served, key, c.GetSlaveEndpoint)
+	}
+	end(span, node, err)
+	return result, err
+}
+
+// node 單一 Key 操作實際處理的節點，無法得知時為 fallback 的端點
+func (c *TracedConn) node(ctx context.Context, served *redislib.ServedBy, key string, fallback func() string) string {
+	return redislib.ServedNode(ctx, c.IRedisConn, served, key, fallback)
+}
+
+// WriteAsync 寫入資料到 Redis
+func (c *TracedConn) WriteAsync(ctx context.Context, key string, value string) (bool, error) {
+	ctx, served := redislib.WithServedBy(ctx)
+	ctx, span := c.startKey(ctx, "redis.write", key)
+	ok, err := c.IRedisConn.WriteAsync(ctx, key, value)
+	end(span, c.node(ctx, served, key, c.GetMasterEndpoint), err)
+	return ok, err
+}
+
+// WriteWithTTLAsync 寫入資料並設定過期時間
+func (c *TracedConn) WriteWithTTLAsync(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	ctx, served := redislib.WithServedBy(ctx)
+	ctx, span := c.startKey(ctx, "redis.write", key)
+	if ttl > 0 {
+		span.SetAttributes(attribute.Int64("redis.ttl_ms", ttl.Milliseconds()))
+	}
+	ok, err := c.IRedisConn.WriteWithTTLAsync(ctx, key, value, ttl)
+	end(span, c.node(ctx, served, key, c.GetMasterEndpoint), err)
+	return ok, err
+}
+
+// GetRandomCache 隨機取得快取資料
+func (c *TracedConn) GetRandomCache(ctx context.Context, key string) (string, error) {
+	ctx, served := redislib.WithServedBy(ctx)
+	ctx, span := c.startKey(ctx, "redis.get_random_cache", key)
+	value, err := c.IRedisConn.GetRandomCache(ctx, key)
+	end(span, c.node(ctx, served, key, c.GetSlaveEndpoint), err)
+	return value, err
+}
+
+// Topology 取得部署拓樸（Cluster 的 CLUSTER INFO / NODES 與 Raft 的 RAFT.INFO 為其子 span）
+func (c *TracedConn) Topology(ctx context.Context) (redislib.Topology, error) {
+	ctx, span := c.start(ctx, "redis.topology")
+	topology, err := c.IRedisConn.Topology(ctx)
+	span.SetAttributes(attribute.Int("redis.nodes", len(topology.Nodes)))
+	end(span, c.GetMasterEndpoint(), err)
+	return topology, err
+}
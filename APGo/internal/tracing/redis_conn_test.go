@@ -0,0 +1,215 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AmandaChou/RedisLab/APGo/internal/redis"
+	"github.com/AmandaChou/RedisLab/APGo/pkg/redislib"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTracedInMemory(t *testing.T) *TracedConn {
+	t.Helper()
+	conn, err := redis.NewRedisInMemory("memory-master", []string{"memory-replica"}, 0)
+	if err != nil {
+		t.Fatalf("Failed to create in-memory connection: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewTracedConn(conn, "RedisInMemory")
+}
+
+// spanAttr 取得 span 的屬性值
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracedConn(t *testing.T) {
+	recorder := useRecorder(t)
+	conn := newTracedInMemory(t)
+	ctx := context.Background()
+
+	if _, err := conn.WriteAsync(ctx, "user:{42}:name", "amanda"); err != nil {
+		t.Fatalf("WriteAsync failed: %v", err)
+	}
+	if value, err := conn.ReadAsync(ctx, "user:{42}:name"); err != nil || value != "amanda" {
+		t.Fatalf("ReadAsync() = %q, %v", value, err)
+	}
+	if _, err := conn.ReadAsync(ctx, "missing"); err == nil {
+		t.Fatalf("Expected ErrKeyNotFound for a missing key")
+	}
+	// 未追蹤的操作直接交給被包裝的連線
+	if ok, err := conn.ExistsAsync(ctx, "user:{42}:name"); err != nil || !ok {
+		t.Fatalf("ExistsAsync() = %v, %v", ok, err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+
+	tests := []struct {
+		span     sdktrace.ReadOnlySpan
+		name     string
+		endpoint string
+		slot     int64
+	}{
+		{spans[0], "redis.write", "memory-master", int64(redislib.KeySlot("42"))},
+		{spans[1], "redis.read", "memory-replica", int64(redislib.KeySlot("42"))},
+		{spans[2], "redis.read", "memory-replica", int64(redislib.KeySlot("missing"))},
+	}
+	for _, tt := range tests {
+		if tt.span.Name() != tt.name || tt.span.SpanKind() != trace.SpanKindClient {
+			t.Errorf("Unexpected span %q (%v), want %q", tt.span.Name(), tt.span.SpanKind(), tt.name)
+		}
+		if v, _ := spanAttr(tt.span, attrEndpoint); v.AsString() != tt.endpoint {
+			t.Errorf("%s endpoint = %q, want %q", tt.name, v.AsString(), tt.endpoint)
+		}
+		if v, _ := spanAttr(tt.span, attrSlot); v.AsInt64() != tt.slot {
+			t.Errorf("%s slot = %d, want %d", tt.name, v.AsInt64(), tt.slot)
+		}
+		if v, _ := spanAttr(tt.span, attrMode); v.AsString() != "RedisInMemory" {
+			t.Errorf("%s mode = %q", tt.name, v.AsString())
+		}
+	}
+
+	// Key 不存在不視為錯誤
+	if hit, ok := spanAttr(spans[2], attrHit); !ok || hit.AsBool() {
+		t.Errorf("Expected redis.hit=false on a missing key")
+	}
+	if spans[2].Status().Code == codes.Error {
+		t.Errorf("Expected a missing key not to mark the span as error")
+	}
+}
+
+// routedConn 在 RedisInMemory 上模擬依 Key 路由的連線（例如 Cluster），並記錄呼叫的讀取方法
+type routedConn struct {
+	*redis.RedisInMemory
+	calls []string
+}
+
+func (r *routedConn) NodeForKey(ctx context.Context, key string) string {
+	return "node-" + key
+}
+
+func (r *routedConn) ReadAsync(ctx context.Context, key string) (string, error) {
+	r.calls = append(r.calls, "ReadAsync")
+	return r.RedisInMemory.ReadAsync(ctx, key)
+}
+
+func (r *routedConn) ReadWithOptionsAsync(ctx context.Context, key string, opts redislib.ReadOptions) (redislib.ReadResult, error) {
+	r.calls = append(r.calls, "ReadWithOptionsAsync")
+	return r.RedisInMemory.ReadWithOptionsAsync(ctx, key, opts)
+}
+
+func TestTracedConn_ServedEndpoint(t *testing.T) {
+	recorder := useRecorder(t)
+	inner, err := redis.NewRedisInMemory("memory-master", []string{"memory-replica"}, 0)
+	if err != nil {
+		t.Fatalf("Failed to create in-memory connection: %v", err)
+	}
+	defer inner.Close()
+	routed := &routedConn{RedisInMemory: inner}
+	conn := NewTracedConn(routed, "RedisCluster")
+	ctx := context.Background()
+
+	if _, err := conn.WriteWithTTLAsync(ctx, "a", "v", 0); err != nil {
+		t.Fatalf("WriteWithTTLAsync failed: %v", err)
+	}
+	if _, err := conn.ReadAsync(ctx, "a"); err != nil {
+		t.Fatalf("ReadAsync failed: %v", err)
+	}
+	if _, err := conn.GetRandomCache(ctx, "a"); err != nil {
+		t.Fatalf("GetRandomCache failed: %v", err)
+	}
+
+	// ReadAsync 呼叫被包裝連線的 ReadAsync，不改變讀取路徑
+	if len(routed.calls) != 1 || routed.calls[0] != "ReadAsync" {
+		t.Errorf("Expected only ReadAsync on the wrapped connection, got %v", routed.calls)
+	}
+
+	// 沒有回報節點的寫入使用負責該 Key 的節點，讀取使用實際選到的節點
+	spans := recorder.Ended()
+	want := []string{"node-a", "memory-replica", "memory-replica"}
+	if len(spans) != len(want) {
+		t.Fatalf("Expected %d spans, got %d", len(want), len(spans))
+	}
+	for i, endpoint := range want {
+		if v, _ := spanAttr(spans[i], attrEndpoint); v.AsString() != endpoint {
+			t.Errorf("%s endpoint = %q, want %q", spans[i].Name(), v.AsString(), endpoint)
+		}
+	}
+}
+
+func TestTracedConn_Error(t *testing.T) {
+	recorder := useRecorder(t)
+	conn := newTracedInMemory(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := conn.GetRandomCache(ctx, "k"); err == nil {
+		t.Fatalf("Expected error for canceled context")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "redis.get_random_cache" || spans[0].Status().Code != codes.Error {
+		t.Fatalf("Expected an error span for GetRandomCache, got %+v", spans)
+	}
+}
+
+func TestMiddleware_Propagation(t *testing.T) {
+	recorder := useRecorder(t)
+	conn := newTracedInMemory(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/cache", func(c *gin.Context) {
+		value, err := conn.ReadAsync(c.Request.Context(), c.Query("key"))
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.String(http.StatusOK, value)
+	})
+	router.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/cache?key=k", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+	redisSpan, server, failed := spans[0], spans[1], spans[2]
+
+	if server.Name() != "GET /cache" || server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("Unexpected server span %q (%v)", server.Name(), server.SpanKind())
+	}
+	if server.SpanContext().TraceID().String() != traceID {
+		t.Errorf("Expected server span to continue trace %s, got %s", traceID, server.SpanContext().TraceID())
+	}
+	if redisSpan.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("Expected the Redis span to be a child of the server span")
+	}
+	if v, _ := spanAttr(server, "http.response.status_code"); v.AsInt64() != http.StatusNotFound {
+		t.Errorf("status code = %d, want 404", v.AsInt64())
+	}
+	if failed.Status().Code != codes.Error {
+		t.Errorf("Expected 5xx responses to mark the server span as error")
+	}
+}
@@ -0,0 +1,145 @@
+// Package tracing 以 OpenTelemetry 記錄 HTTP 請求與 Redis 操作的 span
+//
+// Setup 依設定建立 exporter 並註冊為全域 TracerProvider；未啟用時使用 OpenTelemetry 的 no-op 實作，
+// Middleware 與 TracedConn 不會產生任何 span
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// tracerName 建立 span 使用的 tracer 名稱
+const tracerName = "github.com/AmandaChou/RedisLab/APGo/internal/tracing"
+
+// DefaultServiceName 未設定 service_name 時的服務名稱
+const DefaultServiceName = "apgo"
+
+// Exporter 種類
+const (
+	ExporterNone   = "none"   // 不記錄 span（預設）
+	ExporterStdout = "stdout" // 以格式化的 JSON 輸出到標準輸出
+	ExporterFile   = "file"   // 以每行一個 JSON 的格式附加到檔案，方便離線測試
+	ExporterOTLP   = "otlp"   // 以 OTLP/HTTP 送到 Collector
+)
+
+// Options tracing 設定
+type Options struct {
+	Exporter    string  // none（預設）、stdout、file、otlp
+	File        string  // file exporter 的輸出檔案
+	Endpoint    string  // otlp exporter 的 host:port，未設定使用 OTEL_EXPORTER_OTLP_ENDPOINT 或 localhost:4318
+	Insecure    bool    // otlp exporter 不使用 TLS
+	ServiceName string  // 未設定使用 DefaultServiceName
+	SampleRatio float64 // 取樣比例（0 ~ 1），上游請求已決定取樣時依上游為準
+}
+
+// Enabled 是否有設定 exporter
+func (o Options) Enabled() bool {
+	return o.Exporter != "" && o.Exporter != ExporterNone
+}
+
+// Validate 檢查設定
+func (o Options) Validate() error {
+	switch o.Exporter {
+	case "", ExporterNone, ExporterStdout, ExporterOTLP:
+	case ExporterFile:
+		if o.File == "" {
+			return fmt.Errorf("tracing.file is required for the file exporter")
+		}
+	default:
+		return fmt.Errorf("invalid tracing exporter %q (expected none, stdout, file or otlp)", o.Exporter)
+	}
+	if o.SampleRatio < 0 || o.SampleRatio > 1 {
+		return fmt.Errorf("tracing.sample_ratio must be between 0 and 1, got %v", o.SampleRatio)
+	}
+	return nil
+}
+
+// Provider 已註冊的 TracerProvider，結束時需要呼叫 Shutdown 送出尚未輸出的 span
+type Provider struct {
+	provider *sdktrace.TracerProvider
+	closer   io.Closer // file exporter 開啟的檔案
+}
+
+// Setup 建立 exporter 並註冊為全域的 TracerProvider 與 W3C Trace Context propagator
+// 未啟用時不變更全域設定，返回的 Provider 的 Shutdown 不做任何事
+func Setup(ctx context.Context, opts Options) (*Provider, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if !opts.Enabled() {
+		return &Provider{}, nil
+	}
+
+	p := &Provider{}
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch opts.Exporter {
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterFile:
+		var file *os.File
+		file, err = os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open tracing.file: %w", err)
+		}
+		p.closer = file
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	case ExporterOTLP:
+		var otlpOpts []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			otlpOpts = append(otlpOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			otlpOpts = append(otlpOpts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, otlpOpts...)
+	}
+	if err != nil {
+		if p.closer != nil {
+			p.closer.Close()
+		}
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", opts.Exporter, err)
+	}
+
+	serviceName := opts.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	p.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(p.provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return p, nil
+}
+
+// Enabled 是否有註冊 TracerProvider
+func (p *Provider) Enabled() bool {
+	return p.provider != nil
+}
+
+// Shutdown 送出尚未輸出的 span 並關閉 exporter
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.provider == nil {
+		return nil
+	}
+	err := p.provider.Shutdown(ctx)
+	if p.closer != nil {
+		err = errors.Join(err, p.closer.Close())
+	}
+	return err
+}
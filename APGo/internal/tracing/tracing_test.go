@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// useRecorder 將全域 TracerProvider 換成記錄 span 的實作，測試結束後還原
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	restoreGlobals(t)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
+
+// restoreGlobals 測試結束後還原全域的 TracerProvider 與 propagator
+func restoreGlobals(t *testing.T) {
+	t.Helper()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{"disabled", Options{}, false},
+		{"none", Options{Exporter: ExporterNone}, false},
+		{"stdout", Options{Exporter: ExporterStdout, SampleRatio: 1}, false},
+		{"otlp", Options{Exporter: ExporterOTLP, Endpoint: "collector:4318", SampleRatio: 0.1}, false},
+		{"file", Options{Exporter: ExporterFile, File: "traces.jsonl", SampleRatio: 1}, false},
+		{"file without path", Options{Exporter: ExporterFile}, true},
+		{"unknown exporter", Options{Exporter: "jaeger"}, true},
+		{"sample ratio above 1", Options{Exporter: ExporterStdout, SampleRatio: 1.5}, true},
+		{"negative sample ratio", Options{Exporter: ExporterStdout, SampleRatio: -0.1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetup_Disabled(t *testing.T) {
+	restoreGlobals(t)
+	before := otel.GetTracerProvider()
+
+	provider, err := Setup(context.Background(), Options{})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if provider.Enabled() {
+		t.Errorf("Expected tracing to be disabled without an exporter")
+	}
+	if otel.GetTracerProvider() != before {
+		t.Errorf("Expected the global TracerProvider to be unchanged")
+	}
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown failed: %v", err)
+	}
+}
+
+func TestSetup_FileExporter(t *testing.T) {
+	restoreGlobals(t)
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+
+	provider, err := Setup(context.Background(), Options{Exporter: ExporterFile, File: path, ServiceName: "apgo-test", SampleRatio: 1})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	_, span := otel.Tracer(tracerName).Start(context.Background(), "test-span")
+	span.End()
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read trace file: %v", err)
+	}
+	for _, want := range []string{`"Name":"test-span"`, `"apgo-test"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %s in trace file, got %s", want, data)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package redislib
+
+import "time"
+
+// KeyValue 批次寫入的單筆資料
+type KeyValue struct {
+	Key   string
+	Value string
+	TTL   time.Duration // 0 表示永不過期
+}
+
+// BatchResult 批次操作中單一 Key 的結果
+// 批次操作允許部分失敗，每個 Key 各自帶有錯誤資訊
+type BatchResult struct {
+	Key   string
+	Value string // 讀取結果（僅批次讀取時有值）
+	Found bool   // Key 是否存在（僅批次讀取時有意義）
+	Node  string // 實際處理此 Key 的節點端點
+	Err   error  // 此 Key 的錯誤，nil 表示成功
+}
+
+// CountFailed 計算批次結果中失敗的數量
+func CountFailed(results []BatchResult) int {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
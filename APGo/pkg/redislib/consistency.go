@@ -0,0 +1,28 @@
+package redislib
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ConsistencyToken 讀寫一致性 Token，為寫入完成後 Master 的複寫 offset
+// 讀取時帶入此 Token，只會由複寫 offset 已追上的節點提供資料（read-your-writes）
+// 0 表示不要求一致性
+type ConsistencyToken int64
+
+// String 將 Token 轉為字串，供 API 回傳
+func (t ConsistencyToken) String() string {
+	return strconv.FormatInt(int64(t), 10)
+}
+
+// ParseConsistencyToken 從字串解析一致性 Token（空字串表示不要求一致性）
+func ParseConsistencyToken(s string) (ConsistencyToken, error) {
+	if s == "" {
+		return 0, nil
+	}
+	offset, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidConsistencyToken, s)
+	}
+	return ConsistencyToken(offset), nil
+}
@@ -5,6 +5,7 @@
 //   - RedisSentinel: 哨兵模式，自動故障轉移
 //   - RedisCluster: 叢集模式，分散式儲存
 //   - RedisRaft: Raft 共識模式，強一致性
+//   - RedisInMemory: 內嵌記憶體模式，不需網路，供離線開發與測試
 //
 // 使用範例：
 //
@@ -13,4 +13,14 @@ var (
 	ErrWriteFailed = errors.New("write failed")
 	// ErrReadFailed 讀取失敗
 	ErrReadFailed = errors.New("read failed")
+	// ErrInvalidTTL 無效的過期時間
+	ErrInvalidTTL = errors.New("invalid ttl")
+	// ErrInvalidReadPreference 無效的讀取偏好
+	ErrInvalidReadPreference = errors.New("invalid read preference")
+	// ErrInvalidConsistencyToken 無效的一致性 Token
+	ErrInvalidConsistencyToken = errors.New("invalid consistency token")
+	// ErrInvalidReply 無法解析的 Redis 回應
+	ErrInvalidReply = errors.New("invalid reply")
+	// ErrInvalidFillOptions 無效的 FillCluster 選項
+	ErrInvalidFillOptions = errors.New("invalid fill options")
 )
@@ -0,0 +1,248 @@
+package redislib
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultFillCount FillCluster 預設填充的資料筆數
+	DefaultFillCount = 100
+	// DefaultFillPrefix FillCluster 預設的 Key 前綴
+	DefaultFillPrefix = "cluster:test:key"
+	// DefaultFillConcurrency FillCluster 預設同時執行的 Pipeline 數量
+	DefaultFillConcurrency = 8
+	// DefaultFillBatchSize FillCluster 預設每個 Pipeline 寫入的筆數
+	DefaultFillBatchSize = 500
+	// MaxFillCount FillCluster 單次最多填充的資料筆數
+	MaxFillCount = 1000000
+	// MaxFillValueSize FillCluster 單筆資料的最大大小（位元組）
+	MaxFillValueSize = 1 << 20
+	// MaxFillConcurrency FillCluster 最多同時執行的 Pipeline 數量
+	MaxFillConcurrency = 64
+	// MaxFillBatchSize FillCluster 每個 Pipeline 最多寫入的筆數
+	MaxFillBatchSize = 10000
+	// MaxFillReportKeys 報告列出每個 Key 落點（IncludeKeys）時最多的填充筆數
+	MaxFillReportKeys = 10000
+	// MaxFillFailedKeys 報告最多列出的寫入失敗 Key 數量
+	MaxFillFailedKeys = 1000
+)
+
+// FillOptions FillCluster 的填充選項
+type FillOptions struct {
+	Count  int    // 填充筆數，0 表示 DefaultFillCount
+	Prefix string // Key 前綴，空字串表示 DefaultFillPrefix
+	// ValueSize 每筆資料的大小（位元組），0 表示使用 "value-<i>"
+	ValueSize int
+	// HashTag 不為空時所有 Key 都帶有 {HashTag}，會落在同一個 slot
+	HashTag string
+
+	Concurrency int // 同時執行的 Pipeline 數量，0 表示 DefaultFillConcurrency
+	BatchSize   int // 每個 Pipeline 寫入的筆數，0 表示 DefaultFillBatchSize
+
+	// IncludeKeys 報告是否列出每個 Key 的落點，Count 不得超過 MaxFillReportKeys
+	// （/fillcluster 未指定 include_keys 時，Count 在上限內即預設列出）
+	IncludeKeys bool
+}
+
+// WithDefaults 補上未指定選項的預設值
+func (o FillOptions) WithDefaults() FillOptions {
+	if o.Count == 0 {
+		o.Count = DefaultFillCount
+	}
+	if o.Prefix == "" {
+		o.Prefix = DefaultFillPrefix
+	}
+	if o.Concurrency == 0 {
+		o.Concurrency = DefaultFillConcurrency
+	}
+	if o.BatchSize == 0 {
+		o.BatchSize = DefaultFillBatchSize
+	}
+	return o
+}
+
+// Validate 檢查填充選項是否在允許範圍內
+func (o FillOptions) Validate() error {
+	if o.Count < 0 || o.Count > MaxFillCount {
+		return fmt.Errorf("%w: count must be between 1 and %d, got %d", ErrInvalidFillOptions, MaxFillCount, o.Count)
+	}
+	if o.ValueSize < 0 || o.ValueSize > MaxFillValueSize {
+		return fmt.Errorf("%w: value_size must be between 0 and %d, got %d", ErrInvalidFillOptions, MaxFillValueSize, o.ValueSize)
+	}
+	if o.Concurrency < 0 || o.Concurrency > MaxFillConcurrency {
+		return fmt.Errorf("%w: concurrency must be between 1 and %d, got %d", ErrInvalidFillOptions, MaxFillConcurrency, o.Concurrency)
+	}
+	if o.BatchSize < 0 || o.BatchSize > MaxFillBatchSize {
+		return fmt.Errorf("%w: batch_size must be between 1 and %d, got %d", ErrInvalidFillOptions, MaxFillBatchSize, o.BatchSize)
+	}
+	if o.IncludeKeys && o.Count > MaxFillReportKeys {
+		return fmt.Errorf("%w: include_keys requires count of at most %d, got %d", ErrInvalidFillOptions, MaxFillReportKeys, o.Count)
+	}
+	if strings.ContainsAny(o.HashTag, "{}") {
+		return fmt.Errorf("%w: hash_tag must not contain braces, got %q", ErrInvalidFillOptions, o.HashTag)
+	}
+	return nil
+}
+
+// Key 第 i 筆資料的 Key，格式為 <prefix>:<i> 或 <prefix>:{<hash_tag>}:<i>
+func (o FillOptions) Key(i int) string {
+	if o.HashTag != "" {
+		return fmt.Sprintf("%s:{%s}:%d", o.Prefix, o.HashTag, i)
+	}
+	return fmt.Sprintf("%s:%d", o.Prefix, i)
+}
+
+// Value 第 i 筆資料的值，指定 ValueSize 時補齊或截斷到該大小
+func (o FillOptions) Value(i int) string {
+	value := fmt.Sprintf("value-%d", i)
+	if o.ValueSize == 0 {
+		return value
+	}
+	if len(value) >= o.ValueSize {
+		return value[:o.ValueSize]
+	}
+	return value + strings.Repeat("x", o.ValueSize-len(value))
+}
+
+// FillKey 單一 Key 的落點
+type FillKey struct {
+	Key   string `json:"key"`
+	Slot  int    `json:"slot"`
+	Node  string `json:"node"`            // 負責該 slot 的 Master 位址，slot 未分配時為空字串
+	Error string `json:"error,omitempty"` // 寫入失敗的原因
+	// Skipped 填充被取消時尚未送出寫入
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// FillNodeStat 單一 Master 分配到的 Key 數量
+type FillNodeStat struct {
+	Node  string `json:"node"`
+	ID    string `json:"id"`
+	Slots int    `json:"slots"` // 該 Master 負責的 slot 數量
+	Keys  int    `json:"keys"`
+}
+
+// FillReport FillCluster 的 slot 分配報告
+type FillReport struct {
+	Count      int            `json:"count"`
+	Prefix     string         `json:"prefix"`
+	HashTag    string         `json:"hash_tag,omitempty"`
+	ValueSize  int            `json:"value_size"`
+	Keys       []FillKey      `json:"keys,omitempty"` // 每個 Key 的落點，只在 IncludeKeys 時列出
+	Nodes      []FillNodeStat `json:"nodes"`
+	Unassigned int            `json:"unassigned"` // 落在未分配 slot 的 Key 數量
+	// Skew 最多 Key 的 Master 與平均值的比例，1 表示完全平均，沒有 Master 或 Key 時為 0
+	Skew float64 `json:"skew"`
+
+	Written    int       `json:"written"`               // 寫入成功的 Key 數量
+	Failed     int       `json:"failed"`                // 寫入失敗的 Key 數量
+	Skipped    int       `json:"skipped"`               // 填充被取消而沒有送出寫入的 Key 數量
+	FailedKeys []FillKey `json:"failed_keys,omitempty"` // 寫入失敗的 Key，最多 MaxFillFailedKeys 筆
+	ElapsedMs  int64     `json:"elapsed_ms"`            // 寫入花費的時間
+	Throughput float64   `json:"throughput"`            // 每秒處理的 Key 數量
+}
+
+// FillProgress FillCluster 的寫入進度
+type FillProgress struct {
+	Total      int     `json:"total"`
+	Written    int     `json:"written"`
+	Failed     int     `json:"failed"`
+	Skipped    int     `json:"skipped"` // 填充被取消而沒有送出寫入的 Key 數量
+	ElapsedMs  int64   `json:"elapsed_ms"`
+	Throughput float64 `json:"throughput"` // 每秒處理（成功與失敗）的 Key 數量
+}
+
+// NewFillProgress 依已處理的數量與經過時間計算進度與吞吐量
+func NewFillProgress(total, written, failed, skipped int, elapsed time.Duration) FillProgress {
+	progress := FillProgress{
+		Total:     total,
+		Written:   written,
+		Failed:    failed,
+		Skipped:   skipped,
+		ElapsedMs: elapsed.Milliseconds(),
+	}
+	if elapsed > 0 {
+		progress.Throughput = float64(written+failed) / elapsed.Seconds()
+	}
+	return progress
+}
+
+// NewFillReport 依 CLUSTER NODES 的 slot 分配計算每個 Key 的落點與各 Master 的數量
+// 返回的落點與 keys 順序相同；opts.IncludeKeys 時報告的 Keys 與其共用
+func NewFillReport(opts FillOptions, keys []string, nodes []ClusterNode) (FillReport, []FillKey) {
+	report := FillReport{
+		Count:     len(keys),
+		Prefix:    opts.Prefix,
+		HashTag:   opts.HashTag,
+		ValueSize: opts.ValueSize,
+	}
+	placements := make([]FillKey, 0, len(keys))
+
+	var owners [ClusterSlots]int // 負責該 slot 的 Master 在 report.Nodes 中的位置 +1，0 表示未分配
+	for _, node := range nodes {
+		if node.Role != "master" || node.Failing {
+			continue
+		}
+		report.Nodes = append(report.Nodes, FillNodeStat{Node: node.Addr, ID: node.ID, Slots: node.SlotCount()})
+		for _, r := range node.Slots {
+			for slot := r.Start; slot <= r.End && slot < ClusterSlots; slot++ {
+				owners[slot] = len(report.Nodes)
+			}
+		}
+	}
+
+	for _, key := range keys {
+		slot := KeySlot(key)
+		fillKey := FillKey{Key: key, Slot: slot}
+		if owner := owners[slot]; owner > 0 {
+			fillKey.Node = report.Nodes[owner-1].Node
+			report.Nodes[owner-1].Keys++
+		} else {
+			report.Unassigned++
+		}
+		placements = append(placements, fillKey)
+	}
+
+	sort.SliceStable(report.Nodes, func(i, j int) bool { return report.Nodes[i].Node < report.Nodes[j].Node })
+	report.Skew = fillSkew(report.Nodes)
+	if opts.IncludeKeys {
+		report.Keys = placements
+	}
+	return report, placements
+}
+
+// Complete 依最終進度補上寫入統計，並列出最多 MaxFillFailedKeys 筆寫入失敗的 Key
+func (r *FillReport) Complete(placements []FillKey, final FillProgress) {
+	r.Written = final.Written
+	r.Failed = final.Failed
+	r.Skipped = final.Skipped
+	r.ElapsedMs = final.ElapsedMs
+	r.Throughput = final.Throughput
+	for _, key := range placements {
+		if len(r.FailedKeys) == MaxFillFailedKeys {
+			break
+		}
+		if key.Error != "" {
+			r.FailedKeys = append(r.FailedKeys, key)
+		}
+	}
+}
+
+// fillSkew 計算最多 Key 的 Master 與平均值的比例
+func fillSkew(nodes []FillNodeStat) float64 {
+	if len(nodes) == 0 {
+		return 0
+	}
+	total, most := 0, 0
+	for _, node := range nodes {
+		total += node.Keys
+		most = max(most, node.Keys)
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(most) * float64(len(nodes)) / float64(total)
+}
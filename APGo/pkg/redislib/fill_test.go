@@ -0,0 +1,129 @@
+package redislib
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFillOptions(t *testing.T) {
+	opts := FillOptions{}.WithDefaults()
+	if opts.Count != DefaultFillCount || opts.Prefix != DefaultFillPrefix {
+		t.Fatalf("Unexpected defaults: %+v", opts)
+	}
+	if got := opts.Key(3); got != "cluster:test:key:3" {
+		t.Errorf("Key(3) = %q", got)
+	}
+	if got := opts.Value(3); got != "value-3" {
+		t.Errorf("Value(3) = %q", got)
+	}
+
+	opts = FillOptions{Prefix: "p", HashTag: "user", ValueSize: 10}.WithDefaults()
+	if got := opts.Key(7); got != "p:{user}:7" {
+		t.Errorf("Key(7) with hash tag = %q", got)
+	}
+	if got := opts.Value(7); got != "value-7xxx" {
+		t.Errorf("Value(7) with value size = %q", got)
+	}
+	if got := (FillOptions{ValueSize: 3}).Value(12); got != "val" {
+		t.Errorf("Value(12) truncated = %q", got)
+	}
+
+	invalid := []FillOptions{
+		{Count: -1},
+		{Count: MaxFillCount + 1},
+		{Count: 1, ValueSize: -1},
+		{Count: 1, ValueSize: MaxFillValueSize + 1},
+		{Count: 1, HashTag: "a}b"},
+		{Count: MaxFillReportKeys + 1, IncludeKeys: true},
+	}
+	for _, o := range invalid {
+		if err := o.Validate(); !errors.Is(err, ErrInvalidFillOptions) {
+			t.Errorf("Validate(%+v) = %v, want ErrInvalidFillOptions", o, err)
+		}
+	}
+}
+
+func TestNewFillReport(t *testing.T) {
+	nodes := []ClusterNode{
+		{ID: "b", Addr: "127.0.0.1:30002", Role: "master", Slots: []SlotRange{{Start: 8192, End: 16383}}},
+		{ID: "a", Addr: "127.0.0.1:30001", Role: "master", Slots: []SlotRange{{Start: 0, End: 8191}}},
+		{ID: "c", Addr: "127.0.0.1:30003", Role: "replica", MasterID: "a"},
+	}
+
+	opts := FillOptions{}.WithDefaults()
+	keys := make([]string, opts.Count)
+	for i := range keys {
+		keys[i] = opts.Key(i)
+	}
+
+	report, placements := NewFillReport(opts, keys, nodes)
+	if report.Count != len(keys) || len(placements) != len(keys) || report.Unassigned != 0 {
+		t.Fatalf("Unexpected report: count=%d keys=%d unassigned=%d", report.Count, len(placements), report.Unassigned)
+	}
+	if report.Keys != nil {
+		t.Errorf("Expected no per-key list without IncludeKeys, got %d keys", len(report.Keys))
+	}
+	if len(report.Nodes) != 2 || report.Nodes[0].Node != "127.0.0.1:30001" || report.Nodes[0].Slots != 8192 {
+		t.Fatalf("Unexpected nodes: %+v", report.Nodes)
+	}
+
+	want := map[string]int{}
+	for _, key := range placements {
+		if key.Slot != KeySlot(key.Key) {
+			t.Errorf("Key %s: slot %d, want %d", key.Key, key.Slot, KeySlot(key.Key))
+		}
+		owner := "127.0.0.1:30001"
+		if key.Slot >= 8192 {
+			owner = "127.0.0.1:30002"
+		}
+		if key.Node != owner {
+			t.Errorf("Key %s (slot %d): node %s, want %s", key.Key, key.Slot, key.Node, owner)
+		}
+		want[owner]++
+	}
+	for _, node := range report.Nodes {
+		if node.Keys != want[node.Node] {
+			t.Errorf("Node %s: %d keys, want %d", node.Node, node.Keys, want[node.Node])
+		}
+	}
+	if report.Skew < 1 || report.Skew > 2 {
+		t.Errorf("Skew = %f, want between 1 and 2", report.Skew)
+	}
+
+	// 所有 Key 共用 hash tag 時全部落在同一個 Master，偏斜為 Master 數量
+	tagged := FillOptions{HashTag: "same"}.WithDefaults()
+	for i := range keys {
+		keys[i] = tagged.Key(i)
+	}
+	if report, _ := NewFillReport(tagged, keys, nodes); report.Skew != 2 {
+		t.Errorf("Skew with hash tag = %f, want 2", report.Skew)
+	}
+
+	if report, _ := NewFillReport(opts, keys, nil); report.Unassigned != len(keys) || report.Skew != 0 {
+		t.Errorf("Expected all keys unassigned without masters, got %+v", report.Unassigned)
+	}
+
+	opts.IncludeKeys = true
+	if report, placements := NewFillReport(opts, keys, nodes); len(report.Keys) != len(keys) || &report.Keys[0] != &placements[0] {
+		t.Errorf("Expected IncludeKeys to list every key, got %d keys", len(report.Keys))
+	}
+}
+
+func TestFillReport_Complete(t *testing.T) {
+	placements := make([]FillKey, MaxFillFailedKeys+10)
+	for i := range placements {
+		placements[i] = FillKey{Key: "k", Error: "write failed"}
+	}
+	placements[0].Error = ""
+
+	var report FillReport
+	report.Complete(placements, FillProgress{Total: len(placements) + 5, Written: 1, Failed: len(placements) - 1, Skipped: 5, ElapsedMs: 10})
+
+	// 失敗的 Key 最多列出 MaxFillFailedKeys 筆，數量仍為完整的統計
+	if len(report.FailedKeys) != MaxFillFailedKeys || report.FailedKeys[0].Error == "" {
+		t.Errorf("Expected %d failed keys, got %d", MaxFillFailedKeys, len(report.FailedKeys))
+	}
+	if report.Written != 1 || report.Failed != len(placements)-1 || report.Skipped != 5 || report.ElapsedMs != 10 {
+		t.Errorf("Unexpected totals: %+v", report)
+	}
+}
@@ -0,0 +1,38 @@
+package redislib
+
+import "strings"
+
+// ClusterSlots Redis Cluster 的 hash slot 總數
+const ClusterSlots = 16384
+
+// KeySlot 計算 Key 所屬的 hash slot（與 Redis Cluster 的 CLUSTER KEYSLOT 相同）
+// Key 中若含有 {hash tag}，只會以大括號內的內容計算
+func KeySlot(key string) int {
+	return int(crc16(hashTag(key)) % ClusterSlots)
+}
+
+// hashTag 取出 Key 中的 hash tag，沒有 hash tag 時返回原 Key
+func hashTag(key string) string {
+	if s := strings.IndexByte(key, '{'); s > -1 {
+		if e := strings.IndexByte(key[s+1:], '}'); e > 0 {
+			return key[s+1 : s+e+1]
+		}
+	}
+	return key
+}
+
+// crc16 CRC16-CCITT (XMODEM) 實作，參考 Redis Cluster 規格附錄 A
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
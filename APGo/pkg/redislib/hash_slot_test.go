@@ -0,0 +1,24 @@
+package redislib
+
+import "testing"
+
+func TestKeySlot(t *testing.T) {
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"123456789", 12739}, // CRC16 規格的檢查值 0x31C3
+		{"foo", 12182},
+		{"bar", 5061},
+		{"{user1000}.following", KeySlot("user1000")},
+		{"{user1000}.followers", KeySlot("user1000")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := KeySlot(tt.key); got != tt.want {
+				t.Errorf("KeySlot(%q) = %d, want %d", tt.key, got, tt.want)
+			}
+		})
+	}
+}
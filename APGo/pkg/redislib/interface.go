@@ -1,6 +1,9 @@
 package redislib
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // IRedisConn 定義 Redis 連線介面
 // 對應 C# 的 IRedisConn 介面
@@ -8,12 +11,54 @@ type IRedisConn interface {
 	// ReadAsync 從 Redis 讀取資料（通常從 Slave 讀取）
 	ReadAsync(ctx context.Context, key string) (string, error)
 
+	// ReadWithOptionsAsync 依讀取選項讀取資料，並回報實際提供資料的節點
+	ReadWithOptionsAsync(ctx context.Context, key string, opts ReadOptions) (ReadResult, error)
+
 	// WriteAsync 寫入資料到 Redis（通常寫入 Master）
 	WriteAsync(ctx context.Context, key string, value string) (bool, error)
 
+	// WriteWithTTLAsync 寫入資料並設定過期時間（ttl <= 0 表示永不過期）
+	WriteWithTTLAsync(ctx context.Context, key string, value string, ttl time.Duration) (bool, error)
+
+	// ConsistencyTokenAsync 取得目前 Master 的一致性 Token
+	// 在寫入後呼叫，將 Token 帶入 ReadOptions.After 即可讀到自己的寫入
+	ConsistencyTokenAsync(ctx context.Context) (ConsistencyToken, error)
+
+	// GetTTLAsync 取得 Key 的剩餘存活時間（沒有過期時間時返回 NoTTL）
+	GetTTLAsync(ctx context.Context, key string) (time.Duration, error)
+
+	// ExpireAsync 變更 Key 的過期時間，Key 不存在時返回 ErrKeyNotFound
+	ExpireAsync(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// PersistAsync 移除 Key 的過期時間，使其永久保存
+	// 本來就沒有過期時間時返回 false，Key 不存在時返回 ErrKeyNotFound
+	PersistAsync(ctx context.Context, key string) (bool, error)
+
+	// DeleteAsync 刪除單一 Key（Key 不存在時返回 false）
+	DeleteAsync(ctx context.Context, key string) (bool, error)
+
+	// DeleteManyAsync 刪除多個 Key，返回實際刪除的數量
+	DeleteManyAsync(ctx context.Context, keys []string) (int64, error)
+
+	// ExistsAsync 檢查 Key 是否存在
+	ExistsAsync(ctx context.Context, key string) (bool, error)
+
+	// BatchReadAsync 批次讀取多個 Key，結果順序與輸入相同
+	BatchReadAsync(ctx context.Context, keys []string) ([]BatchResult, error)
+
+	// BatchWriteAsync 批次寫入多筆資料，結果順序與輸入相同
+	BatchWriteAsync(ctx context.Context, entries []KeyValue) ([]BatchResult, error)
+
 	// GetRandomCache 隨機取得快取資料
 	GetRandomCache(ctx context.Context, key string) (string, error)
 
+	// Topology 取得部署拓樸：各節點的位址、角色、健康狀態、複寫來源與 slot / Raft 資訊
+	Topology(ctx context.Context) (Topology, error)
+
+	// Readiness 依模式實際檢查 Master、Replica、Sentinel、Cluster 節點或 Raft Leader，回報各節點狀態與延遲
+	// 節點檢查失敗記錄在結果中，不會返回錯誤
+	Readiness(ctx context.Context) Readiness
+
 	// GetMasterEndpoint 取得 Master 端點資訊
 	GetMasterEndpoint() string
 
@@ -23,3 +68,37 @@ type IRedisConn interface {
 	// Close 關閉連線
 	Close() error
 }
+
+// Unwrap 取得被裝飾器（例如 metrics）包裝的原始連線，沒有包裝時返回 conn 本身
+// 裝飾器以 Unwrap() IRedisConn 方法提供被包裝的連線；模式專屬的功能需要以原始連線判斷型別
+func Unwrap(conn IRedisConn) IRedisConn {
+	for {
+		wrapper, ok := conn.(interface{ Unwrap() IRedisConn })
+		if !ok {
+			return conn
+		}
+		conn = wrapper.Unwrap()
+	}
+}
+
+// Borrower 由裝飾器實作，在借出原始連線給模式專屬的操作期間加上自己的處理
+// （例如計入進行中的操作、記錄指標或建立 span）
+type Borrower interface {
+	// Borrow 在 operation 執行期間借出被包裝的連線，fn 返回後才歸還
+	Borrow(ctx context.Context, operation string, fn func(ctx context.Context, conn IRedisConn) error) error
+}
+
+// Borrow 逐層借出 conn 包裝的原始連線給 fn，返回 fn 的錯誤
+// 與 Unwrap 不同，每一層 Borrower 裝飾器都會處理這次操作，
+// 例如 fn 執行期間連線不會因為熱重載被關閉；只需要判斷型別時才使用 Unwrap
+func Borrow(ctx context.Context, conn IRedisConn, operation string, fn func(ctx context.Context, conn IRedisConn) error) error {
+	if borrower, ok := conn.(Borrower); ok {
+		return borrower.Borrow(ctx, operation, func(ctx context.Context, inner IRedisConn) error {
+			return Borrow(ctx, inner, operation, fn)
+		})
+	}
+	if wrapper, ok := conn.(interface{ Unwrap() IRedisConn }); ok {
+		return Borrow(ctx, wrapper.Unwrap(), operation, fn)
+	}
+	return fn(ctx, conn)
+}
@@ -0,0 +1,20 @@
+package redislib
+
+// PoolStats 單一端點連線池的統計
+// Hits / Misses / Timeouts 為累計值，連線數為取得當下的數量
+type PoolStats struct {
+	Endpoint   string
+	Hits       uint64 // 從連線池取得閒置連線的次數
+	Misses     uint64 // 連線池沒有閒置連線而需要建立新連線的次數
+	Timeouts   uint64 // 等待連線逾時的次數
+	TotalConns uint64
+	IdleConns  uint64
+	StaleConns uint64 // 因閒置過久被移除的連線數（累計）
+}
+
+// PoolStatsProvider 可回報連線池統計的 IRedisConn 實作
+// 不需要網路的實作（例如 RedisInMemory）不必實作此介面
+type PoolStatsProvider interface {
+	// PoolStats 取得各端點的連線池統計，不應發出網路請求
+	PoolStats() []PoolStats
+}
@@ -0,0 +1,73 @@
+package redislib
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReadPreference 讀取偏好，決定讀取時嘗試節點的順序
+type ReadPreference string
+
+const (
+	// ReadPrimary 只從 Master 讀取
+	ReadPrimary ReadPreference = "primary"
+	// ReadPrimaryPreferred 優先從 Master 讀取，失敗時改讀 Replica
+	ReadPrimaryPreferred ReadPreference = "primary_preferred"
+	// ReadReplica 只從 Replica 讀取
+	ReadReplica ReadPreference = "replica"
+	// ReadReplicaPreferred 優先從 Replica 讀取，沒有可用 Replica 時改讀 Master（預設）
+	ReadReplicaPreferred ReadPreference = "replica_preferred"
+	// ReadNearest 從量測延遲最低的節點讀取（包含 Master）
+	ReadNearest ReadPreference = "nearest"
+	// ReadRoundRobin 依序輪流從 Replica 讀取
+	ReadRoundRobin ReadPreference = "round_robin"
+	// ReadWeighted 依權重隨機選擇 Replica
+	ReadWeighted ReadPreference = "weighted"
+)
+
+// ParseReadPreference 從字串解析讀取偏好（空字串表示預設的 replica_preferred）
+func ParseReadPreference(s string) (ReadPreference, error) {
+	switch p := ReadPreference(s); p {
+	case "":
+		return ReadReplicaPreferred, nil
+	case ReadPrimary, ReadPrimaryPreferred, ReadReplica, ReadReplicaPreferred,
+		ReadNearest, ReadRoundRobin, ReadWeighted:
+		return p, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidReadPreference, s)
+	}
+}
+
+// ReadOptions.MaxStaleness / MaxLagBytes 的特殊值，0 已用來表示「使用連線的設定值」
+const (
+	// NoLagLimit 不限制複寫落差
+	NoLagLimit = -1
+	// ZeroLag 只接受已追上 Master（落差為 0）的 Replica
+	ZeroLag = -2
+)
+
+// ReadOptions 單次讀取的選項
+type ReadOptions struct {
+	// Preference 覆寫本次讀取的讀取偏好，空字串表示使用連線的設定值
+	// 只有支援讀寫分離的模式會參考此設定
+	Preference ReadPreference
+
+	// MaxStaleness 可接受的最大複寫時間落差，0 表示使用連線的設定值，NoLagLimit / ZeroLag 覆寫連線的設定值
+	MaxStaleness time.Duration
+	// MaxLagBytes 可接受的最大複寫 offset 落差（位元組），0 表示使用連線的設定值，NoLagLimit / ZeroLag 覆寫連線的設定值
+	MaxLagBytes int64
+
+	// After 寫入時取得的一致性 Token，只從複寫 offset 已達到此值的節點讀取，0 表示不要求
+	After ConsistencyToken
+}
+
+// ReadResult 讀取結果
+type ReadResult struct {
+	Value string
+	Node  string // 實際提供資料的節點端點
+
+	// LagBytes 提供資料節點的複寫 offset 落差，Master 為 0，-1 表示未知
+	LagBytes int64
+	// Staleness 提供資料節點的複寫時間落差，Master 為 0，-1 表示未知
+	Staleness time.Duration
+}
@@ -0,0 +1,59 @@
+package redislib
+
+import "fmt"
+
+// RoleSentinel Sentinel 節點（只出現在就緒檢查中）
+const RoleSentinel NodeRole = "sentinel"
+
+// NodeCheck 就緒檢查中單一節點的結果
+type NodeCheck struct {
+	Address   string   `json:"address"`
+	Role      NodeRole `json:"role"`
+	Healthy   bool     `json:"healthy"`
+	LatencyMs float64  `json:"latency_ms"` // 檢查指令的往返時間，失敗時為等到失敗的時間
+	Error     string   `json:"error,omitempty"`
+}
+
+// Readiness 就緒檢查結果
+// Ready 需要同時具備寫入能力（Writable）與多數派（Quorum）
+type Readiness struct {
+	Mode     string      `json:"mode"`
+	Ready    bool        `json:"ready"`
+	Writable bool        `json:"writable"` // 負責寫入的 Master / Leader 有回應且可以寫入
+	Quorum   bool        `json:"quorum"`   // Sentinel / Cluster / Raft 的多數派仍然可用，沒有多數派的模式永遠為 true
+	Reasons  []string    `json:"reasons,omitempty"`
+	Nodes    []NodeCheck `json:"nodes"`
+}
+
+// NewReadiness 建立就緒檢查結果，預設具備寫入能力與多數派，再以 LoseWrites / LoseQuorum 標記失敗
+func NewReadiness(mode string, nodes []NodeCheck) Readiness {
+	return Readiness{Mode: mode, Ready: true, Writable: true, Quorum: true, Nodes: nodes}
+}
+
+// LoseWrites 標記失去寫入能力並記錄原因
+func (r *Readiness) LoseWrites(format string, args ...any) {
+	r.Writable, r.Ready = false, false
+	r.Reasons = append(r.Reasons, fmt.Sprintf(format, args...))
+}
+
+// LoseQuorum 標記失去多數派並記錄原因
+func (r *Readiness) LoseQuorum(format string, args ...any) {
+	r.Quorum, r.Ready = false, false
+	r.Reasons = append(r.Reasons, fmt.Sprintf(format, args...))
+}
+
+// HealthyCount 健康的節點數
+func (r Readiness) HealthyCount() int {
+	count := 0
+	for _, node := range r.Nodes {
+		if node.Healthy {
+			count++
+		}
+	}
+	return count
+}
+
+// Majority n 個節點的多數派數量
+func Majority(n int) int {
+	return n/2 + 1
+}
@@ -0,0 +1,53 @@
+package redislib
+
+import "testing"
+
+func TestReadiness(t *testing.T) {
+	nodes := []NodeCheck{
+		{Address: "node-a:6379", Role: RoleLeader, Healthy: true},
+		{Address: "node-b:6379", Role: RoleFollower, Healthy: true},
+		{Address: "node-c:6379", Role: RoleFollower, Error: "connection refused"},
+	}
+
+	tests := []struct {
+		name         string
+		mark         func(r *Readiness)
+		wantReady    bool
+		wantWritable bool
+		wantQuorum   bool
+		wantReasons  int
+	}{
+		{"ready by default", func(r *Readiness) {}, true, true, true, 0},
+		{"lose writes", func(r *Readiness) { r.LoseWrites("leader %s is unreachable", "node-a:6379") }, false, false, true, 1},
+		{"lose quorum", func(r *Readiness) { r.LoseQuorum("only %d nodes are up", 1) }, false, true, false, 1},
+		{"lose both", func(r *Readiness) {
+			r.LoseWrites("no leader")
+			r.LoseQuorum("no majority")
+		}, false, false, false, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readiness := NewReadiness("Raft", nodes)
+			tt.mark(&readiness)
+			if readiness.Ready != tt.wantReady || readiness.Writable != tt.wantWritable || readiness.Quorum != tt.wantQuorum {
+				t.Errorf("Expected ready=%v writable=%v quorum=%v, got %+v",
+					tt.wantReady, tt.wantWritable, tt.wantQuorum, readiness)
+			}
+			if len(readiness.Reasons) != tt.wantReasons {
+				t.Errorf("Expected %d reasons, got %v", tt.wantReasons, readiness.Reasons)
+			}
+			if readiness.HealthyCount() != 2 {
+				t.Errorf("Expected 2 healthy nodes, got %d", readiness.HealthyCount())
+			}
+		})
+	}
+}
+
+func TestMajority(t *testing.T) {
+	for n, want := range map[int]int{1: 1, 2: 2, 3: 2, 4: 3, 5: 3} {
+		if got := Majority(n); got != want {
+			t.Errorf("Majority(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
@@ -12,6 +12,8 @@ const (
 	RedisCluster
 	// RedisRaft Raft 共識模式
 	RedisRaft
+	// RedisInMemory 內嵌記憶體模式（不需網路，供離線開發與測試使用）
+	RedisInMemory
 )
 
 // String 返回 RedisMode 的字串表示
@@ -25,6 +27,8 @@ func (m RedisMode) String() string {
 		return "RedisCluster"
 	case RedisRaft:
 		return "RedisRaft"
+	case RedisInMemory:
+		return "RedisInMemory"
 	default:
 		return "Unknown"
 	}
@@ -41,6 +45,8 @@ func ParseRedisMode(s string) (RedisMode, error) {
 		return RedisCluster, nil
 	case "RedisRaft":
 		return RedisRaft, nil
+	case "RedisInMemory":
+		return RedisInMemory, nil
 	default:
 		return -1, ErrInvalidRedisMode
 	}
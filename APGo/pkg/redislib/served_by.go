@@ -0,0 +1,60 @@
+package redislib
+
+import (
+	"context"
+	"sync"
+)
+
+// servedByKey ServedBy 在 context 中的鍵
+type servedByKey struct{}
+
+// ServedBy 記錄實際處理一次操作的節點
+// 裝飾器（metrics、tracing）以 WithServedBy 放入 context，連線實作選定節點後以 RecordServedBy 回報，
+// 不需要改變呼叫的方法（例如 ReadAsync 不必改呼叫 ReadWithOptionsAsync）就能取得節點
+type ServedBy struct {
+	mu   sync.Mutex
+	node string
+}
+
+// WithServedBy 返回帶有 ServedBy 的 context；ctx 已帶有時沿用，讓多層裝飾器取得同一個結果
+func WithServedBy(ctx context.Context) (context.Context, *ServedBy) {
+	if served, ok := ctx.Value(servedByKey{}).(*ServedBy); ok {
+		return ctx, served
+	}
+	served := &ServedBy{}
+	return context.WithValue(ctx, servedByKey{}, served), served
+}
+
+// Node 取得最後回報的節點，沒有回報時返回空字串
+func (s *ServedBy) Node() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.node
+}
+
+// RecordServedBy 回報處理操作的節點，ctx 沒有 ServedBy 時不做任何事
+func RecordServedBy(ctx context.Context, node string) {
+	if served, ok := ctx.Value(servedByKey{}).(*ServedBy); ok {
+		served.mu.Lock()
+		served.node = node
+		served.mu.Unlock()
+	}
+}
+
+// KeyRouter 依 Key 將操作路由到不同節點的 IRedisConn 實作（例如 Cluster）
+type KeyRouter interface {
+	// NodeForKey 取得負責 key 的節點端點，應使用快取的路由資訊而不是每次查詢
+	NodeForKey(ctx context.Context, key string) string
+}
+
+// ServedNode 取得單一 Key 操作的處理節點：served 回報的節點；沒有回報時 conn 為 KeyRouter 則為負責 key 的節點，
+// 否則為 fallback 返回的端點
+func ServedNode(ctx context.Context, conn IRedisConn, served *ServedBy, key string, fallback func() string) string {
+	if node := served.Node(); node != "" {
+		return node
+	}
+	if router, ok := Unwrap(conn).(KeyRouter); ok {
+		return router.NodeForKey(ctx, key)
+	}
+	return fallback()
+}
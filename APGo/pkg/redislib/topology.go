@@ -0,0 +1,398 @@
+package redislib
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NodeRole 節點在部署中的角色
+type NodeRole string
+
+const (
+	// RoleMaster 負責寫入的 Master（Cluster 模式為負責 slot 的 Master）
+	RoleMaster NodeRole = "master"
+	// RoleReplica 複寫 Master 的 Replica
+	RoleReplica NodeRole = "replica"
+	// RoleLeader Raft Leader
+	RoleLeader NodeRole = "leader"
+	// RoleFollower Raft Follower
+	RoleFollower NodeRole = "follower"
+	// RoleCandidate 選舉中的 Raft 節點
+	RoleCandidate NodeRole = "candidate"
+	// RoleUnknown 無法取得角色（通常是節點無法連線）
+	RoleUnknown NodeRole = "unknown"
+)
+
+// TopologyNode 拓樸中的一個節點
+type TopologyNode struct {
+	Address     string      `json:"address"`
+	ID          string      `json:"id,omitempty"` // Cluster node ID 或 Raft node ID
+	Role        NodeRole    `json:"role"`
+	Healthy     bool        `json:"healthy"`
+	Parent      string      `json:"parent,omitempty"`       // 複寫來源（Replica 的 Master、Follower 的 Leader）
+	Slots       []SlotRange `json:"slots,omitempty"`        // Cluster 模式負責的 slot
+	LinkState   string      `json:"link_state,omitempty"`   // Cluster 模式的 link state
+	Term        int64       `json:"term,omitempty"`         // Raft 模式節點回報的 term
+	CommitIndex int64       `json:"commit_index,omitempty"` // Raft 模式節點回報的 commit index
+	Error       string      `json:"error,omitempty"`        // 節點不健康的原因
+}
+
+// Topology 各模式共用的部署拓樸
+type Topology struct {
+	Mode    string         `json:"mode"`
+	Nodes   []TopologyNode `json:"nodes"`
+	Cluster *ClusterInfo   `json:"cluster,omitempty"` // 僅 Cluster 模式
+	Raft    *RaftInfo      `json:"raft,omitempty"`    // 僅 Raft 模式，為 Leader 的 RAFT.INFO
+}
+
+// Primaries 取得所有負責寫入的節點（Master 或 Leader）
+func (t Topology) Primaries() []TopologyNode {
+	var primaries []TopologyNode
+	for _, node := range t.Nodes {
+		if node.Role == RoleMaster || node.Role == RoleLeader {
+			primaries = append(primaries, node)
+		}
+	}
+	return primaries
+}
+
+// HealthyCount 計算健康節點的數量
+func (t Topology) HealthyCount() int {
+	healthy := 0
+	for _, node := range t.Nodes {
+		if node.Healthy {
+			healthy++
+		}
+	}
+	return healthy
+}
+
+// ClusterInfo CLUSTER INFO 的解析結果
+type ClusterInfo struct {
+	State            string `json:"state"` // ok 或 fail
+	SlotsAssigned    int    `json:"slots_assigned"`
+	SlotsOK          int    `json:"slots_ok"`
+	SlotsPFail       int    `json:"slots_pfail"`
+	SlotsFail        int    `json:"slots_fail"`
+	KnownNodes       int    `json:"known_nodes"`
+	Size             int    `json:"size"` // 負責至少一個 slot 的 Master 數量
+	CurrentEpoch     int64  `json:"current_epoch"`
+	MyEpoch          int64  `json:"my_epoch"`
+	MessagesSent     int64  `json:"messages_sent"`
+	MessagesReceived int64  `json:"messages_received"`
+}
+
+// SlotRange 連續的 hash slot 範圍（包含 Start 與 End）
+type SlotRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// SlotMigration 正在搬移中的 slot
+type SlotMigration struct {
+	Slot      int    `json:"slot"`
+	Importing bool   `json:"importing"` // true 表示從 NodeID 匯入，false 表示搬出到 NodeID
+	NodeID    string `json:"node_id"`
+}
+
+// ClusterNode CLUSTER NODES 中的一個節點
+type ClusterNode struct {
+	ID          string          `json:"id"`
+	Addr        string          `json:"addr"` // host:port，節點尚未得知位址時為空
+	BusPort     int             `json:"bus_port"`
+	Hostname    string          `json:"hostname,omitempty"`
+	Flags       []string        `json:"flags"`
+	Role        string          `json:"role"`                // master 或 replica
+	MasterID    string          `json:"master_id,omitempty"` // Replica 所屬的 Master
+	Myself      bool            `json:"myself"`
+	Failing     bool            `json:"failing"` // 被標記為 fail 或 fail?
+	PingSent    int64           `json:"ping_sent"`
+	PongRecv    int64           `json:"pong_recv"`
+	ConfigEpoch int64           `json:"config_epoch"`
+	LinkState   string          `json:"link_state"` // connected 或 disconnected
+	Slots       []SlotRange     `json:"slots"`
+	Migrations  []SlotMigration `json:"migrations,omitempty"`
+}
+
+// SlotCount 計算節點負責的 slot 數量
+func (n ClusterNode) SlotCount() int {
+	count := 0
+	for _, r := range n.Slots {
+		count += r.End - r.Start + 1
+	}
+	return count
+}
+
+// RaftNode RAFT.INFO 中列出的其他節點
+type RaftNode struct {
+	ID           int64  `json:"id"`
+	State        string `json:"state"` // connected、connecting 等連線狀態
+	Voting       bool   `json:"voting"`
+	Addr         string `json:"addr"`
+	LastConnSecs int64  `json:"last_conn_secs"`
+	ConnErrors   int64  `json:"conn_errors"`
+	ConnOKs      int64  `json:"conn_oks"`
+}
+
+// RaftInfo RAFT.INFO 的解析結果
+type RaftInfo struct {
+	DBID             string     `json:"dbid"`
+	NodeID           int64      `json:"node_id"`
+	State            string     `json:"state"` // up、loading 等節點狀態
+	Role             string     `json:"role"`  // leader、follower 或 candidate
+	Voting           bool       `json:"voting"`
+	LeaderID         int64      `json:"leader_id"` // -1 表示目前沒有 Leader
+	CurrentTerm      int64      `json:"current_term"`
+	NumNodes         int        `json:"num_nodes"`
+	NumVotingNodes   int        `json:"num_voting_nodes"`
+	LogEntries       int64      `json:"log_entries"`
+	CurrentIndex     int64      `json:"current_index"`
+	CommitIndex      int64      `json:"commit_index"`
+	LastAppliedIndex int64      `json:"last_applied_index"`
+	Nodes            []RaftNode `json:"nodes"`
+}
+
+// Leader 取得 Leader 節點的位址，self 為查詢的節點位址（RAFT.INFO 不含自己的位址）
+func (i RaftInfo) Leader(self string) (string, bool) {
+	if i.Role == "leader" || (i.LeaderID >= 0 && i.LeaderID == i.NodeID) {
+		return self, true
+	}
+	if i.LeaderID < 0 {
+		return "", false
+	}
+	for _, node := range i.Nodes {
+		if node.ID == i.LeaderID && node.Addr != "" {
+			return node.Addr, true
+		}
+	}
+	return "", false
+}
+
+// ParseInfo 解析 INFO 類指令的 "key:value" 輸出，忽略空行與 "# Section" 標題
+func ParseInfo(info string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// InfoInt 從 ParseInfo 的結果取得整數值，欄位不存在或格式錯誤時返回 -1
+func InfoInt(fields map[string]string, key string) int64 {
+	v, ok := fields[key]
+	if !ok {
+		return -1
+	}
+	return parseInt(v)
+}
+
+// ParseClusterInfo 解析 CLUSTER INFO 的輸出
+func ParseClusterInfo(s string) (ClusterInfo, error) {
+	fields := ParseInfo(s)
+	if fields["cluster_state"] == "" {
+		return ClusterInfo{}, fmt.Errorf("%w: cluster_state not found in CLUSTER INFO", ErrInvalidReply)
+	}
+
+	return ClusterInfo{
+		State:            fields["cluster_state"],
+		SlotsAssigned:    int(InfoInt(fields, "cluster_slots_assigned")),
+		SlotsOK:          int(InfoInt(fields, "cluster_slots_ok")),
+		SlotsPFail:       int(InfoInt(fields, "cluster_slots_pfail")),
+		SlotsFail:        int(InfoInt(fields, "cluster_slots_fail")),
+		KnownNodes:       int(InfoInt(fields, "cluster_known_nodes")),
+		Size:             int(InfoInt(fields, "cluster_size")),
+		CurrentEpoch:     InfoInt(fields, "cluster_current_epoch"),
+		MyEpoch:          InfoInt(fields, "cluster_my_epoch"),
+		MessagesSent:     InfoInt(fields, "cluster_stats_messages_sent"),
+		MessagesReceived: InfoInt(fields, "cluster_stats_messages_received"),
+	}, nil
+}
+
+// ParseClusterNodes 解析 CLUSTER NODES 的輸出，結果依位址排序
+// 每行格式：<id> <ip:port@cport[,hostname]> <flags> <master> <ping-sent> <pong-recv> <config-epoch> <link-state> <slot> ...
+func ParseClusterNodes(s string) ([]ClusterNode, error) {
+	var nodes []ClusterNode
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		node, err := parseClusterNode(line)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].Addr < nodes[j].Addr })
+	return nodes, nil
+}
+
+// parseClusterNode 解析 CLUSTER NODES 的一行
+func parseClusterNode(line string) (ClusterNode, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 8 {
+		return ClusterNode{}, fmt.Errorf("%w: malformed CLUSTER NODES line %q", ErrInvalidReply, line)
+	}
+
+	node := ClusterNode{
+		ID:          fields[0],
+		Flags:       strings.Split(fields[2], ","),
+		Role:        "master",
+		PingSent:    parseInt(fields[4]),
+		PongRecv:    parseInt(fields[5]),
+		ConfigEpoch: parseInt(fields[6]),
+		LinkState:   fields[7],
+		Slots:       []SlotRange{},
+	}
+
+	// ip:port@cport[,hostname]
+	addr, hostname, _ := strings.Cut(fields[1], ",")
+	node.Hostname = hostname
+	addr, busPort, _ := strings.Cut(addr, "@")
+	node.BusPort = int(parseInt(busPort))
+	// 尚未得知位址的節點顯示為 ":0"；IPv6 位址不含中括號，以最後一個冒號分隔
+	if i := strings.LastIndexByte(addr, ':'); i > 0 && addr[i+1:] != "0" {
+		node.Addr = net.JoinHostPort(addr[:i], addr[i+1:])
+	}
+
+	for _, flag := range node.Flags {
+		switch flag {
+		case "myself":
+			node.Myself = true
+		case "slave":
+			node.Role = "replica"
+		case "fail", "fail?":
+			node.Failing = true
+		}
+	}
+	if fields[3] != "-" {
+		node.MasterID = fields[3]
+	}
+
+	for _, slot := range fields[8:] {
+		if strings.HasPrefix(slot, "[") {
+			migration, err := parseSlotMigration(slot)
+			if err != nil {
+				return ClusterNode{}, err
+			}
+			node.Migrations = append(node.Migrations, migration)
+			continue
+		}
+		slotRange, err := parseSlotRange(slot)
+		if err != nil {
+			return ClusterNode{}, err
+		}
+		node.Slots = append(node.Slots, slotRange)
+	}
+	return node, nil
+}
+
+// parseSlotRange 解析 "0-5460" 或單一 slot "42"
+func parseSlotRange(s string) (SlotRange, error) {
+	startStr, endStr, isRange := strings.Cut(s, "-")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return SlotRange{}, fmt.Errorf("%w: invalid slot %q", ErrInvalidReply, s)
+	}
+	end := start
+	if isRange {
+		if end, err = strconv.Atoi(endStr); err != nil {
+			return SlotRange{}, fmt.Errorf("%w: invalid slot %q", ErrInvalidReply, s)
+		}
+	}
+	return SlotRange{Start: start, End: end}, nil
+}
+
+// parseSlotMigration 解析 "[slot->-node]"（搬出）或 "[slot-<-node]"（匯入）
+func parseSlotMigration(s string) (SlotMigration, error) {
+	inner := strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	if slot, nodeID, ok := strings.Cut(inner, "->-"); ok {
+		n, err := strconv.Atoi(slot)
+		if err == nil {
+			return SlotMigration{Slot: n, NodeID: nodeID}, nil
+		}
+	}
+	if slot, nodeID, ok := strings.Cut(inner, "-<-"); ok {
+		n, err := strconv.Atoi(slot)
+		if err == nil {
+			return SlotMigration{Slot: n, Importing: true, NodeID: nodeID}, nil
+		}
+	}
+	return SlotMigration{}, fmt.Errorf("%w: invalid slot migration %q", ErrInvalidReply, s)
+}
+
+// ParseRaftInfo 解析 RAFT.INFO 的輸出
+// 其他節點以 nodeN:id=2,state=connected,voting=yes,addr=host,port=5002,... 的格式列出
+func ParseRaftInfo(s string) (RaftInfo, error) {
+	fields := ParseInfo(s)
+	if _, ok := fields["node_id"]; !ok {
+		return RaftInfo{}, fmt.Errorf("%w: node_id not found in RAFT.INFO", ErrInvalidReply)
+	}
+
+	info := RaftInfo{
+		DBID:             fields["dbid"],
+		NodeID:           InfoInt(fields, "node_id"),
+		State:            fields["state"],
+		Role:             fields["role"],
+		Voting:           fields["is_voting"] == "yes",
+		LeaderID:         InfoInt(fields, "leader_id"),
+		CurrentTerm:      InfoInt(fields, "current_term"),
+		NumNodes:         int(InfoInt(fields, "num_nodes")),
+		NumVotingNodes:   int(InfoInt(fields, "num_voting_nodes")),
+		LogEntries:       InfoInt(fields, "log_entries"),
+		CurrentIndex:     InfoInt(fields, "current_index"),
+		CommitIndex:      InfoInt(fields, "commit_index"),
+		LastAppliedIndex: InfoInt(fields, "last_applied_index"),
+		Nodes:            []RaftNode{},
+	}
+
+	for key, value := range fields {
+		if !strings.HasPrefix(key, "node") || key == "node_id" {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimPrefix(key, "node")); err != nil {
+			continue
+		}
+
+		attrs := make(map[string]string)
+		for _, pair := range strings.Split(value, ",") {
+			if k, v, ok := strings.Cut(pair, "="); ok {
+				attrs[k] = v
+			}
+		}
+		node := RaftNode{
+			ID:           parseInt(attrs["id"]),
+			State:        attrs["state"],
+			Voting:       attrs["voting"] == "yes",
+			LastConnSecs: parseInt(attrs["last_conn_secs"]),
+			ConnErrors:   parseInt(attrs["conn_errors"]),
+			ConnOKs:      parseInt(attrs["conn_oks"]),
+		}
+		if attrs["addr"] != "" && attrs["port"] != "" {
+			node.Addr = net.JoinHostPort(attrs["addr"], attrs["port"])
+		}
+		info.Nodes = append(info.Nodes, node)
+	}
+
+	sort.Slice(info.Nodes, func(i, j int) bool { return info.Nodes[i].ID < info.Nodes[j].ID })
+	return info, nil
+}
+
+// parseInt 解析整數，格式錯誤時返回 -1
+func parseInt(s string) int64 {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
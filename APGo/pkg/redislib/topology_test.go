@@ -0,0 +1,154 @@
+package redislib
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseInfo(t *testing.T) {
+	info := "# Replication\r\nrole:slave\r\nmaster_link_status:up\r\nslave_repl_offset:1234\r\n\r\n"
+	fields := ParseInfo(info)
+
+	if fields["role"] != "slave" {
+		t.Errorf("Expected role slave, got %q", fields["role"])
+	}
+	if fields["master_link_status"] != "up" {
+		t.Errorf("Expected master_link_status up, got %q", fields["master_link_status"])
+	}
+	if got := InfoInt(fields, "slave_repl_offset"); got != 1234 {
+		t.Errorf("Expected slave_repl_offset 1234, got %d", got)
+	}
+	if got := InfoInt(fields, "missing"); got != -1 {
+		t.Errorf("Expected -1 for missing field, got %d", got)
+	}
+}
+
+func TestParseClusterInfo(t *testing.T) {
+	raw := "cluster_state:ok\r\ncluster_slots_assigned:16384\r\ncluster_slots_ok:16384\r\n" +
+		"cluster_slots_pfail:0\r\ncluster_slots_fail:0\r\ncluster_known_nodes:6\r\ncluster_size:3\r\n" +
+		"cluster_current_epoch:6\r\ncluster_my_epoch:2\r\ncluster_stats_messages_sent:1483972\r\n" +
+		"cluster_stats_messages_received:1483968\r\n"
+
+	info, err := ParseClusterInfo(raw)
+	if err != nil {
+		t.Fatalf("ParseClusterInfo failed: %v", err)
+	}
+	want := ClusterInfo{
+		State: "ok", SlotsAssigned: 16384, SlotsOK: 16384, KnownNodes: 6, Size: 3,
+		CurrentEpoch: 6, MyEpoch: 2, MessagesSent: 1483972, MessagesReceived: 1483968,
+	}
+	if info != want {
+		t.Errorf("ParseClusterInfo() = %+v, want %+v", info, want)
+	}
+
+	if _, err := ParseClusterInfo("ERR This instance has cluster support disabled"); !errors.Is(err, ErrInvalidReply) {
+		t.Errorf("Expected ErrInvalidReply for non-cluster reply, got %v", err)
+	}
+}
+
+func TestParseClusterNodes(t *testing.T) {
+	raw := "07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004,node-4 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected\n" +
+		"67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 127.0.0.1:30002@31002 master - 0 1426238316232 2 connected 5461-10922\n" +
+		"e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001 myself,master - 0 0 1 connected 0-5460 16000 [5461->-67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1]\n" +
+		"6ec23923021cf3ffec47632106199cb7f496ce01 127.0.0.1:30005@31005 slave,fail 67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 0 1426238316232 5 disconnected\n" +
+		"824fe116063bc5fcf9f4ffd895bc17aee7731ac3 :0@0 master,noaddr - 1426238316232 0 0 disconnected [100-<-e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca]\n"
+
+	nodes, err := ParseClusterNodes(raw)
+	if err != nil {
+		t.Fatalf("ParseClusterNodes failed: %v", err)
+	}
+	if len(nodes) != 5 {
+		t.Fatalf("Expected 5 nodes, got %d", len(nodes))
+	}
+
+	// 結果依位址排序，沒有位址的節點排在最前面
+	noaddr, myself, master2, replica4, replica5 := nodes[0], nodes[1], nodes[2], nodes[3], nodes[4]
+
+	if noaddr.Addr != "" || len(noaddr.Migrations) != 1 || !noaddr.Migrations[0].Importing || noaddr.Migrations[0].Slot != 100 {
+		t.Errorf("Unexpected noaddr node: %+v", noaddr)
+	}
+
+	if !myself.Myself || myself.Role != "master" || myself.Addr != "127.0.0.1:30001" || myself.BusPort != 31001 {
+		t.Errorf("Unexpected myself node: %+v", myself)
+	}
+	if want := []SlotRange{{0, 5460}, {16000, 16000}}; !reflect.DeepEqual(myself.Slots, want) {
+		t.Errorf("myself slots = %v, want %v", myself.Slots, want)
+	}
+	if myself.SlotCount() != 5462 {
+		t.Errorf("Expected 5462 slots, got %d", myself.SlotCount())
+	}
+	if want := []SlotMigration{{Slot: 5461, NodeID: master2.ID}}; !reflect.DeepEqual(myself.Migrations, want) {
+		t.Errorf("myself migrations = %v, want %v", myself.Migrations, want)
+	}
+
+	if master2.ConfigEpoch != 2 || master2.PongRecv != 1426238316232 || master2.LinkState != "connected" {
+		t.Errorf("Unexpected master node: %+v", master2)
+	}
+
+	if replica4.Role != "replica" || replica4.MasterID != myself.ID || replica4.Hostname != "node-4" || len(replica4.Slots) != 0 {
+		t.Errorf("Unexpected replica node: %+v", replica4)
+	}
+	if !replica5.Failing || replica5.LinkState != "disconnected" {
+		t.Errorf("Expected failing disconnected replica, got %+v", replica5)
+	}
+
+	if _, err := ParseClusterNodes("abc 127.0.0.1:30001 master"); !errors.Is(err, ErrInvalidReply) {
+		t.Errorf("Expected ErrInvalidReply for malformed line, got %v", err)
+	}
+}
+
+func TestParseRaftInfo(t *testing.T) {
+	raw := "# Raft\r\ndbid:c8a2e9a0\r\nnode_id:1\r\nstate:up\r\nrole:follower\r\nis_voting:yes\r\n" +
+		"leader_id:3\r\ncurrent_term:7\r\nnum_nodes:3\r\nnum_voting_nodes:3\r\n" +
+		"node1:id=3,state=connected,voting=yes,addr=10.0.0.3,port=5003,last_conn_secs=120,conn_errors=0,conn_oks=1\r\n" +
+		"node0:id=2,state=connecting,voting=no,addr=10.0.0.2,port=5002,last_conn_secs=-1,conn_errors=4,conn_oks=0\r\n" +
+		"\r\n# Log\r\nlog_entries:42\r\ncurrent_index:45\r\ncommit_index:44\r\nlast_applied_index:44\r\n"
+
+	info, err := ParseRaftInfo(raw)
+	if err != nil {
+		t.Fatalf("ParseRaftInfo failed: %v", err)
+	}
+	if info.NodeID != 1 || info.Role != "follower" || !info.Voting || info.LeaderID != 3 || info.CurrentTerm != 7 ||
+		info.NumNodes != 3 || info.CommitIndex != 44 || info.CurrentIndex != 45 || info.LogEntries != 42 {
+		t.Errorf("Unexpected raft info: %+v", info)
+	}
+
+	wantNodes := []RaftNode{
+		{ID: 2, State: "connecting", Addr: "10.0.0.2:5002", LastConnSecs: -1, ConnErrors: 4},
+		{ID: 3, State: "connected", Voting: true, Addr: "10.0.0.3:5003", LastConnSecs: 120, ConnOKs: 1},
+	}
+	if !reflect.DeepEqual(info.Nodes, wantNodes) {
+		t.Errorf("nodes = %+v, want %+v", info.Nodes, wantNodes)
+	}
+
+	if _, err := ParseRaftInfo("ERR unknown command"); !errors.Is(err, ErrInvalidReply) {
+		t.Errorf("Expected ErrInvalidReply, got %v", err)
+	}
+}
+
+func TestRaftInfoLeader(t *testing.T) {
+	peers := []RaftNode{{ID: 2, Addr: "10.0.0.2:5002"}, {ID: 3, Addr: "10.0.0.3:5003"}}
+
+	tests := []struct {
+		name       string
+		info       RaftInfo
+		wantLeader string
+		wantOK     bool
+	}{
+		{"self is leader", RaftInfo{NodeID: 1, Role: "leader", LeaderID: 1}, "10.0.0.1:5001", true},
+		{"leader id matches self", RaftInfo{NodeID: 1, Role: "candidate", LeaderID: 1}, "10.0.0.1:5001", true},
+		{"follower points to leader", RaftInfo{NodeID: 1, Role: "follower", LeaderID: 2, Nodes: peers}, "10.0.0.2:5002", true},
+		{"no leader", RaftInfo{NodeID: 1, Role: "follower", LeaderID: -1, Nodes: peers}, "", false},
+		{"leader not listed", RaftInfo{NodeID: 1, Role: "follower", LeaderID: 9, Nodes: peers}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leader, ok := tt.info.Leader("10.0.0.1:5001")
+			if leader != tt.wantLeader || ok != tt.wantOK {
+				t.Errorf("Leader() = (%q, %v), want (%q, %v)", leader, ok, tt.wantLeader, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+package redislib
+
+import "time"
+
+// NoTTL 表示 Key 存在但沒有設定過期時間
+// 對應 Redis TTL 指令回傳的 -1
+const NoTTL time.Duration = -1